@@ -2,31 +2,166 @@
 package main
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/joho/godotenv"
-
+	"go-next-todo/backend/internal/config"
 	"go-next-todo/backend/internal/database"
+	"go-next-todo/backend/internal/jobs"
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/migrations"
+	"go-next-todo/backend/internal/password"
+	"go-next-todo/backend/internal/repositories"
 	"go-next-todo/backend/internal/routes"
+	"go-next-todo/backend/internal/services"
+	"go-next-todo/backend/internal/tracing"
 )
 
 func main() {
-	err := godotenv.Load("../../../.env")
+	cfg, args, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Printf("Error loading .env file (this is fine if using explicit env vars): %v", err)
+		fatal("failed to load configuration", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("invalid configuration", err)
 	}
-	if os.Getenv("JWT_SECRET") == "" {
-		log.Fatal("Fatal: JWT_SECRET environment variable is not set. Please set it in your .env file.")
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log))
+	password.SetDefaultHasher(cfg.Password.Hasher())
+	repositories.SetQueryTimeout(time.Duration(cfg.Database.QueryTimeout))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, os.Stdout)
+	if err != nil {
+		fatal("failed to set up tracing", err)
 	}
 
-	db := database.InitDB()
+	db := database.InitDB(cfg.Database)
 	defer db.Close()
 
-	router := routes.SetupRouter(db)
+	migrator, err := migrations.New(db)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+	// "migrate up|down [n]|status" が指定された場合はマイグレーションだけを実行して終了
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := migrations.Run(migrator, args[1:], os.Stdout); err != nil {
+			fatal("migration failed", err)
+		}
+		return
+	}
+	// database.auto_migrate が無効な場合は起動時にマイグレーションを適用しない
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up()
+		if err != nil {
+			fatal("failed to apply migrations", err)
+		}
+		for _, m := range applied {
+			slog.Info("applied migration", "version", m.Version, "name", m.Name)
+		}
+	}
+
+	if err := services.NewRoleService(repositories.NewMySQLRoleRepo(db)).EnsureBuiltInRoles(); err != nil {
+		fatal("failed to ensure built-in roles", err)
+	}
+
+	// SIGINT / SIGTERM を受け取ったら新しい接続の受け付けを止め、処理中のリクエストの完了を待ってから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var backgroundJobs jobs.Group
+	if err := startBackgroundJobs(jobCtx, &backgroundJobs, db, cfg); err != nil {
+		fatal("failed to start background jobs", err)
+	}
+
+	router, err := routes.SetupRouter(db, cfg)
+	if err != nil {
+		fatal("failed to set up router", err)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr(),
+		Handler:           router,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		fatal("server stopped", err)
+	case <-ctx.Done():
+	}
+	stop() // 2回目のシグナルではすぐに終了する
+	slog.Info("shutting down", "timeout", time.Duration(cfg.Server.ShutdownTimeout).String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to drain connections", "error", err)
+	}
 
-	log.Println("Server listening on port 8080...")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatal(err)
+	// ジョブが使っている可能性があるため、データベース接続（defer の db.Close）はジョブの停止後に閉じる
+	stopJobs()
+	backgroundJobs.Wait()
+	// 終了前に、まだ送っていないスパンを送る
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
+	slog.Info("server stopped")
+}
+
+// fatal はエラーを出力して終了します。
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// startBackgroundJobs は定期実行ジョブを group で起動します。ctx がキャンセルされると停止します。
+func startBackgroundJobs(ctx context.Context, group *jobs.Group, db *sql.DB, cfg *config.Config) error {
+	userServiceOptions, err := services.UserServiceOptionsFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	userService := services.NewUserService(
+		repositories.NewUserRepository(db),
+		repositories.NewMySQLResetTokenRepo(db),
+		repositories.NewMySQLVerificationTokenRepo(db),
+		repositories.NewMySQLMagicLinkTokenRepo(db),
+		repositories.NewMySQLSecurityEventRepo(db),
+		userServiceOptions,
+	)
+
+	oidcService := services.NewOIDCService(
+		cfg.OIDC,
+		repositories.NewMySQLOIDCRepo(db),
+		repositories.NewUserRepository(db),
+	)
+	sessionService := services.NewSessionService(repositories.NewMySQLSessionRepo(db))
+
+	// 退会の猶予期間を過ぎたアカウントを削除
+	group.Every(ctx, "purge-deleted-accounts", time.Hour, func() error { return userService.PurgeScheduledDeletions(ctx) })
+	// 期限切れ・使用済みのログインリンクを削除
+	group.Every(ctx, "cleanup-magic-links", time.Hour, userService.CleanupMagicLinks)
+	// 期限切れ・使用済みのパスワードリセットトークンを削除
+	group.Every(ctx, "cleanup-reset-tokens", time.Hour, func() error { return userService.CleanupResetTokens(ctx) })
+	// 期限切れ・使用済みのメール確認トークンを削除
+	group.Every(ctx, "cleanup-verification-tokens", time.Hour, userService.CleanupVerificationTokens)
+	// 期限切れのセッションを削除
+	group.Every(ctx, "cleanup-sessions", time.Hour, sessionService.CleanupExpired)
+	// 使用されなかったOIDCの認可リクエストを削除
+	group.Every(ctx, "cleanup-oidc-requests", time.Hour, oidcService.CleanupExpired)
+	return nil
 }
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"go-next-todo/backend/internal/config"
+)
+
+// printConfig は読み込んだ設定を、秘密情報を伏せてYAMLで出力します。設定が不正な場合は出力した後にエラーを返します。
+func printConfig(cfg *config.Config, w io.Writer) error {
+	if _, err := io.WriteString(w, cfg.String()); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// todoctl はデータベースのマイグレーションやユーザー管理などの運用作業を行うコマンドです。
+//
+//	todoctl migrate up|down [n]|status
+//	todoctl user create -username NAME -email EMAIL [-role ROLE] [-password PASSWORD]
+//	todoctl user promote -email EMAIL [-role ROLE]
+//	todoctl user reset-password -email EMAIL [-password PASSWORD]
+//	todoctl tokens purge
+//	todoctl todos export -email EMAIL [-out FILE]
+//	todoctl todos import -email EMAIL -in FILE [-workspace ID]
+//	todoctl config print
+//
+// コマンドの前に -config FILE や -database.host HOST などの設定フラグを指定できます（internal/config を参照）。
+// -password を省略した場合は標準入力の1行目をパスワードとして読み込みます（シェルの履歴に残さないため）。
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go-next-todo/backend/internal/config"
+	"go-next-todo/backend/internal/database"
+	"go-next-todo/backend/internal/migrations"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/password"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/services"
+)
+
+const usage = `usage: todoctl [config flags] <command> [arguments]
+
+commands:
+  migrate up|down [n]|status   manage the database schema
+  user create                  create a user (e.g. the first admin)
+  user promote                 change a user's role
+  user reset-password          set a user's password and sign out all sessions
+  tokens purge                 delete expired and used password reset tokens
+  todos export                 write a user's todos as JSON
+  todos import                 create todos for a user from a JSON export
+  config print                 print the effective configuration with secrets redacted
+
+Run "todoctl -h" for the config flags and "todoctl <command> -h" for the flags of each command.`
+
+// errUsage はコマンドの指定が正しくない場合のエラーです。
+var errUsage = errors.New(usage)
+
+// cliClient は todoctl からの操作としてセキュリティイベントに記録するクライアント情報です。
+var cliClient = models.ClientInfo{UserAgent: "todoctl"}
+
+func main() {
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "todoctl:", err)
+		os.Exit(2)
+	}
+	if err := run(cfg, args, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "todoctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run は args に従ってサブコマンドを実行します。
+func run(cfg *config.Config, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+
+	// データベースに接続しないコマンド
+	if args[0] == "config" {
+		if len(args) < 2 || args[1] != "print" {
+			return errUsage
+		}
+		return printConfig(cfg, stdout)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	password.SetDefaultHasher(cfg.Password.Hasher())
+	repositories.SetQueryTimeout(time.Duration(cfg.Database.QueryTimeout))
+
+	db := database.InitDB(cfg.Database)
+	defer db.Close()
+	a, err := newApp(db, cfg, stdin, stdout)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "migrate":
+		m, err := migrations.New(db)
+		if err != nil {
+			return err
+		}
+		return migrations.Run(m, args[1:], stdout)
+	case "user":
+		return a.runUser(args[1:])
+	case "tokens":
+		if len(args) < 2 || args[1] != "purge" {
+			return errUsage
+		}
+		if err := a.userService.CleanupResetTokens(context.Background()); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "expired and used password reset tokens deleted")
+		return nil
+	case "todos":
+		return a.runTodos(args[1:])
+	}
+	return errUsage
+}
+
+// app は各サブコマンドが使うリポジトリとサービスです。
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+
+	userRepo         *repositories.UserRepository
+	todoRepo         *repositories.TodoRepository
+	userService      *services.UserService
+	roleService      *services.RoleService
+	todoService      *services.TodoService
+	workspaceService *services.WorkspaceService
+}
+
+func newApp(db *sql.DB, cfg *config.Config, stdin io.Reader, stdout io.Writer) (*app, error) {
+	userServiceOptions, err := services.UserServiceOptionsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	userRepo := repositories.NewUserRepository(db)
+	todoRepo := repositories.NewTodoRepository(db)
+	return &app{
+		stdin:    stdin,
+		stdout:   stdout,
+		userRepo: userRepo,
+		todoRepo: todoRepo,
+		userService: services.NewUserService(
+			userRepo,
+			repositories.NewMySQLResetTokenRepo(db),
+			repositories.NewMySQLVerificationTokenRepo(db),
+			repositories.NewMySQLMagicLinkTokenRepo(db),
+			repositories.NewMySQLSecurityEventRepo(db),
+			userServiceOptions,
+		),
+		roleService:      services.NewRoleService(repositories.NewMySQLRoleRepo(db)),
+		todoService:      services.NewTodoService(todoRepo),
+		workspaceService: services.NewWorkspaceService(repositories.NewMySQLWorkspaceRepo(db), userRepo),
+	}, nil
+}
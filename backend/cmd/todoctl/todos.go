@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"go-next-todo/backend/internal/models"
+)
+
+func (a *app) runTodos(args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	switch args[0] {
+	case "export":
+		return a.exportTodos(args[1:])
+	case "import":
+		return a.importTodos(args[1:])
+	}
+	return errUsage
+}
+
+// exportTodos はユーザーがすべてのワークスペースで作成したTodoをJSON配列として出力します。
+func (a *app) exportTodos(args []string) error {
+	fs := flag.NewFlagSet("todos export", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	out := fs.String("out", "", "output file (stdout when omitted)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	user, err := a.findUser(*email)
+	if err != nil {
+		return err
+	}
+	todos, err := a.todoRepo.FindByUserIDAcrossWorkspaces(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	w := a.stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(todos); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintf(a.stdout, "exported %d todos to %s\n", len(todos), *out)
+	}
+	return nil
+}
+
+// importTodos は todos export の出力（またはエクスポートAPIの todos.json）からTodoを作成します。
+// 取り込むのはタイトルと完了状態だけで、IDや作成日時は新しく採番されます。
+func (a *app) importTodos(args []string) error {
+	fs := flag.NewFlagSet("todos import", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user who will own the todos (required)")
+	in := fs.String("in", "", "JSON file to import, or - for stdin (required)")
+	workspace := fs.Int("workspace", 0, "workspace ID (the user's default workspace when omitted)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("todos import: -in is required")
+	}
+	user, err := a.findUser(*email)
+	if err != nil {
+		return err
+	}
+
+	workspaceID := *workspace
+	if workspaceID == 0 {
+		ws, err := a.workspaceService.DefaultWorkspace(context.Background(), user.ID)
+		if err != nil {
+			return err
+		}
+		workspaceID = ws.ID
+	} else if _, err := a.workspaceService.Resolve(context.Background(), user.ID, strconv.Itoa(workspaceID)); err != nil {
+		return fmt.Errorf("user %s is not a member of workspace %d: %w", user.Email, workspaceID, err)
+	}
+
+	var r io.Reader = a.stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var todos []models.Todo
+	if err := json.NewDecoder(r).Decode(&todos); err != nil {
+		return fmt.Errorf("could not decode %s: %w", *in, err)
+	}
+
+	for i, t := range todos {
+		if t.Title == "" {
+			return fmt.Errorf("todo #%d has no title; imported %d todos", i+1, i)
+		}
+		if _, err := a.todoService.CreateTodo(context.Background(), workspaceID, &models.Todo{Title: t.Title, Completed: t.Completed}, user.ID); err != nil {
+			return fmt.Errorf("could not import todo #%d: %w; imported %d todos", i+1, err, i)
+		}
+	}
+	fmt.Fprintf(a.stdout, "imported %d todos into workspace %d\n", len(todos), workspaceID)
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/password"
+	"go-next-todo/backend/internal/repositories"
+)
+
+func (a *app) runUser(args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	switch args[0] {
+	case "create":
+		return a.createUser(args[1:])
+	case "promote":
+		return a.promoteUser(args[1:])
+	case "reset-password":
+		return a.resetPassword(args[1:])
+	}
+	return errUsage
+}
+
+func (a *app) createUser(args []string) error {
+	fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := fs.String("username", "", "username (required)")
+	email := fs.String("email", "", "email address (required)")
+	role := fs.String("role", models.RoleUser, "role to assign")
+	pw := fs.String("password", "", "password (read from stdin when omitted)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return errors.New("user create: -username and -email are required")
+	}
+	if _, err := a.roleService.GetRole(*role); err != nil {
+		return fmt.Errorf("role %q: %w", *role, err)
+	}
+	plain, err := a.passwordArg(*pw)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.userService.CreateUser(context.Background(), models.UserRegisterRequest{
+		Username: *username,
+		Email:    *email,
+		Password: plain,
+	}, *role)
+	if err != nil {
+		return describePasswordError(err)
+	}
+	fmt.Fprintf(a.stdout, "created user %d (%s) with role %s\n", user.ID, user.Email, user.Role)
+	return nil
+}
+
+func (a *app) promoteUser(args []string) error {
+	fs := flag.NewFlagSet("user promote", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	role := fs.String("role", models.RoleAdmin, "role to assign")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	user, err := a.findUser(*email)
+	if err != nil {
+		return err
+	}
+
+	// todoctl の操作者はユーザーとして存在しないため actorID は0（本人の操作として記録）にする
+	updated, err := a.userService.ChangeRole(context.Background(), 0, uint(user.ID), *role, cliClient)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRoleNotFound) {
+			return fmt.Errorf("role %q does not exist", *role)
+		}
+		return err
+	}
+	fmt.Fprintf(a.stdout, "user %d (%s) now has role %s\n", updated.ID, updated.Email, updated.Role)
+	return nil
+}
+
+func (a *app) resetPassword(args []string) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	pw := fs.String("password", "", "new password (read from stdin when omitted)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	user, err := a.findUser(*email)
+	if err != nil {
+		return err
+	}
+	plain, err := a.passwordArg(*pw)
+	if err != nil {
+		return err
+	}
+
+	if err := a.userService.SetPassword(context.Background(), 0, uint(user.ID), plain, cliClient); err != nil {
+		return describePasswordError(err)
+	}
+	fmt.Fprintf(a.stdout, "password updated for user %d (%s); existing sessions were signed out\n", user.ID, user.Email)
+	return nil
+}
+
+// findUser はメールアドレスでユーザーを探します。
+func (a *app) findUser(email string) (*models.User, error) {
+	if email == "" {
+		return nil, errors.New("-email is required")
+	}
+	user, err := a.userRepo.FindByEmail(context.Background(), email)
+	if err == repositories.ErrUserNotFound {
+		return nil, fmt.Errorf("no user with email %s", email)
+	}
+	return user, err
+}
+
+// passwordArg は -password の値を返します。省略された場合は標準入力の1行目を読み込みます。
+func (a *app) passwordArg(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	line, err := bufio.NewReader(a.stdin).ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		if err != nil {
+			return "", fmt.Errorf("could not read password from stdin: %w", err)
+		}
+		return "", errors.New("password must not be empty")
+	}
+	return line, nil
+}
+
+// describePasswordError はパスワードポリシー違反を読みやすいエラーにします。
+func describePasswordError(err error) error {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return err
+	}
+	msgs := make([]string, 0, len(policyErr.Violations))
+	for _, v := range policyErr.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return fmt.Errorf("password does not meet the password policy: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,385 @@
+// Package config はアプリケーションの設定を扱います。
+//
+// 設定はデフォルト値 → 設定ファイル（YAML または TOML） → 環境変数 → コマンドラインフラグの順に読み込み、
+// 後から読み込んだものが優先されます。各項目の環境変数名は env タグ、フラグ名は "<セクション>.<項目>"
+// （例: -server.port、-database.max_open_conns）です。
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+
+	"go-next-todo/backend/internal/password"
+)
+
+// redacted は秘密情報を表示する際の置き換え文字列です。
+const redacted = "********"
+
+// Config はアプリケーション全体の設定です。
+type Config struct {
+	Server   Server   `yaml:"server" toml:"server"`
+	Database Database `yaml:"database" toml:"database"`
+	Auth     Auth     `yaml:"auth" toml:"auth"`
+	Mail     Mail     `yaml:"mail" toml:"mail"`
+	OIDC     OIDC     `yaml:"oidc" toml:"oidc"`
+	Password Password `yaml:"password" toml:"password"`
+	Log      Log      `yaml:"log" toml:"log"`
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+	Metrics  Metrics  `yaml:"metrics" toml:"metrics"`
+}
+
+// Server はHTTPサーバーの設定です。
+type Server struct {
+	Port        int      `yaml:"port" toml:"port" env:"PORT"`
+	CORSOrigins []string `yaml:"cors_origins" toml:"cors_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// FrontendURL はメール本文に埋め込むフロントエンドのURLです。
+	FrontendURL string `yaml:"frontend_url" toml:"frontend_url" env:"FRONTEND_URL"`
+	// ReadHeaderTimeout・ReadTimeout・WriteTimeout・IdleTimeout は http.Server の同名のタイムアウトです。
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout は終了シグナルを受け取ってから処理中のリクエストの完了を待つ最大時間です。
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+// Database はMySQLへの接続設定です。
+type Database struct {
+	User            string   `yaml:"user" toml:"user" env:"DB_USER"`
+	Password        string   `yaml:"password" toml:"password" env:"DB_PASS" secret:"true"`
+	Host            string   `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port            int      `yaml:"port" toml:"port" env:"DB_PORT"`
+	Name            string   `yaml:"name" toml:"name" env:"DB_NAME"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	// QueryTimeout は1回のデータベース操作にかける最大時間です。0 の場合は期限を設けません。
+	QueryTimeout Duration `yaml:"query_timeout" toml:"query_timeout" env:"DB_QUERY_TIMEOUT"`
+	// AutoMigrate が有効な場合、起動時に未適用のマイグレーションを適用します。
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+// Auth は認証に関する設定です。
+type Auth struct {
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	// CookieMode が有効な場合、ログイン時にJWTをレスポンスボディではなく HttpOnly Cookie で返します。
+	CookieMode bool `yaml:"cookie_mode" toml:"cookie_mode" env:"AUTH_COOKIE_MODE"`
+	// CookieSecure は Cookie の Secure 属性です。HTTP で動かす開発環境では無効にします。
+	CookieSecure   bool   `yaml:"cookie_secure" toml:"cookie_secure" env:"AUTH_COOKIE_SECURE"`
+	CookieSameSite string `yaml:"cookie_samesite" toml:"cookie_samesite" env:"AUTH_COOKIE_SAMESITE"`
+	CookieDomain   string `yaml:"cookie_domain" toml:"cookie_domain" env:"AUTH_COOKIE_DOMAIN"`
+	// EmailVerificationMode はメールアドレス確認を必須にする範囲です（optional / login / todos）。
+	EmailVerificationMode string `yaml:"email_verification_mode" toml:"email_verification_mode" env:"EMAIL_VERIFICATION_MODE"`
+	// AccountDeletionGraceDays は退会手続きから完全削除までの猶予日数です。
+	AccountDeletionGraceDays int `yaml:"account_deletion_grace_days" toml:"account_deletion_grace_days" env:"ACCOUNT_DELETION_GRACE_DAYS"`
+}
+
+// Mail はメール送信（SMTP）の設定です。
+type Mail struct {
+	SMTPHost string `yaml:"smtp_host" toml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort int    `yaml:"smtp_port" toml:"smtp_port" env:"SMTP_PORT"`
+	Username string `yaml:"username" toml:"username" env:"SMTP_USER"`
+	Password string `yaml:"password" toml:"password" env:"SMTP_PASSWORD" secret:"true"`
+	// From は送信元アドレスです。空の場合は Username を使います。
+	From string `yaml:"from" toml:"from" env:"SMTP_FROM"`
+}
+
+// OIDC はOIDCリライングパーティーの設定です。IssuerURL が空の場合はOIDCログインを無効にします。
+type OIDC struct {
+	IssuerURL    string   `yaml:"issuer_url" toml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID     string   `yaml:"client_id" toml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes       []string `yaml:"scopes" toml:"scopes" env:"OIDC_SCOPES"`
+}
+
+// Password はパスワードポリシーとハッシュ化の設定です。
+type Password struct {
+	MinLength     int  `yaml:"min_length" toml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MaxLength     int  `yaml:"max_length" toml:"max_length" env:"PASSWORD_MAX_LENGTH"`
+	MinScore      int  `yaml:"min_score" toml:"min_score" env:"PASSWORD_MIN_SCORE"`
+	RequireUpper  bool `yaml:"require_upper" toml:"require_upper" env:"PASSWORD_REQUIRE_UPPER"`
+	RequireLower  bool `yaml:"require_lower" toml:"require_lower" env:"PASSWORD_REQUIRE_LOWER"`
+	RequireDigit  bool `yaml:"require_digit" toml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol bool `yaml:"require_symbol" toml:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL"`
+	// BreachList は漏洩パスワードのハッシュ一覧（"HASH:COUNT" 形式）のパスです。空の場合は漏洩チェックを行いません。
+	BreachList        string `yaml:"breach_list" toml:"breach_list" env:"PASSWORD_BREACH_LIST"`
+	HashAlgorithm     string `yaml:"hash_algorithm" toml:"hash_algorithm" env:"PASSWORD_HASH_ALGORITHM"`
+	Argon2MemoryKiB   int    `yaml:"argon2_memory_kib" toml:"argon2_memory_kib" env:"PASSWORD_ARGON2_MEMORY_KIB"`
+	Argon2Iterations  int    `yaml:"argon2_iterations" toml:"argon2_iterations" env:"PASSWORD_ARGON2_ITERATIONS"`
+	Argon2Parallelism int    `yaml:"argon2_parallelism" toml:"argon2_parallelism" env:"PASSWORD_ARGON2_PARALLELISM"`
+	BcryptCost        int    `yaml:"bcrypt_cost" toml:"bcrypt_cost" env:"PASSWORD_BCRYPT_COST"`
+}
+
+// Log はログ出力の設定です。
+type Log struct {
+	// Level は出力する最低のレベルです（debug / info / warn / error）。
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// Format は出力形式です（json / text）。
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+// Tracing は OpenTelemetry によるトレースの設定です。
+type Tracing struct {
+	// Exporter はスパンの送信先です（none / otlp / stdout）。none の場合はスパンを記録しません。
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	// Endpoint は OTLP/HTTP でスパンを送るコレクターのURLです。http:// の場合は TLS を使いません。
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	// ServiceName はスパンの service.name です。
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+// Metrics は /metrics エンドポイントの設定です。
+type Metrics struct {
+	// Token を設定すると、/metrics へのリクエストに "Authorization: Bearer <Token>" を必須にします。
+	// 空の場合は誰でも取得できるため、/metrics を公開のネットワークに出さないようにしてください。
+	Token string `yaml:"token" toml:"token" env:"METRICS_TOKEN" secret:"true"`
+}
+
+// Default はデフォルトの設定を返します。JWTSecret やデータベースの接続先など、環境ごとに必要な項目は空です。
+func Default() *Config {
+	policy := password.DefaultPolicy()
+	hasher := password.DefaultHasherConfig()
+	return &Config{
+		Server: Server{
+			Port:              8080,
+			CORSOrigins:       []string{"http://localhost:3000"},
+			FrontendURL:       "http://localhost:3000",
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(15 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(60 * time.Second),
+			ShutdownTimeout:   Duration(20 * time.Second),
+		},
+		Database: Database{
+			Host:            "localhost",
+			Port:            3306,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: Duration(5 * time.Minute),
+			QueryTimeout:    Duration(5 * time.Second),
+			AutoMigrate:     true,
+		},
+		Auth: Auth{
+			CookieSecure:             true,
+			CookieSameSite:           "lax",
+			EmailVerificationMode:    "optional",
+			AccountDeletionGraceDays: 30,
+		},
+		Mail: Mail{
+			SMTPHost: "sandbox.smtp.mailtrap.io",
+			SMTPPort: 2525,
+		},
+		OIDC: OIDC{
+			Scopes: []string{"email", "profile"},
+		},
+		Password: Password{
+			MinLength:         policy.MinLength,
+			MaxLength:         policy.MaxLength,
+			MinScore:          policy.MinScore,
+			HashAlgorithm:     hasher.Algorithm,
+			Argon2MemoryKiB:   int(hasher.Argon2.Memory),
+			Argon2Iterations:  int(hasher.Argon2.Iterations),
+			Argon2Parallelism: int(hasher.Argon2.Parallelism),
+			BcryptCost:        hasher.BcryptCost,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "go-next-todo-backend",
+		},
+	}
+}
+
+// Validate は設定値を検証し、問題をすべてまとめたエラーを返します。
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port %d is out of range", c.Server.Port)
+	for _, origin := range c.Server.CORSOrigins {
+		check(isHTTPURL(origin), "server.cors_origins: %q is not an http(s) origin", origin)
+	}
+	check(isHTTPURL(c.Server.FrontendURL), "server.frontend_url %q is not an http(s) URL", c.Server.FrontendURL)
+	check(c.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout must not be negative")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(c.Database.Host != "", "database.host is required (DB_HOST)")
+	check(c.Database.Name != "", "database.name is required (DB_NAME)")
+	check(c.Database.User != "", "database.user is required (DB_USER)")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port %d is out of range", c.Database.Port)
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns %d exceeds database.max_open_conns %d", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.QueryTimeout >= 0, "database.query_timeout must not be negative")
+
+	check(c.Auth.JWTSecret != "", "auth.jwt_secret is required (JWT_SECRET)")
+	check(oneOf(c.Auth.CookieSameSite, "lax", "strict", "none"), "auth.cookie_samesite %q must be lax, strict or none", c.Auth.CookieSameSite)
+	check(oneOf(c.Auth.EmailVerificationMode, "optional", "login", "todos"),
+		"auth.email_verification_mode %q must be optional, login or todos", c.Auth.EmailVerificationMode)
+	check(c.Auth.AccountDeletionGraceDays >= 0, "auth.account_deletion_grace_days must not be negative")
+
+	check(c.Mail.SMTPPort > 0 && c.Mail.SMTPPort <= 65535, "mail.smtp_port %d is out of range", c.Mail.SMTPPort)
+
+	if c.OIDC.IssuerURL != "" {
+		check(c.OIDC.ClientID != "", "oidc.client_id is required when oidc.issuer_url is set")
+		check(isHTTPURL(c.OIDC.RedirectURL), "oidc.redirect_url must be an http(s) URL when oidc.issuer_url is set")
+	}
+
+	p := c.Password
+	check(p.MinLength >= 0, "password.min_length must not be negative")
+	check(p.MaxLength >= p.MinLength, "password.max_length %d is less than password.min_length %d", p.MaxLength, p.MinLength)
+	check(p.MinScore >= 0 && p.MinScore <= 4, "password.min_score %d must be between 0 and 4", p.MinScore)
+	check(oneOf(p.HashAlgorithm, password.AlgorithmArgon2id, password.AlgorithmBcrypt),
+		"password.hash_algorithm %q must be %s or %s", p.HashAlgorithm, password.AlgorithmArgon2id, password.AlgorithmBcrypt)
+	check(p.Argon2MemoryKiB >= 8*1024 && p.Argon2MemoryKiB <= 4*1024*1024, "password.argon2_memory_kib %d must be between 8192 and 4194304", p.Argon2MemoryKiB)
+	check(p.Argon2Iterations >= 1 && p.Argon2Iterations <= 100, "password.argon2_iterations %d must be between 1 and 100", p.Argon2Iterations)
+	check(p.Argon2Parallelism >= 1 && p.Argon2Parallelism <= 255, "password.argon2_parallelism %d must be between 1 and 255", p.Argon2Parallelism)
+	check(p.BcryptCost >= bcrypt.MinCost && p.BcryptCost <= bcrypt.MaxCost,
+		"password.bcrypt_cost %d must be between %d and %d", p.BcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"), "log.level %q must be debug, info, warn or error", c.Log.Level)
+	check(oneOf(c.Log.Format, "json", "text"), "log.format %q must be json or text", c.Log.Format)
+
+	check(oneOf(c.Tracing.Exporter, "none", "otlp", "stdout"), "tracing.exporter %q must be none, otlp or stdout", c.Tracing.Exporter)
+	if c.Tracing.Exporter == "otlp" {
+		check(isHTTPURL(c.Tracing.Endpoint), "tracing.endpoint %q must be an http(s) URL when tracing.exporter is otlp", c.Tracing.Endpoint)
+	}
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+
+	return errors.Join(errs...)
+}
+
+// DSN はMySQL接続文字列 (DSN) を返します。
+func (d Database) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", d.User, d.Password, d.Host, d.Port, d.Name)
+}
+
+// Addr は http.Server に渡す待ち受けアドレスを返します。
+func (s Server) Addr() string {
+	return fmt.Sprintf(":%d", s.Port)
+}
+
+// Addr はSMTPサーバーのアドレス（host:port）を返します。
+func (m Mail) Addr() string {
+	return net.JoinHostPort(m.SMTPHost, strconv.Itoa(m.SMTPPort))
+}
+
+// SenderAddress は送信元アドレスを返します。
+func (m Mail) SenderAddress() string {
+	if m.From != "" {
+		return m.From
+	}
+	return m.Username
+}
+
+// Enabled はOIDCログインに必要な設定が揃っているかを返します。
+func (o OIDC) Enabled() bool {
+	return o.IssuerURL != "" && o.ClientID != "" && o.RedirectURL != ""
+}
+
+// Policy はパスワードポリシーを返します。BreachList が指定されている場合は漏洩パスワードの一覧を読み込みます。
+func (p Password) Policy() (password.Policy, error) {
+	policy := password.Policy{
+		MinLength:     p.MinLength,
+		MaxLength:     p.MaxLength,
+		MinScore:      p.MinScore,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+	}
+	if p.BreachList != "" {
+		list, err := password.LoadHashPrefixList(p.BreachList)
+		if err != nil {
+			return policy, err
+		}
+		policy.Breached = list
+	}
+	return policy, nil
+}
+
+// Hasher はパスワードのハッシュ設定を返します。
+func (p Password) Hasher() *password.Hasher {
+	h := password.DefaultHasherConfig()
+	h.Algorithm = p.HashAlgorithm
+	h.Argon2.Memory = uint32(p.Argon2MemoryKiB)
+	h.Argon2.Iterations = uint32(p.Argon2Iterations)
+	h.Argon2.Parallelism = uint8(p.Argon2Parallelism)
+	h.BcryptCost = p.BcryptCost
+	return h
+}
+
+// Redacted は秘密情報（secret タグの付いた項目）を伏せた設定のコピーを返します。
+func (c *Config) Redacted() *Config {
+	cp := *c
+	cp.Server.CORSOrigins = append([]string(nil), c.Server.CORSOrigins...)
+	cp.OIDC.Scopes = append([]string(nil), c.OIDC.Scopes...)
+	for _, f := range fields(&cp) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+	return &cp
+}
+
+// String は秘密情報を伏せた設定をYAMLで返します。
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<invalid config: %v>", err)
+	}
+	return string(out)
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func oneOf(s string, allowed ...string) bool {
+	for _, a := range allowed {
+		if s == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration は "5m" や "90s" のような文字列で指定できる time.Duration です。
+type Duration time.Duration
+
+// UnmarshalText は time.ParseDuration の形式の文字列を読み込みます。
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText は time.Duration.String の形式で出力します。
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
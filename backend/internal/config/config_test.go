@@ -0,0 +1,134 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/config"
+)
+
+// validConfig は検証を通る最小限の設定を返します。
+func validConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Database.User = "todo"
+	cfg.Database.Name = "todo"
+	cfg.Auth.JWTSecret = "secret"
+	return cfg
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Accepts the defaults with required values", func(t *testing.T) {
+		assert.NoError(t, validConfig().Validate())
+	})
+
+	t.Run("Reports every problem at once", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Port = 70000
+		cfg.Auth.CookieSameSite = "sometimes"
+		cfg.Server.ShutdownTimeout = 0
+		cfg.Password.MinScore = 5
+		cfg.Tracing.Exporter = "jaeger"
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		for _, want := range []string{"server.port", "server.shutdown_timeout", "database.name", "database.user", "auth.jwt_secret", "auth.cookie_samesite", "password.min_score", "tracing.exporter"} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("Requires client settings when OIDC is enabled", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.OIDC.IssuerURL = "https://idp.example.com"
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "oidc.client_id")
+		assert.Contains(t, err.Error(), "oidc.redirect_url")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("ENV_FILE", filepath.Join(t.TempDir(), "missing.env"))
+	t.Setenv("CONFIG_FILE", "")
+
+	t.Run("Environment overrides the config file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+server:
+  port: 9000
+  cors_origins: ["https://todo.example.com"]
+database:
+  host: db.internal
+  conn_max_lifetime: 90s
+auth:
+  jwt_secret: from-file
+`)
+		t.Setenv("JWT_SECRET", "from-env")
+		t.Setenv("DB_QUERY_TIMEOUT", "2s")
+
+		cfg, args, err := config.Load([]string{"-config", path, "migrate", "up"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"migrate", "up"}, args)
+		assert.Equal(t, 9000, cfg.Server.Port)
+		assert.Equal(t, []string{"https://todo.example.com"}, cfg.Server.CORSOrigins)
+		assert.Equal(t, "db.internal", cfg.Database.Host)
+		assert.Equal(t, 90*time.Second, time.Duration(cfg.Database.ConnMaxLifetime))
+		assert.Equal(t, "from-env", cfg.Auth.JWTSecret)
+		assert.Equal(t, 2*time.Second, time.Duration(cfg.Database.QueryTimeout))
+		// ファイルに書かれていない項目はデフォルト値のまま
+		assert.Equal(t, 3306, cfg.Database.Port)
+	})
+
+	t.Run("Flags override the environment", func(t *testing.T) {
+		path := writeFile(t, "config.toml", `
+[server]
+port = 9000
+
+[oidc]
+scopes = ["email"]
+`)
+		t.Setenv("PORT", "9100")
+		t.Setenv("OIDC_SCOPES", "email profile groups")
+
+		cfg, _, err := config.Load([]string{"-config", path, "-server.port", "9200", "-auth.cookie_mode=true"})
+		require.NoError(t, err)
+		assert.Equal(t, 9200, cfg.Server.Port)
+		assert.True(t, cfg.Auth.CookieMode)
+		assert.Equal(t, []string{"email", "profile", "groups"}, cfg.OIDC.Scopes)
+	})
+
+	t.Run("Rejects malformed values", func(t *testing.T) {
+		t.Setenv("DB_PORT", "not-a-port")
+		_, _, err := config.Load(nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_PORT")
+	})
+
+	t.Run("Rejects unknown file formats", func(t *testing.T) {
+		_, _, err := config.Load([]string{"-config", writeFile(t, "config.json", "{}")})
+		assert.Error(t, err)
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.Password = "db-pass"
+	cfg.Mail.Password = "smtp-pass"
+
+	out := cfg.String()
+	assert.NotContains(t, out, "db-pass")
+	assert.NotContains(t, out, "smtp-pass")
+	assert.NotContains(t, out, "jwt_secret: secret")
+	assert.Contains(t, out, "********")
+	// 元の設定は変更されない
+	assert.Equal(t, "db-pass", cfg.Database.Password)
+}
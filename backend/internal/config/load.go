@@ -0,0 +1,167 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load はデフォルト値・設定ファイル・環境変数・コマンドラインフラグ args から設定を読み込み、
+// フラグ以外の残りの引数（サブコマンドなど）とともに返します。検証は行わないため、呼び出し元で Validate を呼び出してください。
+//
+// 設定ファイルは -config フラグまたは環境変数 CONFIG_FILE で指定し、拡張子（.yaml / .yml / .toml）で形式を判断します。
+// 環境変数を書いた .env ファイルは -env-file フラグまたは環境変数 ENV_FILE で指定でき（デフォルトは ".env"）、
+// 存在する場合のみ読み込みます。.env の値はすでに設定されている環境変数を上書きしません。
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fset := flag.NewFlagSet("config", flag.ContinueOnError)
+	configFile := fset.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML configuration file")
+	envFile := fset.String("env-file", envOr("ENV_FILE", ".env"), "path to a .env file (ignored when missing)")
+	// フラグは他の設定をすべて読み込んだ後に適用するため、ここでは値を記録するだけにする
+	overrides := map[string]string{}
+	for _, f := range fields(cfg) {
+		name := f.path
+		fset.Func(name, fmt.Sprintf("%s (env %s)", name, f.env), func(v string) error {
+			overrides[name] = v
+			return nil
+		})
+	}
+	if err := fset.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if err := godotenv.Load(*envFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("could not load %s: %w", *envFile, err)
+	}
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, nil, err
+	}
+	for _, f := range fields(cfg) {
+		if v, ok := overrides[f.path]; ok {
+			if err := f.set(v); err != nil {
+				return nil, nil, fmt.Errorf("invalid -%s %q: %w", f.path, v, err)
+			}
+		}
+	}
+	return cfg, fset.Args(), nil
+}
+
+// ApplyEnv は環境変数が設定されている項目を上書きします。
+func (c *Config) ApplyEnv() error {
+	for _, f := range fields(c) {
+		v, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+		if err := f.set(v); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", f.env, v, err)
+		}
+	}
+	return nil
+}
+
+// loadFile は設定ファイルを読み込みます。ファイルに書かれていない項目は元の値のままです。
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file format %q (use .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// field は設定の1項目です。
+type field struct {
+	path   string // フラグ名（"<セクション>.<項目>"）
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// fields は cfg の全項目を定義順に返します。
+func fields(cfg *Config) []field {
+	var out []field
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionName := tagName(sections.Type().Field(i))
+		for j := 0; j < section.NumField(); j++ {
+			sf := section.Type().Field(j)
+			out = append(out, field{
+				path:   sectionName + "." + tagName(sf),
+				env:    sf.Tag.Get("env"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+	return out
+}
+
+func tagName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+	return name
+}
+
+// set は文字列の値を項目の型に変換して設定します。リストはカンマまたは空白で区切ります。
+func (f field) set(s string) error {
+	if u, ok := f.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return errors.New("not an integer")
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return errors.New("not a boolean")
+		}
+		f.value.SetBool(b)
+	case reflect.Slice:
+		list := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		f.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+	return nil
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -2,39 +2,39 @@ package database
 // db接続設定を行うディレクトリ
 import (
 	"database/sql"
-	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql"
-)
-
-// GetDSN は環境変数からMySQL接続文字列 (DSN) を構築します。
-func GetDSN() string {
-	// main.go で godotenv.Load() が呼び出されるため、ここでは省略
-	user := os.Getenv("DB_USER")
-	pass := os.Getenv("DB_PASS")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	name := os.Getenv("DB_NAME")
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
 
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, pass, host, port, name)
-}
+	"go-next-todo/backend/internal/config"
+)
 
-// InitDB はデータベース接続を初期化します。
-func InitDB() *sql.DB {
-	dsn := GetDSN()
-	db, err := sql.Open("mysql", dsn)
+// InitDB はデータベース接続を初期化します。SQL 文ごとに、呼び出し元の context.Context のスパンの子スパンを記録します。
+func InitDB(cfg config.Database) *sql.DB {
+	dsn := cfg.DSN()
+	db, err := otelsql.Open("mysql", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNameMySQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
-		log.Fatalf("Fatal: Failed to open database connection: %v", err)
+		slog.Error("failed to open database connection", "error", err)
+		os.Exit(1)
 	}
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
 	if err := db.Ping(); err != nil {
-		log.Fatalf("Fatal: Failed to ping database: %v", err)
+		slog.Error("failed to ping database", "host", cfg.Host, "name", cfg.Name, "error", err)
+		os.Exit(1)
 	}
-	log.Println("Successfully connected to MySQL database!")
+	slog.Info("connected to MySQL database", "host", cfg.Host, "name", cfg.Name)
 	return db
 }
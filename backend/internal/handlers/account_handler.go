@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/services"
+)
+
+// currentUserID はコンテキストからログイン中のユーザーIDを取得します。
+// 取得できない場合はエラーレスポンスを書き込み、false を返します。
+func currentUserID(c *gin.Context) (uint, bool) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return 0, false
+	}
+	userID, ok := userIDVal.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type in context"})
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// clientInfo はセキュリティイベントに記録するリクエスト元の情報を返します。
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// GetMeHandler はログイン中のユーザー情報を返します。
+func (h *UserHandler) GetMeHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateMeHandler はログイン中のユーザーのプロフィール（ユーザー名）を更新します。
+func (h *UserHandler) UpdateMeHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UserUpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, req)
+	if err != nil {
+		if err == repositories.ErrDuplicateUsername {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+			return
+		}
+		if err == repositories.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// SecurityActivityHandler はログイン中のユーザーに関する最近のセキュリティイベント（ログイン・パスワード変更など）を返します。
+func (h *UserHandler) SecurityActivityHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.userService.RecentSecurityActivity(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security activity"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// ChangePasswordHandler は現在のパスワードを確認してパスワードを変更します。
+// 既存のトークンはすべて無効になるため、新しいトークンを返します。
+func (h *UserHandler) ChangePasswordHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UserChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	user, err := h.userService.ChangePassword(c.Request.Context(), userID, req, clientInfo(c))
+	if err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	token, ok := h.deliverToken(c, user)
+	if !ok {
+		return
+	}
+
+	res := gin.H{"message": "Password changed successfully"}
+	if token != "" {
+		res["token"] = token
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ChangeEmailHandler は新しいメールアドレス宛に変更確認メールを送信します。
+func (h *UserHandler) ChangeEmailHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UserChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	err := h.userService.RequestEmailChange(c.Request.Context(), userID, req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+		if err == repositories.ErrDuplicateEmail {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request email change"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Confirmation email sent to the new address"})
+}
+
+// DeleteMeHandler はパスワードを再確認してから退会手続きを行います。
+// アカウントは猶予期間の経過後に完全に削除されます。
+func (h *UserHandler) DeleteMeHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UserDeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	deletionAt, err := h.userService.DeleteAccount(c.Request.Context(), userID, req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Account scheduled for deletion", "deletion_at": deletionAt})
+}
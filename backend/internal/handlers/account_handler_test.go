@@ -0,0 +1,296 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/testutil"
+)
+
+func TestGetMe_Success(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var me models.User
+	err = json.Unmarshal(w.Body.Bytes(), &me)
+	assert.NoError(t, err)
+	assert.Equal(t, "normal_user", me.Username)
+	assert.Equal(t, "normal_user@example.com", me.Email)
+}
+
+func TestUpdateMe_Rename(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(map[string]string{"username": "renamed_user"})
+	req, _ := http.NewRequest(http.MethodPatch, "/api/me", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var me models.User
+	err = json.Unmarshal(w.Body.Bytes(), &me)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed_user", me.Username)
+
+	// 既存のユーザー名には変更できない
+	body, _ = json.Marshal(map[string]string{"username": "admin_user"})
+	req, _ = http.NewRequest(http.MethodPatch, "/api/me", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestChangePassword_InvalidatesExistingTokens(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	oldToken, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(map[string]string{
+		"current_password": "password123",
+		"new_password":     "Amber-Falcon-Riddle-88",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/me/password", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+oldToken)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	newToken := response["token"]
+	require.NotEmpty(t, newToken)
+
+	// 古いトークンは拒否される
+	req, _ = http.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+oldToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 新しいトークンは使える
+	req, _ = http.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+newToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 新しいパスワードでログインできる
+	_, err = testutil.LoginAndGetToken(t, r, "normal_user@example.com", "Amber-Falcon-Riddle-88")
+	assert.NoError(t, err)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(map[string]string{
+		"current_password": "wrongpassword",
+		"new_password":     "Amber-Falcon-Riddle-88",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/me/password", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestChangeEmail_ConfirmedByNewAddress(t *testing.T) {
+	r, store, mailer := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(map[string]string{
+		"new_email": "changed@example.com",
+		"password":  "password123",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/me/email", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	// 確認前はメールアドレスが変わらない
+	_, err = userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+
+	confirmToken := mailer.LastToken(t, "changed@example.com", "verify-email")
+
+	req, _ = http.NewRequest(http.MethodPost, "/api/verify-email/"+confirmToken, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	changed, err := userRepo.FindByEmail(context.Background(), "changed@example.com")
+	require.NoError(t, err)
+	assert.NotNil(t, changed.EmailVerifiedAt)
+}
+
+func TestChangeEmail_TakenAddressKeepsToken(t *testing.T) {
+	r, store, mailer := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+	ctx := context.Background()
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(map[string]string{
+		"new_email": "changed@example.com",
+		"password":  "password123",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/me/email", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	confirmToken := mailer.LastToken(t, "changed@example.com", "verify-email")
+
+	// 確認前に別のユーザーが同じアドレスに変更した
+	admin, err := userRepo.FindByEmail(ctx, "admin@example.com")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.UpdateEmail(ctx, uint(admin.ID), "changed@example.com"))
+
+	req, _ = http.NewRequest(http.MethodPost, "/api/verify-email/"+confirmToken, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "email already in use")
+
+	// アドレスが空けば同じリンクで変更できる
+	require.NoError(t, userRepo.UpdateEmail(ctx, uint(admin.ID), "admin@example.com"))
+
+	req, _ = http.NewRequest(http.MethodPost, "/api/verify-email/"+confirmToken, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	changed, err := userRepo.FindByEmail(ctx, "changed@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "normal_user", changed.Username)
+}
+
+func TestDeleteMe_SchedulesDeletionAndLoginCancels(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	// パスワードが違う場合は拒否
+	body, _ := json.Marshal(map[string]string{"password": "wrongpassword"})
+	req, _ := http.NewRequest(http.MethodDelete, "/api/me", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	body, _ = json.Marshal(map[string]string{"password": "password123"})
+	req, _ = http.NewRequest(http.MethodDelete, "/api/me", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	user, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, user.DeletionAt)
+	assert.True(t, user.DeletionAt.After(time.Now()), "Deletion should happen after the grace period")
+
+	// 既存のトークンは無効になる
+	req, _ = http.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 猶予期間中のログインで退会が取り消される
+	_, err = testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	user, err = userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, user.DeletionAt)
+}
+
+func TestExportMe_Zip(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	testutil.CreateTestTodo(t, r, token, "Exported Todo", false)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"profile.json", "todos.json", "password_reset_tokens.json"}, names)
+}
+
+func TestExportMe_JSON(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	testutil.CreateTestTodo(t, r, token, "Exported Todo", false)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/me/export?format=json", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var export struct {
+		Profile models.User   `json:"profile"`
+		Todos   []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Equal(t, "normal_user@example.com", export.Profile.Email)
+	require.Len(t, export.Todos, 1)
+	assert.Equal(t, "Exported Todo", export.Todos[0].Title)
+}
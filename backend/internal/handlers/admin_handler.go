@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/services"
+)
+
+// AdminHandler は管理者向けのユーザー管理ハンドラーです。
+type AdminHandler struct {
+	userService *services.UserService
+	roleService *services.RoleService
+}
+
+// NewAdminHandler は新しいAdminHandlerを作成します。
+func NewAdminHandler(userService *services.UserService, roleService *services.RoleService) *AdminHandler {
+	return &AdminHandler{userService: userService, roleService: roleService}
+}
+
+// targetUserID はパスパラメータ :id から操作対象のユーザーIDを取得します。
+// 不正な形式の場合はエラーレスポンスを書き込み、false を返します。
+func targetUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// writeAdminError はユーザー管理操作のエラーをレスポンスに変換します。
+func writeAdminError(c *gin.Context, err error, fallback string) {
+	switch {
+	case err == repositories.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repositories.ErrRoleNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found"})
+	case errors.Is(err, services.ErrCannotModifySelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot modify your own account"})
+	case errors.Is(err, services.ErrPermissionEscalation):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot assign a role with permissions you do not have"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListUsersHandler はユーザー一覧を検索・ページングして返します。
+func (h *AdminHandler) ListUsersHandler(c *gin.Context) {
+	var filter models.UserListFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	result, err := h.userService.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUserHandler は指定IDのユーザーを返します。
+func (h *AdminHandler) GetUserHandler(c *gin.Context) {
+	id, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetProfile(c.Request.Context(), id)
+	if err != nil {
+		writeAdminError(c, err, "Failed to fetch user")
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateRoleHandler はユーザーのロールを変更します。
+func (h *AdminHandler) UpdateRoleHandler(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminUpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	// 自分が持たない権限を他のユーザーに与えられないようにする
+	actorPerms, _ := c.Get("user_permissions")
+	perms, _ := actorPerms.(models.PermissionSet)
+	if err := h.roleService.CheckAssignable(perms, req.Role); err != nil {
+		writeAdminError(c, err, "Failed to update role")
+		return
+	}
+
+	user, err := h.userService.ChangeRole(c.Request.Context(), actorID, id, req.Role, clientInfo(c))
+	if err != nil {
+		writeAdminError(c, err, "Failed to update role")
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DisableUserHandler はユーザーを無効化します。
+func (h *AdminHandler) DisableUserHandler(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+// EnableUserHandler は無効化されたユーザーを有効化します。
+func (h *AdminHandler) EnableUserHandler(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *AdminHandler) setDisabled(c *gin.Context, disabled bool) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.SetUserDisabled(c.Request.Context(), actorID, id, disabled, clientInfo(c))
+	if err != nil {
+		writeAdminError(c, err, "Failed to update user status")
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ForcePasswordResetHandler はユーザーのパスワードを無効化し、リセットメールを送信します。
+func (h *AdminHandler) ForcePasswordResetHandler(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.ForcePasswordReset(c.Request.Context(), actorID, id, clientInfo(c)); err != nil {
+		writeAdminError(c, err, "Failed to force password reset")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset email sent"})
+}
+
+// DeleteUserHandler はユーザーを削除します。
+func (h *AdminHandler) DeleteUserHandler(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(c.Request.Context(), actorID, id, clientInfo(c)); err != nil {
+		writeAdminError(c, err, "Failed to delete user")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListSecurityEventsHandler はセキュリティイベントを検索・ページングして返します。
+func (h *AdminHandler) ListSecurityEventsHandler(c *gin.Context) {
+	var filter models.SecurityEventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	result, err := h.userService.ListSecurityEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security events"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
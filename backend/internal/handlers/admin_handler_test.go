@@ -0,0 +1,149 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/testutil"
+)
+
+func TestAdminListUsers_Authorization(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	tokenNormal, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+
+	t.Run("Normal user cannot list users", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenNormal)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Admin can search users with pagination", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/admin/users?q=normal&page=1&per_page=10", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var result models.UserListResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 1, result.Total)
+		require.Len(t, result.Users, 1)
+		assert.Equal(t, "normal_user@example.com", result.Users[0].Email)
+		assert.Equal(t, 10, result.PerPage)
+	})
+}
+
+func TestAdminUpdateRole(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+	normalUser, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(map[string]string{"role": "admin"})
+	req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/admin/users/%d/role", normalUser.ID), bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "admin", updated.Role)
+
+	// 自分自身のロールは変更できない
+	admin, err := userRepo.FindByEmail(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	body, _ = json.Marshal(map[string]string{"role": "user"})
+	req, _ = http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/admin/users/%d/role", admin.ID), bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminDisableUser_BlocksLoginAndSessions(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+	tokenNormal, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	normalUser, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/disable", normalUser.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 既存のトークンは使えない
+	req, _ = http.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenNormal)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// ログインもできない
+	_, err = testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	assert.Error(t, err)
+
+	// 有効化すると再びログインできる
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/enable", normalUser.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	_, err = testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	assert.NoError(t, err)
+}
+
+func TestAdminDeleteUser(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+	target := testutil.CreateTestUser(t, userRepo, "user_to_delete", "delete_me@example.com", "password123", "user")
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/admin/users/%d", target.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	_, err = userRepo.FindByEmail(context.Background(), "delete_me@example.com")
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	// 存在しないユーザー
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/admin/users/%d", target.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/config"
+	"go-next-todo/backend/internal/services"
+)
+
+const (
+	// AuthCookieName はCookieモードでJWTを保存する HttpOnly Cookie の名前です。
+	AuthCookieName = "access_token"
+	// CSRFCookieName はダブルサブミット用のCSRFトークンを保存する Cookie の名前です。JavaScript から読み取れます。
+	CSRFCookieName = "csrf_token"
+	// CSRFHeader はフロントエンドが CSRFCookieName の値を送り返すヘッダーです。
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// AuthCookieConfig はCookieモードの設定です。
+// 有効な場合、ログイン時にJWTをレスポンスボディではなく HttpOnly Cookie で返します。
+type AuthCookieConfig struct {
+	Enabled  bool
+	Secure   bool
+	SameSite http.SameSite
+	Domain   string
+}
+
+// NewAuthCookieConfig は認証設定から Cookie モードの設定を作成します。
+// SameSite=None は Secure 属性が必須のため、その場合は Secure を常に有効にします。
+func NewAuthCookieConfig(cfg config.Auth) AuthCookieConfig {
+	cookies := AuthCookieConfig{
+		Enabled:  cfg.CookieMode,
+		Secure:   cfg.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		Domain:   cfg.CookieDomain,
+	}
+	switch strings.ToLower(cfg.CookieSameSite) {
+	case "strict":
+		cookies.SameSite = http.SameSiteStrictMode
+	case "none":
+		cookies.SameSite = http.SameSiteNoneMode
+		cookies.Secure = true
+	}
+	return cookies
+}
+
+// setAuthCookies はJWTとCSRFトークンの Cookie を設定します。
+func (cfg AuthCookieConfig) setAuthCookies(c *gin.Context, token string) error {
+	csrfToken, err := generateCSRFToken()
+	if err != nil {
+		return err
+	}
+	maxAge := int(services.TokenTTL.Seconds())
+	http.SetCookie(c.Writer, cfg.cookie(AuthCookieName, token, maxAge, true))
+	http.SetCookie(c.Writer, cfg.cookie(CSRFCookieName, csrfToken, maxAge, false))
+	return nil
+}
+
+// clearAuthCookies はJWTとCSRFトークンの Cookie を削除します。
+func (cfg AuthCookieConfig) clearAuthCookies(c *gin.Context) {
+	http.SetCookie(c.Writer, cfg.cookie(AuthCookieName, "", -1, true))
+	http.SetCookie(c.Writer, cfg.cookie(CSRFCookieName, "", -1, false))
+}
+
+func (cfg AuthCookieConfig) cookie(name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: cfg.SameSite,
+	}
+}
+
+// generateCSRFToken はダブルサブミット用のランダムなCSRFトークンを生成します。
+func generateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
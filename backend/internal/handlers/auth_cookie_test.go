@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/testutil"
+)
+
+func TestCookieMode_LoginAndCSRF(t *testing.T) {
+	t.Setenv("AUTH_COOKIE_MODE", "true")
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	body, _ := json.Marshal(map[string]string{"email": "normal_user@example.com", "password": "password123"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.NotContains(t, res, "token", "Token must not be exposed to JavaScript in cookie mode")
+
+	var authCookie, csrfCookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		switch c.Name {
+		case "access_token":
+			authCookie = c
+		case "csrf_token":
+			csrfCookie = c
+		}
+	}
+	require.NotNil(t, authCookie)
+	require.NotNil(t, csrfCookie)
+	assert.True(t, authCookie.HttpOnly)
+	assert.True(t, authCookie.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, authCookie.SameSite)
+	assert.False(t, csrfCookie.HttpOnly)
+
+	todoBody, _ := json.Marshal(map[string]interface{}{"title": "Cookie todo", "completed": false})
+
+	t.Run("Cookie authenticates read requests", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/me", nil)
+		req.AddCookie(authCookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("State-changing request without CSRF token is rejected", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos", bytes.NewBuffer(todoBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(authCookie)
+		req.AddCookie(csrfCookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("State-changing request with matching CSRF token succeeds", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos", bytes.NewBuffer(todoBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", csrfCookie.Value)
+		req.AddCookie(authCookie)
+		req.AddCookie(csrfCookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("Logout revokes the session and clears cookies", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/api/logout", nil)
+		req.Header.Set("X-CSRF-Token", csrfCookie.Value)
+		req.AddCookie(authCookie)
+		req.AddCookie(csrfCookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		for _, c := range w.Result().Cookies() {
+			assert.True(t, c.MaxAge < 0, "cookie %s should be cleared", c.Name)
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, "/api/me", nil)
+		req.AddCookie(authCookie)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
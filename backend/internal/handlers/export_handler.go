@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/services"
+)
+
+// ExportHandler は個人データのエクスポートを扱うハンドラーです。
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler は新しいExportHandlerを作成します。
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// ExportMeHandler はログイン中のユーザーの個人データをダウンロードさせます。
+// ?format=json の場合は1つのJSON、それ以外はJSONファイルをまとめたZIPを返します。
+func (h *ExportHandler) ExportMeHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.exportService.CollectUserData(c.Request.Context(), userID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export user data"})
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s", userID, data.ExportedAt.Format("20060102"))
+	if c.Query("format") == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.Header("Content-Type", "application/json")
+		c.Status(http.StatusOK)
+		if err := h.exportService.WriteJSON(c.Writer, data); err != nil {
+			logging.FromContext(c.Request.Context()).Error("failed to write JSON export", "user_id", userID, "error", err)
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	// ヘッダー送信後は200を取り消せないため、書き込みエラーはログのみ
+	if err := h.exportService.WriteZip(c.Writer, data); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to write ZIP export", "user_id", userID, "error", err)
+	}
+}
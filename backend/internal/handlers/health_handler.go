@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/health"
+)
+
+// HealthHandler はライブネス・レディネスプローブを扱うハンドラーです。
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+// NewHealthHandler は新しいHealthHandlerを作成します。checker はレディネスの判定に使います。
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// LivenessHandler はプロセスがリクエストに応答できることだけを返します。
+// 依存先の障害で再起動されないよう、依存先は確認しません。
+func (h *HealthHandler) LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// ReadinessHandler は依存先を確認し、チェックごとの結果を返します。
+// 必須のチェックが1つでも失敗した場合は 503 を返します。
+func (h *HealthHandler) ReadinessHandler(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,68 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/health"
+	"go-next-todo/backend/testutil"
+)
+
+func getReadiness(t *testing.T, r http.Handler) (int, health.Report) {
+	req, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report), w.Body.String())
+	return w.Code, report
+}
+
+func TestHealthProbes(t *testing.T) {
+	db, r, _, _ := testutil.SetupTestDB(t)
+	defer db.Close()
+
+	t.Run("Liveness does not check dependencies", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("Readiness reports each check", func(t *testing.T) {
+		code, report := getReadiness(t, r)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, health.StatusOK, report.Status)
+
+		checks := map[string]health.Result{}
+		for _, c := range report.Checks {
+			checks[c.Name] = c
+		}
+		assert.Equal(t, health.StatusOK, checks["database"].Status)
+		assert.Equal(t, health.StatusOK, checks["migrations"].Status)
+		// テスト環境ではメールサーバーに接続できない場合があるが、必須ではない
+		require.Contains(t, checks, "smtp")
+		assert.True(t, checks["smtp"].Optional)
+	})
+
+	t.Run("Readiness fails while migrations are pending", func(t *testing.T) {
+		_, err := db.Exec("DELETE FROM schema_migrations WHERE version = 3")
+		require.NoError(t, err)
+		defer db.Exec("INSERT INTO schema_migrations (version, name) VALUES (3, 'security_events')")
+
+		code, report := getReadiness(t, r)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, health.StatusFail, report.Status)
+		for _, c := range report.Checks {
+			if c.Name == "migrations" {
+				assert.Equal(t, "pending migrations: 1", c.Error)
+			}
+		}
+	})
+}
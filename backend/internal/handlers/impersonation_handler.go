@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/services"
+)
+
+// ImpersonationHandler は管理者によるなりすましのハンドラーです。
+type ImpersonationHandler struct {
+	impersonationService *services.ImpersonationService
+}
+
+// NewImpersonationHandler は新しいImpersonationHandlerを作成します。
+func NewImpersonationHandler(impersonationService *services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{impersonationService: impersonationService}
+}
+
+// StartImpersonationHandler は指定したユーザーになりすますための短期間のトークンを発行します。
+// 管理者自身のセッションを上書きしないよう、Cookieモードでもトークンはレスポンスボディで返します。
+func (h *ImpersonationHandler) StartImpersonationHandler(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	res, err := h.impersonationService.Start(c.Request.Context(), actorID, id, req.Reason, clientInfo(c))
+	if err != nil {
+		switch {
+		case err == repositories.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case errors.Is(err, services.ErrCannotModifySelf):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot impersonate yourself"})
+		case errors.Is(err, services.ErrImpersonationForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot impersonate a user with elevated permissions"})
+		case errors.Is(err, services.ErrAccountDisabled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account disabled"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start impersonation"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
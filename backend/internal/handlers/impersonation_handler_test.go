@@ -0,0 +1,147 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/testutil"
+)
+
+func postImpersonate(t *testing.T, r *gin.Engine, token string, userID int) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"reason": "debugging todo sync issue"})
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/impersonate", userID), bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestImpersonation(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+	tokenNormal, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	admin, err := userRepo.FindByEmail(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	normalUser, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+
+	t.Run("Normal user cannot impersonate", func(t *testing.T) {
+		w := postImpersonate(t, r, tokenNormal, admin.ID)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Cannot impersonate a privileged user", func(t *testing.T) {
+		other := testutil.CreateTestUser(t, userRepo, "other_admin", "other_admin@example.com", "password123", "admin")
+
+		w := postImpersonate(t, r, tokenAdmin, other.ID)
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	t.Run("Reason is required", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/impersonate", normalUser.ID), bytes.NewBufferString(`{}`))
+		req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	w := postImpersonate(t, r, tokenAdmin, normalUser.ID)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var res models.ImpersonationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.NotEmpty(t, res.Token)
+	assert.Equal(t, normalUser.ID, res.User.ID)
+	impersonated := res.Token
+
+	t.Run("Impersonated token acts as the subject", func(t *testing.T) {
+		w := getSecurityEvents(t, r, impersonated, "/api/me")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var me models.User
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &me))
+		assert.Equal(t, "normal_user@example.com", me.Email)
+	})
+
+	t.Run("Sensitive operations are blocked", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"current_password": "password123", "new_password": "Blue-Kettle-Morning-42"})
+		req, _ := http.NewRequest(http.MethodPost, "/api/me/password", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+impersonated)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		req, _ = http.NewRequest(http.MethodDelete, "/api/me", bytes.NewBufferString(`{"password":"password123"}`))
+		req.Header.Set("Authorization", "Bearer "+impersonated)
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// 個人データのエクスポートと本人のセッションの失効もできない
+		w = getSecurityEvents(t, r, impersonated, "/api/me/export")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = getSecurityEvents(t, r, tokenNormal, "/api/me/sessions")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var sessions []models.Session
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+		require.NotEmpty(t, sessions)
+		req, _ = http.NewRequest(http.MethodDelete, "/api/me/sessions/"+sessions[0].ID, nil)
+		req.Header.Set("Authorization", "Bearer "+impersonated)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Session is marked with the impersonator", func(t *testing.T) {
+		w := getSecurityEvents(t, r, tokenNormal, "/api/me/sessions")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var sessions []models.Session
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+		var found bool
+		for _, s := range sessions {
+			if s.ImpersonatorID != nil {
+				assert.Equal(t, admin.ID, *s.ImpersonatorID)
+				found = true
+			}
+		}
+		assert.True(t, found, "impersonation session should be listed")
+	})
+
+	t.Run("Actions are audited with the actor", func(t *testing.T) {
+		w := getSecurityEvents(t, r, tokenAdmin, fmt.Sprintf("/api/admin/security-events?user_id=%d&actor_id=%d", normalUser.ID, admin.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list models.SecurityEventListResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+
+		types := map[string]int{}
+		for _, e := range list.Events {
+			types[e.Type]++
+		}
+		assert.Equal(t, 1, types[models.EventImpersonationStarted])
+		// GET /api/me と拒否された4件の操作。/api/me/sessions は本人のトークンで呼んでいるため含まれない
+		assert.Equal(t, 5, types[models.EventImpersonationAction])
+	})
+
+	t.Run("Disabling the admin revokes the impersonation token", func(t *testing.T) {
+		require.NoError(t, userRepo.SetDisabled(context.Background(), uint(admin.ID), true))
+		w := getSecurityEvents(t, r, impersonated, "/api/me")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
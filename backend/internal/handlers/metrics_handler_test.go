@@ -0,0 +1,37 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/testutil"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	db, r, _, _ := testutil.SetupTestDB(t)
+	defer db.Close()
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	_, err = testutil.LoginAndGetToken(t, r, "normal_user@example.com", "wrong-password")
+	require.Error(t, err)
+	testutil.CreateTestTodo(t, r, token, "Metrics todo", true)
+
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `todo_http_requests_total{method="POST",route="/api/login",status="200"}`)
+	assert.Contains(t, body, `todo_http_request_duration_seconds_bucket{method="POST",route="/api/todos"`)
+	assert.Contains(t, body, `todo_logins_total{method="password",reason="",result="success"}`)
+	assert.Contains(t, body, `todo_logins_total{method="password",reason="invalid_password",result="failure"}`)
+	assert.Contains(t, body, "todo_todos_created_total")
+	assert.Contains(t, body, "todo_todos_completed_total")
+	assert.Contains(t, body, `go_sql_open_connections{db_name="mysql"}`)
+}
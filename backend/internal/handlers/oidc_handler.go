@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/services"
+)
+
+// OIDCStateCookieName はログインを開始したブラウザを識別するため、state のハッシュを保存する HttpOnly Cookie の名前です。
+const OIDCStateCookieName = "oidc_state"
+
+// oidcStateCookiePath は state の Cookie を送信するパスです。
+const oidcStateCookiePath = "/api/auth/oidc"
+
+// OIDCHandler はOIDC（外部IDプロバイダー）ログインのハンドラーです。
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+	userHandler *UserHandler
+}
+
+// NewOIDCHandler は新しいOIDCHandlerを作成します。トークンの発行は userHandler に委ねます。
+func NewOIDCHandler(oidcService *services.OIDCService, userHandler *UserHandler) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService, userHandler: userHandler}
+}
+
+// LoginHandler はIDプロバイダーの認可URLを返します。フロントエンドはこのURLへリダイレクトします。
+func (h *OIDCHandler) LoginHandler(c *gin.Context) {
+	if !h.oidcService.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+
+	authURL, state, err := h.oidcService.AuthCodeURL(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start OIDC login"})
+		return
+	}
+	// 他人のコールバックURLでログインさせられないよう、state をこのブラウザに紐付ける
+	http.SetCookie(c.Writer, h.stateCookie(oidcStateDigest(state), int(services.OIDCAuthRequestTTL.Seconds())))
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// stateCookie は state のハッシュを保存する Cookie を返します。
+func (h *OIDCHandler) stateCookie(value string, maxAge int) *http.Cookie {
+	cookie := h.userHandler.cookies.cookie(OIDCStateCookieName, value, maxAge, true)
+	cookie.Path = oidcStateCookiePath
+	return cookie
+}
+
+// oidcStateDigest は Cookie に保存する state のハッシュを返します。
+func oidcStateDigest(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// CallbackHandler はIDプロバイダーからの code と state を受け取り、通常のログインと同じJWTを返します。
+func (h *OIDCHandler) CallbackHandler(c *gin.Context) {
+	if !h.oidcService.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "OIDC login was denied", "details": errParam})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code or state"})
+		return
+	}
+
+	// ログインを開始したブラウザからのコールバックか確認する
+	digest, err := c.Cookie(OIDCStateCookieName)
+	http.SetCookie(c.Writer, h.stateCookie("", -1))
+	if err != nil || subtle.ConstantTimeCompare([]byte(digest), []byte(oidcStateDigest(state))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired state"})
+		return
+	}
+
+	user, err := h.oidcService.Callback(c.Request.Context(), code, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCStateInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired state"})
+		case errors.Is(err, services.ErrOIDCTokenInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ID token"})
+		case errors.Is(err, services.ErrOIDCEmailNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address not verified by identity provider"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete OIDC login"})
+		}
+		return
+	}
+	user, err = h.userHandler.userService.CompleteExternalLogin(c.Request.Context(), user, services.LoginMethodOIDC, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		case errors.Is(err, services.ErrEmailNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address not verified"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete OIDC login"})
+		}
+		return
+	}
+
+	h.userHandler.respondWithToken(c, user, services.LoginMethodOIDC)
+}
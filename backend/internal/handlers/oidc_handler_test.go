@@ -0,0 +1,216 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/handlers"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/testutil"
+)
+
+// setupOIDC はモックIDプロバイダーを起動し、OIDCログインを有効にした状態でテスト環境を構築します。
+func setupOIDC(t *testing.T) (*testutil.MockIdP, *gin.Engine, *repositories.MemoryUserStore) {
+	idp := testutil.NewMockIdP(t, "todo-app")
+	t.Setenv("OIDC_ISSUER_URL", idp.Issuer())
+	t.Setenv("OIDC_CLIENT_ID", "todo-app")
+	t.Setenv("OIDC_CLIENT_SECRET", "secret")
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:3000/auth/callback")
+
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	return idp, r, store.Users()
+}
+
+// oidcAuthorize はログインを開始してIDプロバイダーで認可し、コールバックのURLとブラウザに設定された state の Cookie を返します。
+func oidcAuthorize(t *testing.T, idp *testutil.MockIdP, r *gin.Engine) (string, *http.Cookie) {
+	req, _ := http.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stateCookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == handlers.OIDCStateCookieName {
+			stateCookie = c
+		}
+	}
+	require.NotNil(t, stateCookie, "state cookie must be set")
+	assert.True(t, stateCookie.HttpOnly)
+
+	var res map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	code, state := idp.Authorize(t, res["authorization_url"])
+	assert.NotEqual(t, state, stateCookie.Value, "only the hash of the state is stored in the cookie")
+
+	return "/api/auth/oidc/callback?" + url.Values{"code": {code}, "state": {state}}.Encode(), stateCookie
+}
+
+// oidcCallback はコールバックURLにアクセスします。stateCookie が nil の場合は Cookie を送信しません。
+func oidcCallback(r *gin.Engine, callback string, stateCookie *http.Cookie) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, callback, nil)
+	if stateCookie != nil {
+		req.AddCookie(stateCookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// oidcLogin はログイン開始 → IDプロバイダーでの認可 → コールバックまでを行い、コールバックのレスポンスを返します。
+func oidcLogin(t *testing.T, idp *testutil.MockIdP, r *gin.Engine) *httptest.ResponseRecorder {
+	callback, stateCookie := oidcAuthorize(t, idp, r)
+	return oidcCallback(r, callback, stateCookie)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	idp, r, userRepo := setupOIDC(t)
+
+	t.Run("New user is created and receives a token", func(t *testing.T) {
+		idp.User = testutil.MockIdPUser{Subject: "corp-123", Email: "new_hire@example.com", EmailVerified: true, PreferredUsername: "new_hire"}
+		callback, stateCookie := oidcAuthorize(t, idp, r)
+		w := oidcCallback(r, callback, stateCookie)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.NotEmpty(t, res["token"])
+		assert.Equal(t, "user", res["role"])
+
+		// 同じ state は再利用できない
+		w = oidcCallback(r, callback, stateCookie)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// 2回目は同じユーザーとしてログインする
+		w = oidcLogin(t, idp, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var second map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+		assert.Equal(t, res["user_id"], second["user_id"])
+	})
+
+	t.Run("Verified email is linked to an existing verified user", func(t *testing.T) {
+		owner := testutil.CreateTestUser(t, userRepo, "verified_owner", "verified_owner@example.com", "password123", "user")
+		require.NoError(t, userRepo.MarkEmailVerified(context.Background(), uint(owner.ID)))
+
+		idp.User = testutil.MockIdPUser{Subject: "corp-456", Email: "verified_owner@example.com", EmailVerified: true}
+		w := oidcLogin(t, idp, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.EqualValues(t, owner.ID, res["user_id"])
+
+		// 確認済みのアカウントはパスワードでも引き続きログインできる
+		_, err := testutil.LoginAndGetToken(t, r, "verified_owner@example.com", "password123")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unverified local account loses its password when linked", func(t *testing.T) {
+		// 第三者が先に被害者のアドレスで登録していた場合を想定する
+		squatterToken, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+		require.NoError(t, err)
+
+		idp.User = testutil.MockIdPUser{Subject: "corp-457", Email: "normal_user@example.com", EmailVerified: true}
+		w := oidcLogin(t, idp, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.EqualValues(t, 1, res["user_id"])
+
+		_, err = testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+		assert.Error(t, err, "the previous password must no longer work")
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/me", nil)
+		req.Header.Set("Authorization", "Bearer "+squatterToken)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "tokens issued before linking must be revoked")
+
+		user, err := userRepo.FindByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.NotNil(t, user.EmailVerifiedAt)
+	})
+
+	t.Run("Login cancels a scheduled deletion", func(t *testing.T) {
+		leaving := testutil.CreateTestUser(t, userRepo, "leaving_user", "leaving@example.com", "password123", "user")
+		require.NoError(t, userRepo.MarkEmailVerified(context.Background(), uint(leaving.ID)))
+		require.NoError(t, userRepo.ScheduleDeletion(context.Background(), uint(leaving.ID), time.Now().Add(24*time.Hour)))
+
+		idp.User = testutil.MockIdPUser{Subject: "corp-458", Email: "leaving@example.com", EmailVerified: true}
+		w := oidcLogin(t, idp, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		user, err := userRepo.FindByID(context.Background(), uint(leaving.ID))
+		require.NoError(t, err)
+		assert.Nil(t, user.DeletionAt)
+	})
+
+	t.Run("Taken username gets a suffix", func(t *testing.T) {
+		idp.User = testutil.MockIdPUser{Subject: "corp-459", Email: "second_admin@example.com", EmailVerified: true, PreferredUsername: "admin_user"}
+		w := oidcLogin(t, idp, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		user, err := userRepo.FindByEmail(context.Background(), "second_admin@example.com")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(user.Username, "admin_user_"), user.Username)
+	})
+
+	t.Run("Unverified email is not linked to an existing user", func(t *testing.T) {
+		idp.User = testutil.MockIdPUser{Subject: "corp-789", Email: "admin@example.com", EmailVerified: false}
+		w := oidcLogin(t, idp, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Callback without the browser's state cookie is rejected", func(t *testing.T) {
+		idp.User = testutil.MockIdPUser{Subject: "corp-123", Email: "new_hire@example.com", EmailVerified: true}
+		// 攻撃者が自分のログインで得たコールバックURLを被害者に開かせる
+		callback, _ := oidcAuthorize(t, idp, r)
+		_, victimCookie := oidcAuthorize(t, idp, r)
+
+		w := oidcCallback(r, callback, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = oidcCallback(r, callback, victimCookie)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown state is rejected", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/auth/oidc/callback?code=abc&state=unknown", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestOIDCLogin_EmailVerificationBeforeLogin(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_MODE", "login")
+	idp, r, _ := setupOIDC(t)
+
+	idp.User = testutil.MockIdPUser{Subject: "corp-900", Email: "unverified_hire@example.com", EmailVerified: false}
+	w := oidcLogin(t, idp, r)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	idp.User = testutil.MockIdPUser{Subject: "corp-901", Email: "verified_hire@example.com", EmailVerified: true}
+	w = oidcLogin(t, idp, r)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestOIDCLogin_NotConfigured(t *testing.T) {
+	t.Setenv("OIDC_ISSUER_URL", "")
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,136 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/testutil"
+)
+
+type policyErrorResponse struct {
+	Error      string `json:"error"`
+	Violations []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"violations"`
+}
+
+func (r policyErrorResponse) codes() []string {
+	codes := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		codes[i] = v.Code
+	}
+	return codes
+}
+
+func postRegister(t *testing.T, r *gin.Engine, username, email, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"username": username, "email": email, "password": password})
+	req, _ := http.NewRequest(http.MethodPost, "/api/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRegisterUser_PasswordPolicyViolations(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	tests := []struct {
+		name     string
+		password string
+		expected []string
+	}{
+		{"Too short and weak", "abc12", []string{"too_short", "too_weak"}},
+		{"Common password", "password1", []string{"too_weak"}},
+		{"Keyboard pattern", "qwertyuiop", []string{"too_weak"}},
+		{"Contains username", "policyuser-Lantern-42", []string{"similar_to_username"}},
+		{"Contains email", "Xq9!policy.mail@example.com", []string{"similar_to_email"}},
+		{"Too long", strings.Repeat("Zx9!", 20), []string{"too_long"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postRegister(t, r, "policyuser", "policy.mail@example.com", tt.password)
+			require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+			var res policyErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, "Password does not meet the password policy", res.Error)
+			for _, code := range tt.expected {
+				assert.Contains(t, res.codes(), code)
+			}
+			for _, v := range res.Violations {
+				assert.NotEmpty(t, v.Message)
+			}
+		})
+	}
+
+	t.Run("Strong password is accepted", func(t *testing.T) {
+		w := postRegister(t, r, "policyuser", "policy.mail@example.com", "Granite-Orchid-Sailing-63")
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
+
+func TestRegisterUser_BreachedPassword(t *testing.T) {
+	breached := "Xylophone-Meadow-Copper-51"
+	sum := sha1.Sum([]byte(breached))
+	list := "# test list\n" +
+		"0000000000000000000000000000000000000001:3\n" +
+		strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n"
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+	t.Setenv("PASSWORD_BREACH_LIST", path)
+
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	w := postRegister(t, r, "breacheduser", "breached@example.com", breached)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	var res policyErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []string{"breached"}, res.codes())
+
+	w = postRegister(t, r, "breacheduser", "breached@example.com", "Granite-Orchid-Sailing-63")
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestResetPassword_PasswordPolicyViolation(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+
+	resetTokenRepo := store.ResetTokens()
+	token, _ := generateResetToken()
+	require.NoError(t, resetTokenRepo.Save(context.Background(), &models.PasswordResetToken{
+		UserID:    1,
+		Token:     token,
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+	}))
+
+	body, _ := json.Marshal(map[string]string{"password": "12345678"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/reset-password/"+token, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	var res policyErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Contains(t, res.codes(), "too_weak")
+
+	// ポリシー違反ではトークンは消費されない
+	reset, err := resetTokenRepo.FindByToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Nil(t, reset.UsedAt)
+}
@@ -0,0 +1,41 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-next-todo/backend/internal/routes"
+	"go-next-todo/backend/testutil"
+)
+
+func TestRequestID(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	get := func(requestID string) string {
+		req, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+		if requestID != "" {
+			req.Header.Set(routes.RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Header().Get(routes.RequestIDHeader)
+	}
+
+	t.Run("Propagates the caller's request ID", func(t *testing.T) {
+		assert.Equal(t, "lb-7f3a.1", get("lb-7f3a.1"))
+	})
+
+	t.Run("Generates an ID when none is sent", func(t *testing.T) {
+		first, second := get(""), get("")
+		assert.Len(t, first, 32)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("Replaces IDs that are unsafe to log", func(t *testing.T) {
+		id := get("abc\ninjected=1")
+		assert.Len(t, id, 32)
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/services"
+)
+
+// RoleHandler は管理者向けのロール定義ハンドラーです。
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+// NewRoleHandler は新しいRoleHandlerを作成します。
+func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// writeRoleError はロール操作のエラーをレスポンスに変換します。
+func writeRoleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case err == repositories.ErrRoleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+	case err == repositories.ErrDuplicateRole:
+		c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+	case errors.Is(err, services.ErrBuiltInRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Built-in roles cannot be modified"})
+	case errors.Is(err, services.ErrRoleInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Role is assigned to users"})
+	case errors.Is(err, services.ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListPermissionsHandler は定義済みの権限一覧を返します。
+func (h *RoleHandler) ListPermissionsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"permissions": models.AllPermissions})
+}
+
+// ListRolesHandler はすべてのロールを返します。
+func (h *RoleHandler) ListRolesHandler(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRoleHandler は指定した名前のロールを返します。
+func (h *RoleHandler) GetRoleHandler(c *gin.Context) {
+	role, err := h.roleService.GetRole(c.Param("name"))
+	if err != nil {
+		writeRoleError(c, err, "Failed to fetch role")
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// CreateRoleHandler はカスタムロールを作成します。
+func (h *RoleHandler) CreateRoleHandler(c *gin.Context) {
+	var req models.RoleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(req)
+	if err != nil {
+		writeRoleError(c, err, "Failed to create role")
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRoleHandler はカスタムロールの説明と権限を更新します。
+func (h *RoleHandler) UpdateRoleHandler(c *gin.Context) {
+	var req models.RoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Param("name"), req)
+	if err != nil {
+		writeRoleError(c, err, "Failed to update role")
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRoleHandler はカスタムロールを削除します。
+func (h *RoleHandler) DeleteRoleHandler(c *gin.Context) {
+	if err := h.roleService.DeleteRole(c.Param("name")); err != nil {
+		writeRoleError(c, err, "Failed to delete role")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
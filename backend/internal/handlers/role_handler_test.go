@@ -0,0 +1,177 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/testutil"
+)
+
+func TestCustomRole_GrantsOnlyAssignedPermissions(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+
+	// users.read のみを持つロールを作成
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":        "support",
+		"description": "Read-only access to users",
+		"permissions": []string{models.PermUsersRead},
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/admin/roles", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// ロールを割り当て
+	supportUser := testutil.CreateTestUser(t, userRepo, "support_user", "support@example.com", "password123", "user")
+	body, _ = json.Marshal(map[string]string{"role": "support"})
+	req, _ = http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/admin/users/%d/role", supportUser.ID), bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	tokenSupport, err := testutil.LoginAndGetToken(t, r, "support@example.com", "password123")
+	require.NoError(t, err)
+
+	t.Run("Support can list users", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenSupport)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Support cannot disable users", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/disable", supportUser.ID+1), nil)
+		req.Header.Set("Authorization", "Bearer "+tokenSupport)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Support cannot manage roles", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/admin/roles", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenSupport)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Role in use cannot be deleted", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/api/admin/roles/support", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestRoles_Validation(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+
+	t.Run("Unknown permission is rejected", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":        "broken",
+			"permissions": []string{"todos.fly"},
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/api/admin/roles", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Built-in roles cannot be modified", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"permissions": []string{},
+		})
+		req, _ := http.NewRequest(http.MethodPut, "/api/admin/roles/admin", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown role cannot be assigned", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"role": "nosuchrole"})
+		req, _ := http.NewRequest(http.MethodPatch, "/api/admin/users/1/role", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestChangeRole_CannotGrantMorePermissionsThanActor(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+
+	changeRole := func(token string, userID int, role string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"role": role})
+		req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/admin/users/%d/role", userID), bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// users.manage を持つが admin ではないロールを作成して割り当てる
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":        "usermanager",
+		"permissions": []string{models.PermUsersRead, models.PermUsersManage},
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/admin/roles", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	manager := testutil.CreateTestUser(t, userRepo, "manager_user", "manager@example.com", "password123", "user")
+	w = changeRole(tokenAdmin, manager.ID, "usermanager")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	tokenManager, err := testutil.LoginAndGetToken(t, r, "manager@example.com", "password123")
+	require.NoError(t, err)
+	target := testutil.CreateTestUser(t, userRepo, "target_user", "target@example.com", "password123", "user")
+
+	t.Run("Cannot assign admin", func(t *testing.T) {
+		w := changeRole(tokenManager, target.ID, models.RoleAdmin)
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+		u, err := userRepo.FindByID(context.Background(), uint(target.ID))
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleUser, u.Role)
+	})
+
+	t.Run("Can assign roles within own permissions", func(t *testing.T) {
+		w := changeRole(tokenManager, target.ID, "usermanager")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+}
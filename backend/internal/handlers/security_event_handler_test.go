@@ -0,0 +1,165 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/testutil"
+)
+
+func getSecurityEvents(t *testing.T, r *gin.Engine, token, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", "security-test/1.0")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSecurityActivity_RecordsLogins(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	_, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "wrongpassword")
+	require.Error(t, err)
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	w := getSecurityEvents(t, r, token, "/api/me/security-events")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var res struct {
+		Events []models.SecurityEvent `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Events, 2)
+
+	// 新しい順に返る
+	assert.Equal(t, models.EventLoginSucceeded, res.Events[0].Type)
+	assert.Equal(t, "password", res.Events[0].Metadata["method"])
+	assert.Equal(t, models.EventLoginFailed, res.Events[1].Type)
+	assert.Equal(t, "invalid_password", res.Events[1].Metadata["reason"])
+}
+
+func TestAdminSecurityEvents(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+	tokenNormal, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	admin, err := userRepo.FindByEmail(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	normalUser, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+
+	_, err = testutil.LoginAndGetToken(t, r, "nobody@example.com", "password123")
+	require.Error(t, err)
+
+	// 下で normal_user を admin に変更するため、先に確認する
+	t.Run("Normal user cannot query security events", func(t *testing.T) {
+		w := getSecurityEvents(t, r, tokenNormal, "/api/admin/security-events")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	body, _ := json.Marshal(map[string]string{"role": "admin"})
+	req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/admin/users/%d/role", normalUser.ID), bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	t.Run("Filter by type and user", func(t *testing.T) {
+		w := getSecurityEvents(t, r, tokenAdmin, fmt.Sprintf("/api/admin/security-events?type=role.changed&user_id=%d", normalUser.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var result models.SecurityEventListResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Equal(t, 1, result.Total)
+		event := result.Events[0]
+		require.NotNil(t, event.ActorID)
+		assert.Equal(t, admin.ID, *event.ActorID)
+		assert.Equal(t, "user", event.Metadata["from"])
+		assert.Equal(t, "admin", event.Metadata["to"])
+	})
+
+	t.Run("Failed login for unknown email is recorded without a user", func(t *testing.T) {
+		w := getSecurityEvents(t, r, tokenAdmin, "/api/admin/security-events?type=login.failed")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var result models.SecurityEventListResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Equal(t, 1, result.Total)
+		assert.Nil(t, result.Events[0].UserID)
+		assert.Equal(t, "unknown_email", result.Events[0].Metadata["reason"])
+		assert.Equal(t, "nobody@example.com", result.Events[0].Metadata["email"])
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		w := getSecurityEvents(t, r, tokenAdmin, "/api/admin/security-events?type=login.succeeded&per_page=1&page=2")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var result models.SecurityEventListResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 2, result.Total)
+		assert.Len(t, result.Events, 1)
+		assert.Equal(t, 2, result.Page)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		w := getSecurityEvents(t, r, tokenAdmin, "/api/admin/security-events?since=yesterday")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSecurityEvents_RevokedTokenIsRecorded(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	oldToken, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+oldToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = getSecurityEvents(t, r, oldToken, "/api/me")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	w = getSecurityEvents(t, r, token, "/api/me/security-events")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Events []models.SecurityEvent `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	normalUser, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+
+	var rejected *models.SecurityEvent
+	for i := range res.Events {
+		if res.Events[i].Type == models.EventTokenRejected {
+			rejected = &res.Events[i]
+		}
+	}
+	require.NotNil(t, rejected, "Expected the use of a revoked token to be recorded")
+	assert.Equal(t, normalUser.ID, *rejected.UserID)
+	assert.Nil(t, rejected.ActorID)
+	assert.Equal(t, "session_invalid", rejected.Metadata["reason"])
+	assert.Equal(t, "security-test/1.0", rejected.UserAgent)
+}
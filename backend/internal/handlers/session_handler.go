@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/repositories"
+)
+
+// ListSessionsHandler はログイン中のユーザーの有効なセッション一覧を返します。
+func (h *UserHandler) ListSessionsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(int(userID), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSessionHandler は指定したセッションを失効させ、その端末をログアウトさせます。
+func (h *UserHandler) RevokeSessionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(int(userID), c.Param("id")); err != nil {
+		if err == repositories.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutHandler は現在のセッションを失効させます。Cookieモードでは Cookie も削除します。
+func (h *UserHandler) LogoutHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(int(userID), c.GetString("session_id")); err != nil && err != repositories.ErrSessionNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	if h.cookies.Enabled {
+		h.cookies.clearAuthCookies(c)
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/testutil"
+)
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	// 2つの端末からログイン
+	laptopToken, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+	phoneToken, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/me/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+laptopToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var sessions []models.Session
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 2)
+
+	var other models.Session
+	for _, s := range sessions {
+		if !s.Current {
+			other = s
+		}
+	}
+	require.NotEmpty(t, other.ID)
+
+	t.Run("Revoking a session signs out that device", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/api/me/sessions/"+other.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+laptopToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		req, _ = http.NewRequest(http.MethodGet, "/api/me", nil)
+		req.Header.Set("Authorization", "Bearer "+phoneToken)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req, _ = http.NewRequest(http.MethodGet, "/api/me", nil)
+		req.Header.Set("Authorization", "Bearer "+laptopToken)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Other users' sessions cannot be revoked", func(t *testing.T) {
+		adminToken, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+		require.NoError(t, err)
+
+		var current models.Session
+		for _, s := range sessions {
+			if s.Current {
+				current = s
+			}
+		}
+		req, _ := http.NewRequest(http.MethodDelete, "/api/me/sessions/"+current.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -40,7 +40,12 @@ func (h *TodoHandler) CreateTodoHandler(c *gin.Context) {
 		return
 	}
 
-	createdTodo, err := h.todoService.CreateTodo(&newTodo, userID)
+	workspaceID, _, ok := currentWorkspace(c)
+	if !ok {
+		return
+	}
+
+	createdTodo, err := h.todoService.CreateTodo(c.Request.Context(), workspaceID, &newTodo, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save todo to database"})
 		return
@@ -68,14 +73,8 @@ func (h *TodoHandler) UpdateTodoHandler(c *gin.Context) {
 		return
 	}
 
-	userRoleVal, exists := c.Get("user_role")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User role not found in context"})
-		return
-	}
-	userRole, ok := userRoleVal.(string)
+	workspaceID, perms, ok := currentWorkspace(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user role type in context"})
 		return
 	}
 
@@ -85,7 +84,7 @@ func (h *TodoHandler) UpdateTodoHandler(c *gin.Context) {
 		return
 	}
 
-	updatedTodo, err := h.todoService.UpdateTodo(id, &updateTodo, userID, userRole)
+	updatedTodo, err := h.todoService.UpdateTodo(c.Request.Context(), workspaceID, id, &updateTodo, userID, perms)
 	if err != nil {
 		if err == repositories.ErrTodoNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
@@ -121,18 +120,12 @@ func (h *TodoHandler) DeleteTodoHandler(c *gin.Context) {
 		return
 	}
 
-	userRoleVal, exists := c.Get("user_role")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User role not found in context"})
-		return
-	}
-	userRole, ok := userRoleVal.(string)
+	workspaceID, perms, ok := currentWorkspace(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user role type in context"})
 		return
 	}
 
-	err = h.todoService.DeleteTodo(id, userID, userRole)
+	err = h.todoService.DeleteTodo(c.Request.Context(), workspaceID, id, userID, perms)
 	if err != nil {
 		if err == repositories.ErrTodoNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
@@ -161,18 +154,12 @@ func (h *TodoHandler) GetTodosHandler(c *gin.Context) {
 		return
 	}
 
-	userRoleVal, exists := c.Get("user_role")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User role not found in context"})
-		return
-	}
-	userRole, ok := userRoleVal.(string)
+	workspaceID, perms, ok := currentWorkspace(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user role type in context"})
 		return
 	}
 
-	todos, err := h.todoService.GetTodos(userID, userRole)
+	todos, err := h.todoService.GetTodos(c.Request.Context(), workspaceID, userID, perms)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todos"})
 		return
@@ -200,18 +187,12 @@ func (h *TodoHandler) GetTodoByIDHandler(c *gin.Context) {
 		return
 	}
 
-	userRoleVal, exists := c.Get("user_role")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User role not found in context"})
-		return
-	}
-	userRole, ok := userRoleVal.(string)
+	workspaceID, perms, ok := currentWorkspace(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user role type in context"})
 		return
 	}
 
-	todo, err := h.todoService.GetTodoByID(id, userID, userRole)
+	todo, err := h.todoService.GetTodoByID(c.Request.Context(), workspaceID, id, userID, perms)
 	if err != nil {
 		if err == repositories.ErrTodoNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
@@ -226,3 +207,24 @@ func (h *TodoHandler) GetTodoByIDHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, todo)
 }
+
+// currentWorkspace は WorkspaceMiddleware が設定したワークスペースIDと、ワークスペース内の権限を取得します。
+// 取得できない場合はエラーレスポンスを書き込み、false を返します。
+func currentWorkspace(c *gin.Context) (int, models.PermissionSet, bool) {
+	workspaceID := c.GetInt("workspace_id")
+	if workspaceID == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Workspace not found in context"})
+		return 0, nil, false
+	}
+	permsVal, exists := c.Get("workspace_permissions")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Workspace permissions not found in context"})
+		return 0, nil, false
+	}
+	perms, ok := permsVal.(models.PermissionSet)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid workspace permissions type in context"})
+		return 0, nil, false
+	}
+	return workspaceID, perms, true
+}
@@ -3,6 +3,7 @@ package handlers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -20,8 +21,7 @@ import (
 )
 
 func TestCreateTodo_Success(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
 	require.NoError(t, err)
@@ -53,8 +53,7 @@ func TestCreateTodo_Success(t *testing.T) {
 }
 
 func TestCreateTodo_AuthenticatedUserSuccess(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, store, _ := testutil.SetupMemoryRouter(t)
 
 	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
 	require.NoError(t, err)
@@ -86,10 +85,7 @@ func TestCreateTodo_AuthenticatedUserSuccess(t *testing.T) {
 	require.WithinDuration(t, time.Now(), createdTodo.CreatedAt, 5*time.Second)
 	require.WithinDuration(t, time.Now(), createdTodo.UpdatedAt, 5*time.Second)
 
-	var dbTodo models.Todo
-	err = db.QueryRow("SELECT id, user_id, title, completed, created_at, updated_at FROM todos WHERE id = ?", createdTodo.ID).Scan(
-		&dbTodo.ID, &dbTodo.UserID, &dbTodo.Title, &dbTodo.Completed, &dbTodo.CreatedAt, &dbTodo.UpdatedAt,
-	)
+	dbTodo, err := store.Todos().FindByID(context.Background(), testutil.DefaultWorkspaceID, createdTodo.ID)
 	require.NoError(t, err)
 	require.Equal(t, createdTodo.ID, dbTodo.ID)
 	require.Equal(t, createdTodo.UserID, dbTodo.UserID)
@@ -99,10 +95,9 @@ func TestCreateTodo_AuthenticatedUserSuccess(t *testing.T) {
 
 func TestGetTodosHandler_Authorization(t *testing.T) {
 	// データベースとルーターをセットアップ
-	db, router, _, _ := testutil.SetupTestDB(t) // todoRepo を使用
-	defer db.Close()
+	router, _, _ := testutil.SetupMemoryRouter(t)
 
-	// testutil.SetupTestDB で既に 'normal_user@example.com' と 'admin@example.com' が作成されている前提
+	// testutil.SetupMemoryRouter で既に 'normal_user@example.com' と 'admin@example.com' が作成されている前提
 	// これらのユーザーでログインしてトークンを取得
 	tokenNormal, err := testutil.LoginAndGetToken(t, router, "normal_user@example.com", "password123") // t を追加
 	require.NoError(t, err)
@@ -159,11 +154,10 @@ func TestGetTodosHandler_Authorization(t *testing.T) {
 }
 
 func TestGetTodoByIDHandler_Authorization(t *testing.T) {
-	db, router, _, userRepo := testutil.SetupTestDB(t)
+	router, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
 
-	defer db.Close()
-
-	// testutil.SetupTestDB で作成されたユーザーを使用
+	// testutil.SetupMemoryRouter で作成されたユーザーを使用
 	// ログインしてトークンを取得
 	tokenNormal, err := testutil.LoginAndGetToken(t, router, "normal_user@example.com", "password123")
 	require.NoError(t, err)
@@ -218,8 +212,8 @@ func TestGetTodoByIDHandler_Authorization(t *testing.T) {
 }
 
 func TestUpdateTodoHandler_Authorization(t *testing.T) {
-	db, router, _, userRepo := testutil.SetupTestDB(t)
-	defer db.Close()
+	router, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
 
 	tokenNormal, err := testutil.LoginAndGetToken(t, router, "normal_user@example.com", "password123")
 	require.NoError(t, err)
@@ -283,8 +277,8 @@ func TestUpdateTodoHandler_Authorization(t *testing.T) {
 }
 
 func TestDeleteTodoHandler_Authorization(t *testing.T) {
-	db, router, todoRepo, userRepo := testutil.SetupTestDB(t)
-	defer db.Close()
+	router, store, _ := testutil.SetupMemoryRouter(t)
+	todoRepo, userRepo := store.Todos(), store.Users()
 
 	tokenNormal, err := testutil.LoginAndGetToken(t, router, "normal_user@example.com", "password123")
 	require.NoError(t, err)
@@ -307,7 +301,7 @@ func TestDeleteTodoHandler_Authorization(t *testing.T) {
 
 		require.Equal(t, http.StatusNoContent, resp.Code)
 		// 削除されたことを確認
-		_, err := todoRepo.FindByID(todoNormalUser.ID)
+		_, err := todoRepo.FindByID(context.Background(), todoNormalUser.WorkspaceID, todoNormalUser.ID)
 		require.ErrorIs(t, err, repositories.ErrTodoNotFound)
 	})
 
@@ -320,7 +314,7 @@ func TestDeleteTodoHandler_Authorization(t *testing.T) {
 
 		require.Equal(t, http.StatusForbidden, resp.Code)
 		// 削除されていないことを確認
-		_, err := todoRepo.FindByID(todoOtherUser.ID)
+		_, err := todoRepo.FindByID(context.Background(), todoOtherUser.WorkspaceID, todoOtherUser.ID)
 		require.NoError(t, err)
 	})
 
@@ -333,7 +327,7 @@ func TestDeleteTodoHandler_Authorization(t *testing.T) {
 
 		require.Equal(t, http.StatusNoContent, resp.Code)
 		// 削除されたことを確認
-		_, err := todoRepo.FindByID(todoOtherUser.ID)
+		_, err := todoRepo.FindByID(context.Background(), todoOtherUser.WorkspaceID, todoOtherUser.ID)
 		require.ErrorIs(t, err, repositories.ErrTodoNotFound)
 	})
 }
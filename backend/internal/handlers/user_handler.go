@@ -1,24 +1,33 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/password"
 	"go-next-todo/backend/internal/repositories"
 	"go-next-todo/backend/internal/services"
 )
 
 // UserHandler はユーザー関連のハンドラーを管理します。
 type UserHandler struct {
-	userService *services.UserService
-	jwtService  *services.JWTService
+	userService    *services.UserService
+	jwtService     *services.JWTService
+	sessionService *services.SessionService
+	cookies        AuthCookieConfig
 }
 
 // NewUserHandler は新しいUserHandlerを作成します。
-func NewUserHandler(userService *services.UserService, jwtService *services.JWTService) *UserHandler {
-	return &UserHandler{userService: userService, jwtService: jwtService}
+func NewUserHandler(userService *services.UserService, jwtService *services.JWTService, sessionService *services.SessionService, cookies AuthCookieConfig) *UserHandler {
+	return &UserHandler{
+		userService:    userService,
+		jwtService:     jwtService,
+		sessionService: sessionService,
+		cookies:        cookies,
+	}
 }
 
 // RegisterHandler はユーザー登録を処理します。
@@ -29,9 +38,12 @@ func (h *UserHandler) RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.RegisterUser(req)
+	user, err := h.userService.RegisterUser(c.Request.Context(), req)
 	if err != nil {
-		if err == repositories.ErrDuplicateEmail {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
+		if err == repositories.ErrDuplicateEmail || err == repositories.ErrDuplicateUsername {
 			c.JSON(http.StatusConflict, gin.H{"error": "Username or email already exists"})
 			return
 		}
@@ -50,19 +62,67 @@ func (h *UserHandler) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.AuthenticateUser(req)
+	user, err := h.userService.AuthenticateUser(c.Request.Context(), req, clientInfo(c))
 	if err != nil {
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address not verified"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(uint(user.ID), user.Email, user.Role)
+	h.respondWithToken(c, user, services.LoginMethodPassword)
+}
+
+// respondWithToken はログインに成功したユーザーにJWTを発行し、ログインのレスポンスを返します。
+// パスワード・ログインリンク・OIDC のいずれのログイン方法でも同じレスポンスになります。
+// Cookieモードでは、JWTをボディに含めず HttpOnly Cookie に設定します。
+// method はログイン方法で、ログイン成功のセキュリティイベントに記録します。
+func (h *UserHandler) respondWithToken(c *gin.Context, user *models.User, method string) {
+	token, ok := h.deliverToken(c, user)
+	if !ok {
+		return
+	}
+	h.userService.RecordLogin(c.Request.Context(), user, method, clientInfo(c))
+
+	res := gin.H{"user_id": user.ID, "role": user.Role}
+	if token != "" {
+		res["token"] = token
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// deliverToken はJWTを発行し、Cookieモードでは Cookie に設定します。
+// レスポンスボディに含めるトークンを返し、Cookieモードでは空文字を返します。
+// 失敗した場合はエラーレスポンスを書き込み、false を返します。
+func (h *UserHandler) deliverToken(c *gin.Context, user *models.User) (string, bool) {
+	token, err := h.issueToken(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		return
+		return "", false
+	}
+	if !h.cookies.Enabled {
+		return token, true
 	}
+	if err := h.cookies.setAuthCookies(c, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return "", false
+	}
+	return "", true
+}
 
-	c.JSON(http.StatusOK, gin.H{"token": token, "user_id": user.ID, "role": user.Role})
+// issueToken はリクエスト元の端末のセッションを作成し、そのセッションのJWTを発行します。
+func (h *UserHandler) issueToken(c *gin.Context, user *models.User) (string, error) {
+	session, err := h.sessionService.Start(user, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return "", err
+	}
+	return h.jwtService.GenerateToken(uint(user.ID), user.Email, user.Role, user.TokenVersion, session.ID)
 }
 
 // ProtectedHandler は認証テスト用のハンドラーです。
@@ -98,7 +158,7 @@ func (h *UserHandler) ForgotPasswordHandler(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.ForgotPasswordUser(req.Email)
+	err := h.userService.ForgotPasswordUser(c.Request.Context(), req.Email, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password reset"})
 		return
@@ -116,11 +176,90 @@ func (h *UserHandler) ResetPasswordHandler(c *gin.Context) {
 
 	token := c.Param("token")
 
-	err := h.userService.ResetPasswordUser(token, req.Password)
+	err := h.userService.ResetPasswordUser(c.Request.Context(), token, req.Password, clientInfo(c))
 	if err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
 }
+
+// writePasswordPolicyError はパスワードポリシー違反のエラーであれば、違反の一覧を含む400レスポンスを書き込み true を返します。
+func writePasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":      "Password does not meet the password policy",
+		"violations": policyErr.Violations,
+	})
+	return true
+}
+
+// VerifyEmailHandler はメールアドレス確認トークンを検証します。
+func (h *UserHandler) VerifyEmailHandler(c *gin.Context) {
+	token := c.Param("token")
+
+	if err := h.userService.VerifyEmail(c.Request.Context(), token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerificationHandler は確認メールの再送を処理します。
+func (h *UserHandler) ResendVerificationHandler(c *gin.Context) {
+	var req models.UserResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.userService.ResendVerification(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// RequestMagicLinkHandler はログインリンクをメールで送信します。
+func (h *UserHandler) RequestMagicLinkHandler(c *gin.Context) {
+	var req models.UserMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.userService.RequestMagicLink(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send login link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Login link sent"})
+}
+
+// MagicLinkLoginHandler はログインリンクのトークンをJWTと交換します。
+func (h *UserHandler) MagicLinkLoginHandler(c *gin.Context) {
+	user, err := h.userService.ExchangeMagicLink(c.Request.Context(), c.Param("token"), clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrMagicLinkInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired login link"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+
+	h.respondWithToken(c, user, services.LoginMethodMagicLink)
+}
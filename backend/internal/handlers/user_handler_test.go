@@ -3,17 +3,21 @@ package handlers_test
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"go-next-todo/backend/testutil"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
 	"go-next-todo/backend/internal/models"
 	"go-next-todo/backend/internal/repositories"
@@ -29,13 +33,13 @@ func generateResetToken() (string, error) {
 }
 
 func TestRegisterUser_Success(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
+	// ユーザー名は8文字以上
 	newUserData := map[string]string{
-		"username": "newuser",
+		"username": "newuser1",
 		"email":    "newuser@example.com",
-		"password": "newpassword",
+		"password": "Blue-Kettle-Morning-42",
 	}
 	jsonValue, _ := json.Marshal(newUserData)
 
@@ -51,15 +55,14 @@ func TestRegisterUser_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &responseUser)
 	assert.NoError(t, err, "Response should be a valid JSON user object")
 	assert.NotZero(t, responseUser.ID, "Expected a non-zero User ID")
-	assert.Equal(t, "newuser", responseUser.Username, "Expected username to match")
+	assert.Equal(t, "newuser1", responseUser.Username, "Expected username to match")
 	assert.Equal(t, "newuser@example.com", responseUser.Email, "Expected email to match")
 	assert.Equal(t, "user", responseUser.Role, "Expected default role to be 'user'")
 	assert.Empty(t, responseUser.PasswordHash, "Password hash should not be returned in response")
 }
 
 func TestRegisterUser_InvalidInput(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	invalidUserData := map[string]string{
 		"username": "invaliduser",
@@ -82,8 +85,8 @@ func TestRegisterUser_InvalidInput(t *testing.T) {
 }
 
 func TestRegisterUser_DuplicateEmail(t *testing.T) {
-	db, r, _, userRepo := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
 
 	existingUser := models.User{
 		Username:     "existing",
@@ -91,13 +94,13 @@ func TestRegisterUser_DuplicateEmail(t *testing.T) {
 		PasswordHash: "hashedpass",
 		Role:         "user",
 	}
-	_, err := userRepo.Create(&existingUser)
+	_, err := userRepo.Create(context.Background(), &existingUser)
 	assert.NoError(t, err)
 
 	duplicateUserData := map[string]string{
 		"username": "anotheruser",
 		"email":    "duplicate@example.com",
-		"password": "somepassword",
+		"password": "Quiet-Harbor-Lantern-7",
 	}
 	jsonValue, _ := json.Marshal(&duplicateUserData)
 
@@ -115,8 +118,7 @@ func TestRegisterUser_DuplicateEmail(t *testing.T) {
 }
 
 func TestLoginUser_Success(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	loginCredentials := map[string]string{
 		"email":    "normal_user@example.com",
@@ -140,9 +142,72 @@ func TestLoginUser_Success(t *testing.T) {
 	assert.NotEmpty(t, token, "Expected token to be non-empty")
 }
 
+func TestLoginUser_RehashesLegacyBcryptHash(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("legacypass"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	user, err := userRepo.Create(context.Background(), &models.User{
+		Username:     "legacyuser",
+		Email:        "legacy@example.com",
+		PasswordHash: string(legacyHash),
+		Role:         models.RoleUser,
+	})
+	require.NoError(t, err)
+	testutil.AddTestWorkspaceMember(t, userRepo, user)
+
+	token, err := testutil.LoginAndGetToken(t, r, "legacy@example.com", "legacypass")
+	require.NoError(t, err)
+
+	// ログイン時に現在の設定（Argon2id）でハッシュし直される
+	updated, err := userRepo.FindByID(context.Background(), uint(user.ID))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(updated.PasswordHash, "$argon2id$"), "Expected hash to be upgraded to argon2id")
+	assert.NoError(t, repositories.VerifyPassword(updated.PasswordHash, "legacypass"))
+	assert.Equal(t, user.TokenVersion, updated.TokenVersion, "Rehashing must not invalidate existing sessions")
+
+	// 既存のトークンも新しいハッシュでのログインも有効
+	req, _ := http.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	_, err = testutil.LoginAndGetToken(t, r, "legacy@example.com", "legacypass")
+	assert.NoError(t, err)
+	again, err := userRepo.FindByID(context.Background(), uint(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, updated.PasswordHash, again.PasswordHash, "Up-to-date hashes should not be rewritten")
+}
+
+func TestLoginUser_DisabledAccountIsNotRehashed(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("legacypass"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	user, err := userRepo.Create(context.Background(), &models.User{
+		Username:     "legacyuser",
+		Email:        "legacy@example.com",
+		PasswordHash: string(legacyHash),
+		Role:         models.RoleUser,
+	})
+	require.NoError(t, err)
+	testutil.AddTestWorkspaceMember(t, userRepo, user)
+	require.NoError(t, userRepo.SetDisabled(context.Background(), uint(user.ID), true))
+
+	_, err = testutil.LoginAndGetToken(t, r, "legacy@example.com", "legacypass")
+	require.Error(t, err)
+
+	// ログインを拒否したアカウントのハッシュは書き換えない
+	updated, err := userRepo.FindByID(context.Background(), uint(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, string(legacyHash), updated.PasswordHash)
+}
+
 func TestLoginUser_InvalidCredentials(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	loginCredentials := map[string]string{
 		"email":    "nonexistent@example.com",
@@ -164,18 +229,17 @@ func TestLoginUser_InvalidCredentials(t *testing.T) {
 }
 
 func TestResetPassword_Success(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, store, _ := testutil.SetupMemoryRouter(t)
 
 	// トークンを作成
-	resetTokenRepo := repositories.NewMySQLResetTokenRepo(db)
+	resetTokenRepo := store.ResetTokens()
 	token, _ := generateResetToken()
 	resetToken := &models.PasswordResetToken{
 		UserID:    1,
 		Token:     token,
 		ExpiresAt: time.Now().Add(1 * time.Hour),
 	}
-	err := resetTokenRepo.Save(resetToken)
+	err := resetTokenRepo.Save(context.Background(), resetToken)
 	assert.NoError(t, err)
 
 	resetData := map[string]string{
@@ -196,9 +260,64 @@ func TestResetPassword_Success(t *testing.T) {
 	assert.Contains(t, response["message"], "Password reset successfully")
 }
 
+func TestResetPassword_TokenHardening(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+
+	resetTokenRepo := store.ResetTokens()
+	saveToken := func() string {
+		token, _ := generateResetToken()
+		require.NoError(t, resetTokenRepo.Save(context.Background(), &models.PasswordResetToken{
+			UserID:    1,
+			Token:     token,
+			ExpiresAt: time.Now().Add(1 * time.Hour),
+		}))
+		return token
+	}
+	resetWith := func(token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"password": "Blue-Kettle-Morning-42"})
+		req, _ := http.NewRequest(http.MethodPost, "/api/reset-password/"+token, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	oldToken := saveToken()
+
+	t.Run("Only the token hash is stored", func(t *testing.T) {
+		tokens, err := resetTokenRepo.FindByUserID(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Empty(t, tokens[0].Token, "the plain token must not be kept")
+		found, err := resetTokenRepo.FindByToken(context.Background(), oldToken)
+		require.NoError(t, err)
+		assert.Equal(t, tokens[0].ID, found.ID)
+	})
+
+	t.Run("A new request invalidates older tokens", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"email": "normal_user@example.com"})
+		req, _ := http.NewRequest(http.MethodPost, "/api/forgot-password", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = resetWith(oldToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("A token can only be used once", func(t *testing.T) {
+		token := saveToken()
+		w := resetWith(token)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = resetWith(token)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
 func TestForgotPassword_Success(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	forgotData := map[string]string{
 		"email": "normal_user@example.com",
@@ -219,8 +338,7 @@ func TestForgotPassword_Success(t *testing.T) {
 }
 
 func TestForgotPassword_InvalidEmail(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	forgotData := map[string]string{
 		"email": "invalid-email",
@@ -239,3 +357,216 @@ func TestForgotPassword_InvalidEmail(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, response["error"], "Invalid request payload")
 }
+
+func TestVerifyEmail_Success(t *testing.T) {
+	r, store, mailer := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	newUserData := map[string]string{
+		"username": "verifyuser",
+		"email":    "verifyuser@example.com",
+		"password": "Velvet-Canyon-Ember-19",
+	}
+	jsonValue, _ := json.Marshal(newUserData)
+
+	req, _ := http.NewRequest("POST", "/api/register", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var responseUser models.User
+	err := json.Unmarshal(w.Body.Bytes(), &responseUser)
+	assert.NoError(t, err)
+	assert.Nil(t, responseUser.EmailVerifiedAt, "Newly registered user should not be verified")
+
+	// 登録時に発行されたトークン
+	token := mailer.LastToken(t, "verifyuser@example.com", "verify-email")
+
+	req, _ = http.NewRequest("POST", "/api/verify-email/"+token, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected HTTP Status Code 200 OK")
+
+	verifiedUser, err := userRepo.FindByEmail(context.Background(), "verifyuser@example.com")
+	assert.NoError(t, err)
+	assert.NotNil(t, verifiedUser.EmailVerifiedAt, "Expected email to be verified")
+
+	// 同じトークンは再利用できない
+	req, _ = http.NewRequest("POST", "/api/verify-email/"+token, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected used token to be rejected")
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	req, _ := http.NewRequest("POST", "/api/verify-email/invalidtoken", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected HTTP Status Code 400 Bad Request")
+}
+
+func TestVerifyEmail_TokenIsConsumedOnce(t *testing.T) {
+	_, store, _ := testutil.SetupMemoryRouter(t)
+
+	verifyRepo := store.VerificationTokens()
+	require.NoError(t, verifyRepo.Save(&models.EmailVerificationToken{
+		UserID:    1,
+		Email:     "normal_user@example.com",
+		Purpose:   models.VerificationPurposeVerify,
+		Token:     "once",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	vt, err := verifyRepo.FindByToken("once")
+	require.NoError(t, err)
+
+	// 同時に使用されても1回しか成功しない
+	require.NoError(t, verifyRepo.MarkUsed(vt.ID))
+	assert.ErrorIs(t, verifyRepo.MarkUsed(vt.ID), repositories.ErrVerificationTokenNotFound)
+}
+
+func TestEmailVerificationMode_BlocksLogin(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_MODE", "login")
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	_, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.Contains(t, err.Error(), "Email address not verified")
+
+	user, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.MarkEmailVerified(context.Background(), uint(user.ID)))
+
+	_, err = testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	assert.NoError(t, err)
+}
+
+func TestEmailVerificationMode_BlocksTodoCreation(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_MODE", "todos")
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
+	require.NoError(t, err, "ログインは確認前でも許可される")
+
+	body, _ := json.Marshal(map[string]string{"title": "Blocked"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/todos", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// 一覧の取得は制限しない
+	req, _ = http.NewRequest(http.MethodGet, "/api/todos", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	user, err := userRepo.FindByEmail(context.Background(), "normal_user@example.com")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.MarkEmailVerified(context.Background(), uint(user.ID)))
+
+	testutil.CreateTestTodo(t, r, token, "Allowed", false)
+}
+
+func TestResendVerification_UnknownEmail(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	jsonValue, _ := json.Marshal(map[string]string{"email": "unknown@example.com"})
+
+	req, _ := http.NewRequest("POST", "/api/resend-verification", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// メールアドレスの存在が分からないよう成功扱い
+	assert.Equal(t, http.StatusOK, w.Code, "Expected HTTP Status Code 200 OK")
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	r, _, mailer := testutil.SetupMemoryRouter(t)
+
+	jsonValue, _ := json.Marshal(map[string]string{"email": "normal_user@example.com"})
+	req, _ := http.NewRequest("POST", "/api/login/magic-link", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	token := mailer.LastToken(t, "normal_user@example.com", "login/magic-link")
+
+	req, _ = http.NewRequest("POST", "/api/login/magic-link/"+token, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Expected HTTP Status Code 200 OK")
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response["token"])
+
+	// 同じリンクは再利用できない
+	req, _ = http.NewRequest("POST", "/api/login/magic-link/"+token, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected used link to be rejected")
+}
+
+func TestMagicLinkLogin_NewLinkInvalidatesOlderOnes(t *testing.T) {
+	r, _, mailer := testutil.SetupMemoryRouter(t)
+
+	requestLink := func() string {
+		jsonValue, _ := json.Marshal(map[string]string{"email": "normal_user@example.com"})
+		req, _ := http.NewRequest("POST", "/api/login/magic-link", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return mailer.LastToken(t, "normal_user@example.com", "login/magic-link")
+	}
+	oldToken := requestLink()
+	newToken := requestLink()
+	require.NotEqual(t, oldToken, newToken)
+
+	// 新しいリンクを発行すると以前のリンクは使えない
+	req, _ := http.NewRequest("POST", "/api/login/magic-link/"+oldToken, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected superseded link to be rejected")
+
+	req, _ = http.NewRequest("POST", "/api/login/magic-link/"+newToken, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestMagicLinkLogin_ExpiredToken(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+
+	err := store.MagicLinkTokens().Save(&models.MagicLinkToken{UserID: 1, Token: "expiredtoken", ExpiresAt: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/api/login/magic-link/expiredtoken", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMagicLinkRequest_UnknownEmail(t *testing.T) {
+	r, _, _ := testutil.SetupMemoryRouter(t)
+
+	jsonValue, _ := json.Marshal(map[string]string{"email": "nobody@example.com"})
+	req, _ := http.NewRequest("POST", "/api/login/magic-link", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Unknown emails must not be revealed")
+}
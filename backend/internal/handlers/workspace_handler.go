@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/services"
+)
+
+// WorkspaceHandler はワークスペースとメンバー管理のハンドラーです。
+type WorkspaceHandler struct {
+	workspaceService *services.WorkspaceService
+}
+
+// NewWorkspaceHandler は新しいWorkspaceHandlerを作成します。
+func NewWorkspaceHandler(workspaceService *services.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{workspaceService: workspaceService}
+}
+
+// writeWorkspaceError はワークスペース操作のエラーをレスポンスに変換します。
+// 所属していないワークスペースは存在を明かさないよう 404 として扱います。
+func writeWorkspaceError(c *gin.Context, err error, fallback string) {
+	switch {
+	case err == repositories.ErrWorkspaceNotFound, err == repositories.ErrNotMember:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+	case err == repositories.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case err == repositories.ErrAlreadyMember:
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+	case errors.Is(err, services.ErrWorkspaceForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	case err == repositories.ErrLastOwner:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace must have at least one owner"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// workspaceParams はパスの :id（と :user_id）を取得します。
+func workspaceParams(c *gin.Context, withUser bool) (workspaceID, userID int, ok bool) {
+	workspaceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, 0, false
+	}
+	if withUser {
+		userID, err = strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+			return 0, 0, false
+		}
+	}
+	return workspaceID, userID, true
+}
+
+// ListWorkspacesHandler はログイン中のユーザーが所属するワークスペースを返します。
+func (h *WorkspaceHandler) ListWorkspacesHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	workspaces, err := h.workspaceService.ListWorkspaces(c.Request.Context(), int(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workspaces"})
+		return
+	}
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// CreateWorkspaceHandler はワークスペースを作成します。作成者がオーナーになります。
+func (h *WorkspaceHandler) CreateWorkspaceHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req models.WorkspaceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	ws, err := h.workspaceService.CreateWorkspace(c.Request.Context(), int(userID), req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create workspace"})
+		return
+	}
+	c.JSON(http.StatusCreated, ws)
+}
+
+// ListMembersHandler はワークスペースのメンバー一覧を返します。
+func (h *WorkspaceHandler) ListMembersHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	workspaceID, _, ok := workspaceParams(c, false)
+	if !ok {
+		return
+	}
+
+	members, err := h.workspaceService.ListMembers(c.Request.Context(), int(userID), workspaceID)
+	if err != nil {
+		writeWorkspaceError(c, err, "Failed to fetch members")
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMemberHandler はメールアドレスで指定したユーザーをワークスペースに追加します。
+func (h *WorkspaceHandler) AddMemberHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	workspaceID, _, ok := workspaceParams(c, false)
+	if !ok {
+		return
+	}
+	var req models.WorkspaceAddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	member, err := h.workspaceService.AddMember(c.Request.Context(), int(userID), workspaceID, req.Email, req.Role)
+	if err != nil {
+		writeWorkspaceError(c, err, "Failed to add member")
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMemberHandler はメンバーのロールを変更します。
+func (h *WorkspaceHandler) UpdateMemberHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	workspaceID, targetID, ok := workspaceParams(c, true)
+	if !ok {
+		return
+	}
+	var req models.WorkspaceUpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	member, err := h.workspaceService.UpdateMemberRole(c.Request.Context(), int(userID), workspaceID, targetID, req.Role)
+	if err != nil {
+		writeWorkspaceError(c, err, "Failed to update member")
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMemberHandler はメンバーをワークスペースから外します。
+func (h *WorkspaceHandler) RemoveMemberHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	workspaceID, targetID, ok := workspaceParams(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.workspaceService.RemoveMember(c.Request.Context(), int(userID), workspaceID, targetID); err != nil {
+		writeWorkspaceError(c, err, "Failed to remove member")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,248 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/testutil"
+)
+
+func TestWorkspaces_IsolateTodos(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	alice := testutil.CreateTestUser(t, userRepo, "alice_user", "alice@example.com", "password123", "user")
+	testutil.CreateTestUser(t, userRepo, "bobby_user", "bob@example.com", "password123", "user")
+
+	tokenAlice, err := testutil.LoginAndGetToken(t, r, "alice@example.com", "password123")
+	require.NoError(t, err)
+	tokenBob, err := testutil.LoginAndGetToken(t, r, "bob@example.com", "password123")
+	require.NoError(t, err)
+	tokenAdmin, err := testutil.LoginAndGetToken(t, r, "admin@example.com", "adminpass")
+	require.NoError(t, err)
+
+	// Alice が新しいワークスペースを作成し、オーナーになる
+	body, _ := json.Marshal(map[string]string{"name": "Team A"})
+	req, _ := http.NewRequest(http.MethodPost, "/api/workspaces", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAlice)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var teamA models.Workspace
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &teamA))
+	assert.Equal(t, models.WorkspaceRoleOwner, teamA.Role)
+	teamAHeader := strconv.Itoa(teamA.ID)
+
+	// Team A にTodoを作成
+	body, _ = json.Marshal(map[string]interface{}{"title": "Team A secret", "completed": false})
+	req, _ = http.NewRequest(http.MethodPost, "/api/todos", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+tokenAlice)
+	req.Header.Set("X-Workspace-ID", teamAHeader)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var teamATodo models.Todo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &teamATodo))
+	assert.Equal(t, teamA.ID, teamATodo.WorkspaceID)
+
+	t.Run("Todo is not visible from the default workspace", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/todos/%d", teamATodo.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+tokenAlice)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Global admin is not a member of other workspaces", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenAdmin)
+		req.Header.Set("X-Workspace-ID", teamAHeader)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Non-member cannot read members", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/workspaces/%d/members", teamA.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+tokenBob)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid workspace header is rejected", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenAlice)
+		req.Header.Set("X-Workspace-ID", "abc")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Owner adds a member who only sees their own todos", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"email": "bob@example.com", "role": "member"})
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/workspaces/%d/members", teamA.ID), bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+tokenAlice)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		req, _ = http.NewRequest(http.MethodGet, "/api/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenBob)
+		req.Header.Set("X-Workspace-ID", teamAHeader)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var todos []models.Todo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+		assert.Empty(t, todos)
+
+		req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/api/todos/%d", teamATodo.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+tokenBob)
+		req.Header.Set("X-Workspace-ID", teamAHeader)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Member cannot manage members", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"role": "admin"})
+		req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/workspaces/%d/members/%d", teamA.ID, alice.ID), bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+tokenBob)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Last owner cannot leave", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/workspaces/%d/members/%d", teamA.ID, alice.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+tokenAlice)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestWorkspaces_DefaultCreatedOnceUnderConcurrency(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+
+	// どのワークスペースにも所属していないユーザー
+	hashed, err := repositories.HashPassword("password123")
+	require.NoError(t, err)
+	_, err = userRepo.Create(context.Background(), &models.User{Username: "loner_user", Email: "loner@example.com", PasswordHash: hashed, Role: models.RoleUser})
+	require.NoError(t, err)
+	token, err := testutil.LoginAndGetToken(t, r, "loner@example.com", "password123")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "/api/todos", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/workspaces", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var workspaces []models.Workspace
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &workspaces))
+	require.Len(t, workspaces, 1)
+	assert.Equal(t, "loner_user's workspace", workspaces[0].Name)
+	assert.Equal(t, models.WorkspaceRoleOwner, workspaces[0].Role)
+}
+
+func TestWorkspaces_ConcurrentDemotionsKeepAnOwner(t *testing.T) {
+	r, store, _ := testutil.SetupMemoryRouter(t)
+	userRepo := store.Users()
+	workspaces := store.Workspaces()
+
+	// 8人のオーナーが同時に自分をメンバーに変更しても、最後の1人は変更できない
+	const owners = 8
+	tokens := make([]string, owners)
+	ids := make([]int, owners)
+	var ws *models.Workspace
+	for i := range owners {
+		email := fmt.Sprintf("owner%d@example.com", i)
+		user := testutil.CreateTestUser(t, userRepo, fmt.Sprintf("owner_user%d", i), email, "password123", "user")
+		ids[i] = user.ID
+		if i == 0 {
+			var err error
+			ws, err = workspaces.Create(context.Background(), "Shared Team", user.ID)
+			require.NoError(t, err)
+		} else {
+			require.NoError(t, workspaces.AddMember(context.Background(), ws.ID, user.ID, models.WorkspaceRoleOwner))
+		}
+		token, err := testutil.LoginAndGetToken(t, r, email, "password123")
+		require.NoError(t, err)
+		tokens[i] = token
+	}
+
+	var wg sync.WaitGroup
+	codes := make(chan int, owners)
+	for i := range owners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, _ := json.Marshal(map[string]string{"role": models.WorkspaceRoleMember})
+			req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/workspaces/%d/members/%d", ws.ID, ids[i]), bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+tokens[i])
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: owners - 1, http.StatusBadRequest: 1}, counts)
+
+	members, err := workspaces.ListMembers(context.Background(), ws.ID)
+	require.NoError(t, err)
+	remaining := 0
+	for _, m := range members {
+		if m.Role == models.WorkspaceRoleOwner {
+			remaining++
+		}
+	}
+	assert.Equal(t, 1, remaining)
+}
@@ -0,0 +1,152 @@
+// Package health はライブネス・レディネスプローブ用の依存先のチェックを扱います。
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/migrations"
+)
+
+// DefaultTimeout は1つのチェックにかける最大時間のデフォルト値です。
+const DefaultTimeout = 2 * time.Second
+
+// チェック結果の状態です。
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// StatusWarn は必須でないチェックが失敗した状態です。全体の状態には影響しません。
+	StatusWarn = "warn"
+)
+
+// Check は1つの依存先のチェックです。
+// Run が返すエラーのメッセージはレスポンスに含まれるため、接続先や内部のエラーを含めないでください。
+type Check struct {
+	Name string
+	// Optional なチェックが失敗しても、全体の状態は失敗になりません。
+	Optional bool
+	Run      func(ctx context.Context) error
+}
+
+// Result は1つのチェックの結果です。
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Optional  bool    `json:"optional"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report はすべてのチェックの結果です。
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy は必須のチェックがすべて成功したかを返します。
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// Checker はチェックをまとめて実行します。
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker は各チェックを timeout で打ち切る Checker を作成します。
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Run はすべてのチェックを並行して実行し、登録した順に結果を返します。
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make([]Result, len(c.checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, r := range report.Checks {
+		if r.Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusOK,
+		Optional:  check.Optional,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		if check.Optional {
+			result.Status = StatusWarn
+		}
+		result.Error = err.Error()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Error = "timed out"
+		}
+	}
+	return result
+}
+
+// Database はデータベースに ping できるかを確認します。
+func Database(db *sql.DB) Check {
+	return Check{Name: "database", Run: func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			logging.FromContext(ctx).Error("health check: database ping failed", "error", err)
+			return errors.New("database is unreachable")
+		}
+		return nil
+	}}
+}
+
+// Migrations は未適用のマイグレーションがないかを確認します。
+func Migrations(m *migrations.Migrator) Check {
+	return Check{Name: "migrations", Run: func(ctx context.Context) error {
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("health check: could not read migration status", "error", err)
+			return errors.New("could not read migration status")
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("pending migrations: %d", len(pending))
+		}
+		return nil
+	}}
+}
+
+// TCP は addr にTCPで接続できるかを確認します。メールサーバーなど、応答の中身を確認しない依存先に使います。
+func TCP(name, addr string, optional bool) Check {
+	return Check{Name: name, Optional: optional, Run: func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			logging.FromContext(ctx).Warn("health check: connection failed", "check", name, "addr", addr, "error", err)
+			return errors.New("connection failed")
+		}
+		return conn.Close()
+	}}
+}
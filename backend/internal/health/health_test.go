@@ -0,0 +1,54 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/health"
+)
+
+func TestChecker(t *testing.T) {
+	ok := health.Check{Name: "ok", Run: func(ctx context.Context) error { return nil }}
+	failing := health.Check{Name: "failing", Run: func(ctx context.Context) error { return errors.New("boom") }}
+	slow := health.Check{Name: "slow", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	t.Run("Reports ok when every check passes", func(t *testing.T) {
+		report := health.NewChecker(time.Second, ok).Run(context.Background())
+		assert.True(t, report.Healthy())
+		require.Len(t, report.Checks, 1)
+		assert.Equal(t, health.StatusOK, report.Checks[0].Status)
+		assert.Empty(t, report.Checks[0].Error)
+	})
+
+	t.Run("Fails when a required check fails", func(t *testing.T) {
+		report := health.NewChecker(time.Second, ok, failing).Run(context.Background())
+		assert.False(t, report.Healthy())
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, "ok", report.Checks[0].Name)
+		assert.Equal(t, health.StatusFail, report.Checks[1].Status)
+		assert.Equal(t, "boom", report.Checks[1].Error)
+	})
+
+	t.Run("Only warns when an optional check fails", func(t *testing.T) {
+		optional := failing
+		optional.Optional = true
+		report := health.NewChecker(time.Second, ok, optional).Run(context.Background())
+		assert.True(t, report.Healthy())
+		assert.Equal(t, health.StatusWarn, report.Checks[1].Status)
+	})
+
+	t.Run("Times out slow checks", func(t *testing.T) {
+		report := health.NewChecker(20*time.Millisecond, slow).Run(context.Background())
+		assert.False(t, report.Healthy())
+		assert.Equal(t, "timed out", report.Checks[0].Error)
+		assert.GreaterOrEqual(t, report.Checks[0].LatencyMS, float64(20))
+	})
+}
@@ -0,0 +1,46 @@
+// Package jobs はバックグラウンドで定期実行する処理を扱います。
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Every は ctx がキャンセルされるまで interval ごとに fn を実行します。
+// 起動直後にも1回実行します。fn のエラーはログに出力し、次回の実行を続けます。
+func Every(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(); err != nil {
+			slog.Error("job failed", "job", name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Group は複数の定期実行ジョブを起動し、すべての停止を待てるようにします。
+type Group struct {
+	wg sync.WaitGroup
+}
+
+// Every は Every をゴルーチンで起動します。
+func (g *Group) Every(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		Every(ctx, name, interval, fn)
+	}()
+}
+
+// Wait は起動したジョブがすべて停止するまで待ちます。実行中の fn は最後まで実行されます。
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
@@ -0,0 +1,114 @@
+// Package logging は log/slog による構造化ログと、リクエストごとのロガーの受け渡しを扱います。
+//
+// リクエストごとのロガーはリクエストIDなどの属性を持ち、context.Context で handlers から services・repositories に渡します。
+// トークンやパスワードなどの秘密情報とメールアドレスは、属性のキーから判断して出力前に伏せます。
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"go-next-todo/backend/internal/config"
+)
+
+// redacted は伏せた値の代わりに出力する文字列です。
+const redacted = "[REDACTED]"
+
+// New は設定に従ったロガーを作成します。秘密情報は Redact で伏せます。
+func New(w io.Writer, cfg config.Log) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(cfg.Level),
+		ReplaceAttr: Redact,
+	}
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func parseLevel(s string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// sensitiveKeys は値をすべて伏せる属性のキーです。"_token" などで終わるキーも伏せます。
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"authorization": true,
+	"cookie":        true,
+	"dsn":           true,
+}
+
+var sensitiveSuffixes = []string{"_password", "_secret", "_token"}
+
+// Redact は slog.HandlerOptions.ReplaceAttr として使い、秘密情報の属性を伏せ、メールアドレスの属性を MaskEmail で一部だけにします。
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case isSensitive(key):
+		return slog.String(a.Key, redacted)
+	case key == "email" || strings.HasSuffix(key, "_email"):
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	}
+	return a
+}
+
+func isSensitive(key string) bool {
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskEmail はメールアドレスのローカル部の先頭1文字以外を伏せます（"alice@example.com" → "a***@example.com"）。
+// ログから利用者を調べられる程度の情報だけを残します。
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return redacted
+	}
+	// マルチバイト文字を途中で切らないよう、先頭の1文字をルーン単位で取り出す
+	_, size := utf8.DecodeRuneInString(local)
+	return local[:size] + "***@" + domain
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithLogger は logger を持つ context を返します。
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext は ctx のロガーを返します。ロガーがない場合は slog.Default を返します。
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithRequestID はリクエストIDを持つ context を返します。
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID は ctx のリクエストIDを返します。ない場合は空文字列です。
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,68 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/config"
+	"go-next-todo/backend/internal/logging"
+)
+
+func TestNew_RedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.Log{Level: "info", Format: "json"})
+
+	logger.Info("password reset requested",
+		"token", "raw-reset-token",
+		"reset_token", "another-token",
+		"password", "hunter2",
+		"email", "alice@example.com",
+		"new_email", "bob@example.org",
+		"user_id", 42,
+	)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), buf.String())
+	assert.Equal(t, "[REDACTED]", entry["token"])
+	assert.Equal(t, "[REDACTED]", entry["reset_token"])
+	assert.Equal(t, "[REDACTED]", entry["password"])
+	assert.Equal(t, "a***@example.com", entry["email"])
+	assert.Equal(t, "b***@example.org", entry["new_email"])
+	assert.Equal(t, float64(42), entry["user_id"])
+	assert.NotContains(t, buf.String(), "raw-reset-token")
+	assert.NotContains(t, buf.String(), "alice@")
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.Log{Level: "warn", Format: "text"})
+	logger.Info("hidden")
+	logger.Warn("shown")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "msg=shown")
+}
+
+func TestMaskEmail(t *testing.T) {
+	assert.Equal(t, "a***@example.com", logging.MaskEmail("alice@example.com"))
+	assert.Equal(t, "山***@example.com", logging.MaskEmail("山田@example.com"))
+	assert.Equal(t, "[REDACTED]", logging.MaskEmail("not-an-email"))
+	assert.Equal(t, "[REDACTED]", logging.MaskEmail("@example.com"))
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.Log{Level: "info", Format: "json"}).With("request_id", "req-1")
+
+	ctx := logging.WithRequestID(logging.WithLogger(context.Background(), logger), "req-1")
+	logging.FromContext(ctx).Info("hello")
+
+	assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+	assert.Equal(t, "req-1", logging.RequestID(ctx))
+	assert.NotNil(t, logging.FromContext(context.Background()))
+	assert.Empty(t, logging.RequestID(context.Background()))
+}
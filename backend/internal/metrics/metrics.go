@@ -0,0 +1,150 @@
+// Package metrics は Prometheus 形式で公開するメトリクスを扱います。
+//
+// HTTP リクエストとアプリケーションのイベント（ログイン、Todo の作成・完了）のメトリクスはパッケージ変数として定義し、
+// NewRegistry で作成したレジストリに登録して /metrics で公開します。
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "todo"
+
+// unmatchedRoute はどのルートにも一致しなかったリクエストの route ラベルです。
+// パスをそのままラベルにすると、トークンを含むパスや存在しないパスの数だけ系列が増えてしまうためです。
+const unmatchedRoute = "unmatched"
+
+// otherMethod は標準以外のHTTPメソッドの method ラベルです。
+// クライアントが送る任意のメソッド名をラベルにすると系列が際限なく増えてしまうためです。
+const otherMethod = "other"
+
+// knownMethods は method ラベルにそのまま使うHTTPメソッドです。
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// ログイン結果の result ラベルの値です。
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failure"
+)
+
+var (
+	// HTTPRequests はルート・メソッド・ステータスコードごとのリクエスト数です。
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration はルート・メソッドごとのリクエストの処理時間です。
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// Logins はログイン方法・結果ごとのログイン試行の数です。reason は失敗の理由で、成功した場合は空です。
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Number of login attempts by method, result and failure reason.",
+	}, []string{"method", "result", "reason"})
+
+	// TodosCreated は作成された Todo の数です。
+	TodosCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "todos_created_total",
+		Help:      "Number of todos created.",
+	})
+
+	// TodosCompleted は完了にされた Todo の数です。完了の状態で作成された Todo も含みます。
+	TodosCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "todos_completed_total",
+		Help:      "Number of todos marked as completed.",
+	})
+)
+
+// NewRegistry はアプリケーションのメトリクス、db のコネクションプールの統計、Go ランタイムとプロセスのメトリクスを登録したレジストリを作成します。
+// パッケージ変数のメトリクスは複数のレジストリに登録できるため、テストでルーターを何度作成しても登録が衝突しません。
+func NewRegistry(db *sql.DB) *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		HTTPRequests,
+		HTTPRequestDuration,
+		Logins,
+		TodosCreated,
+		TodosCompleted,
+		collectors.NewDBStatsCollector(db, "mysql"),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// Handler は reg のメトリクスを Prometheus のテキスト形式で返すハンドラーです。
+func Handler(reg *prometheus.Registry) gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+}
+
+// RequireToken は "Authorization: Bearer <token>" ヘッダーがないリクエストを 401 にするミドルウェアです。
+// token が空の場合は何も確認しません。
+func RequireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+		}
+	}
+}
+
+// Middleware はリクエストの数と処理時間を、一致したルートのパターン（"/api/todos/:id" など）ごとに記録するミドルウェアです。
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		if !knownMethods[method] {
+			method = otherMethod
+		}
+		HTTPRequests.WithLabelValues(route, method, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPRequestDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveLogin はログインの試行を記録します。成功した場合 reason は空にします。
+func ObserveLogin(method, reason string) {
+	result := LoginSucceeded
+	if reason != "" {
+		result = LoginFailed
+	}
+	Logins.WithLabelValues(method, result, reason).Inc()
+}
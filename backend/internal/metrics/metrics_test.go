@@ -0,0 +1,81 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"go-next-todo/backend/internal/metrics"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(metrics.Middleware())
+	r.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	matched := metrics.HTTPRequests.WithLabelValues("/items/:id", http.MethodGet, "204")
+	unmatched := metrics.HTTPRequests.WithLabelValues("unmatched", http.MethodGet, "404")
+	beforeMatched, beforeUnmatched := testutil.ToFloat64(matched), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/items/1", "/items/2", "/secret-token-in-path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// パスではなくルートのパターンごとに集計し、一致しないパスは1つの系列にまとめる
+	assert.Equal(t, beforeMatched+2, testutil.ToFloat64(matched))
+	assert.Equal(t, beforeUnmatched+1, testutil.ToFloat64(unmatched))
+}
+
+func TestMiddleware_UnknownMethods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(metrics.Middleware())
+
+	other := metrics.HTTPRequests.WithLabelValues("unmatched", "other", "404")
+	before := testutil.ToFloat64(other)
+
+	for _, method := range []string{"PROPFIND", "X-RANDOM-1", "X-RANDOM-2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+
+	// 標準以外のメソッドは1つの系列にまとめる
+	assert.Equal(t, before+3, testutil.ToFloat64(other))
+}
+
+func TestRequireToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(token, header string) int {
+		r := gin.New()
+		r.GET("/metrics", metrics.RequireToken(token), func(c *gin.Context) { c.Status(http.StatusOK) })
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("", ""), "No token configured")
+	assert.Equal(t, http.StatusUnauthorized, serve("scrape-secret", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("scrape-secret", "Bearer wrong"))
+	assert.Equal(t, http.StatusOK, serve("scrape-secret", "Bearer scrape-secret"))
+}
+
+func TestObserveLogin(t *testing.T) {
+	success := metrics.Logins.WithLabelValues("password", metrics.LoginSucceeded, "")
+	failure := metrics.Logins.WithLabelValues("password", metrics.LoginFailed, "invalid_password")
+	beforeSuccess, beforeFailure := testutil.ToFloat64(success), testutil.ToFloat64(failure)
+
+	metrics.ObserveLogin("password", "")
+	metrics.ObserveLogin("password", "invalid_password")
+	metrics.ObserveLogin("password", "invalid_password")
+
+	assert.Equal(t, beforeSuccess+1, testutil.ToFloat64(success))
+	assert.Equal(t, beforeFailure+2, testutil.ToFloat64(failure))
+}
@@ -0,0 +1,204 @@
+package migrations_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/migrations"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/testutil"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("Orders migrations by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0010_add_index.up.sql":   {Data: []byte("CREATE INDEX idx ON t (c);")},
+			"0010_add_index.down.sql": {Data: []byte("DROP INDEX idx ON t;")},
+			"0002_create_t.up.sql":    {Data: []byte("CREATE TABLE t (c INT);")},
+			"0002_create_t.down.sql":  {Data: []byte("DROP TABLE t;")},
+			"README.md":               {Data: []byte("ignored")},
+		}
+		ms, err := migrations.Load(fsys)
+		require.NoError(t, err)
+		require.Len(t, ms, 2)
+		assert.Equal(t, int64(2), ms[0].Version)
+		assert.Equal(t, "create_t", ms[0].Name)
+		assert.Equal(t, int64(10), ms[1].Version)
+	})
+
+	t.Run("Requires a down migration", func(t *testing.T) {
+		_, err := migrations.Load(fstest.MapFS{
+			"0001_create_t.up.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Rejects duplicate versions", func(t *testing.T) {
+		_, err := migrations.Load(fstest.MapFS{
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"0001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Rejects malformed file names", func(t *testing.T) {
+		_, err := migrations.Load(fstest.MapFS{
+			"create_t.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db, _, _, _ := testutil.SetupTestDB(t)
+	defer db.Close()
+
+	m, err := migrations.New(db)
+	require.NoError(t, err)
+
+	// SetupTestDB で適用済み
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "migration %d should be applied", s.Version)
+	}
+	applied, err := m.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	last := statuses[len(statuses)-1]
+	rolledBack, err := m.Down(1)
+	require.NoError(t, err)
+	require.Len(t, rolledBack, 1)
+	assert.Equal(t, last.Version, rolledBack[0].Version)
+
+	statuses, err = m.Status()
+	require.NoError(t, err)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	applied, err = m.Up()
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, last.Version, applied[0].Version)
+}
+
+// TestMigrator_UpgradesHandMadeSchema はマイグレーション導入前に手動で作成したテーブルに、後から追加した列が作成されることを確認します。
+func TestMigrator_UpgradesHandMadeSchema(t *testing.T) {
+	db, _, _, _ := testutil.SetupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("SET FOREIGN_KEY_CHECKS=0")
+	require.NoError(t, err)
+	for _, table := range []string{
+		"schema_migrations", "security_events", "sessions", "magic_link_tokens", "email_verification_tokens",
+		"password_reset_tokens", "user_identities", "oidc_auth_requests", "todos", "workspace_members",
+		"workspaces", "users", "role_permissions", "roles",
+	} {
+		_, err := db.Exec("DROP TABLE IF EXISTS " + table)
+		require.NoError(t, err)
+	}
+	_, err = db.Exec("SET FOREIGN_KEY_CHECKS=1")
+	require.NoError(t, err)
+
+	// マイグレーション導入前のテストで作成していたテーブル
+	_, err = db.Exec(`
+		CREATE TABLE users (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			username VARCHAR(255) NOT NULL UNIQUE,
+			email VARCHAR(255) NOT NULL UNIQUE,
+			password_hash VARCHAR(255) NOT NULL,
+			role ENUM('user', 'admin') NOT NULL DEFAULT 'user',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE todos (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			completed BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE password_reset_tokens (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			token VARCHAR(255) NOT NULL UNIQUE,
+			expires_at DATETIME NOT NULL,
+			used_at DATETIME NULL
+		)`)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users (id, username, email, password_hash, role) VALUES (1, 'legacy', 'legacy@example.com', 'x', 'admin')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO todos (user_id, title) VALUES (1, 'legacy todo')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES (1, 'legacy-reset-token', ?)", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	m, err := migrations.New(db)
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	for table, columns := range map[string][]string{
+		"users":                 {"email_verified_at", "token_version", "deletion_at", "disabled_at"},
+		"todos":                 {"workspace_id"},
+		"password_reset_tokens": {"token_hash", "created_at"},
+	} {
+		for _, column := range columns {
+			var count int
+			err := db.QueryRow(
+				"SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?",
+				table, column,
+			).Scan(&count)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count, "%s.%s should be added", table, column)
+		}
+	}
+
+	// 既存のユーザーのロールは roles テーブルを参照し、既存の ToDo は作成されたワークスペースに移る
+	var roleRefs int
+	require.NoError(t, db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'role' AND referenced_table_name = 'roles'`,
+	).Scan(&roleRefs))
+	assert.Equal(t, 1, roleRefs)
+
+	var workspaceName, memberRole string
+	require.NoError(t, db.QueryRow(`
+		SELECT w.name, m.role FROM todos t
+		JOIN workspaces w ON w.id = t.workspace_id
+		JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = t.user_id
+		WHERE t.title = 'legacy todo'`,
+	).Scan(&workspaceName, &memberRole))
+	assert.Equal(t, "legacy's workspace", workspaceName)
+	assert.Equal(t, "owner", memberRole)
+
+	// 平文で保存されていたリセットトークンはハッシュに置き換わり、送信済みのリンクで引き続き検索できる
+	var plaintextColumns int
+	require.NoError(t, db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'password_reset_tokens' AND column_name = 'token'`,
+	).Scan(&plaintextColumns))
+	assert.Zero(t, plaintextColumns)
+	resetToken, err := repositories.NewMySQLResetTokenRepo(db).FindByToken(context.Background(), "legacy-reset-token")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), resetToken.UserID)
+
+	// 再度適用しても何も起きない
+	applied, err := m.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
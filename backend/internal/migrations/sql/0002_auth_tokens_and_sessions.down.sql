@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS oidc_auth_requests;
+DROP TABLE IF EXISTS magic_link_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS email_verification_tokens;
+DROP TABLE IF EXISTS password_reset_tokens;
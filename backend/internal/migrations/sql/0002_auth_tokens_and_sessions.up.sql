@@ -0,0 +1,101 @@
+-- 認証に関するトークン・セッション・外部アカウント連携のテーブルです。
+
+-- リセットトークンは平文を保存せず、SHA-256 ハッシュのみを保存します。
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	expires_at DATETIME NOT NULL,
+	used_at DATETIME NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 手動で作成したデータベースの password_reset_tokens は平文の token 列を持つため、上の CREATE TABLE は何もしません。
+-- 0004 と同じく token をハッシュに置き換え、送信済みのリンクは引き続き使えるようにします。
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'password_reset_tokens' AND column_name = 'created_at') = 0,
+	'ALTER TABLE password_reset_tokens ADD COLUMN created_at DATETIME DEFAULT CURRENT_TIMESTAMP',
+	'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @plaintext_reset_tokens = (SELECT COUNT(*) FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = 'password_reset_tokens' AND column_name = 'token');
+SET @ddl = IF(@plaintext_reset_tokens > 0,
+	'ALTER TABLE password_reset_tokens ADD COLUMN token_hash CHAR(64) NULL AFTER user_id',
+	'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+SET @ddl = IF(@plaintext_reset_tokens > 0,
+	'UPDATE password_reset_tokens SET token_hash = SHA2(token, 256)',
+	'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+SET @ddl = IF(@plaintext_reset_tokens > 0,
+	'ALTER TABLE password_reset_tokens DROP COLUMN token, MODIFY token_hash CHAR(64) NOT NULL, ADD UNIQUE INDEX idx_password_reset_tokens_token_hash (token_hash)',
+	'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	purpose ENUM('verify', 'change_email') NOT NULL DEFAULT 'verify',
+	token VARCHAR(255) NOT NULL UNIQUE,
+	expires_at DATETIME NOT NULL,
+	used_at DATETIME NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id VARCHAR(64) PRIMARY KEY,
+	user_id INT NOT NULL,
+	token_version INT NOT NULL DEFAULT 0,
+	device VARCHAR(255) NOT NULL DEFAULT '',
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	user_agent VARCHAR(512) NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_seen_at DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL,
+	revoked_at DATETIME NULL,
+	impersonator_id INT NULL,
+	INDEX idx_sessions_user_id (user_id),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS magic_link_tokens (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	token VARCHAR(255) NOT NULL UNIQUE,
+	expires_at DATETIME NOT NULL,
+	used_at DATETIME NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS oidc_auth_requests (
+	state VARCHAR(255) PRIMARY KEY,
+	nonce VARCHAR(255) NOT NULL,
+	code_verifier VARCHAR(255) NOT NULL,
+	expires_at DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	issuer VARCHAR(255) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uq_user_identities_issuer_subject (issuer, subject),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+-- セキュリティイベントの監査ログです。ユーザーの削除後も記録を残すため外部キーは設定しません。
+CREATE TABLE IF NOT EXISTS security_events (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	event_type VARCHAR(64) NOT NULL,
+	user_id INT NULL,
+	actor_id INT NULL,
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	user_agent VARCHAR(512) NOT NULL DEFAULT '',
+	metadata JSON NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_security_events_user_id (user_id, id),
+	INDEX idx_security_events_type (event_type, created_at)
+);
@@ -0,0 +1,12 @@
+-- ハッシュから平文のトークンは復元できないため、発行済みのトークンは削除します。
+DELETE FROM email_verification_tokens;
+ALTER TABLE email_verification_tokens
+	DROP INDEX idx_email_verification_tokens_token_hash,
+	DROP COLUMN token_hash,
+	ADD COLUMN token VARCHAR(255) NOT NULL UNIQUE AFTER purpose;
+
+DELETE FROM magic_link_tokens;
+ALTER TABLE magic_link_tokens
+	DROP INDEX idx_magic_link_tokens_token_hash,
+	DROP COLUMN token_hash,
+	ADD COLUMN token VARCHAR(255) NOT NULL UNIQUE AFTER user_id;
@@ -0,0 +1,15 @@
+-- メール確認トークンとマジックリンクのトークンを、リセットトークンと同じく SHA-256 のハッシュで保存します。
+-- 発行済みのトークンはハッシュに置き換えるため、送信済みのリンクは引き続き使えます。
+ALTER TABLE email_verification_tokens ADD COLUMN token_hash CHAR(64) NULL AFTER purpose;
+UPDATE email_verification_tokens SET token_hash = SHA2(token, 256);
+ALTER TABLE email_verification_tokens
+	DROP COLUMN token,
+	MODIFY token_hash CHAR(64) NOT NULL,
+	ADD UNIQUE INDEX idx_email_verification_tokens_token_hash (token_hash);
+
+ALTER TABLE magic_link_tokens ADD COLUMN token_hash CHAR(64) NULL AFTER user_id;
+UPDATE magic_link_tokens SET token_hash = SHA2(token, 256);
+ALTER TABLE magic_link_tokens
+	DROP COLUMN token,
+	MODIFY token_hash CHAR(64) NOT NULL,
+	ADD UNIQUE INDEX idx_magic_link_tokens_token_hash (token_hash);
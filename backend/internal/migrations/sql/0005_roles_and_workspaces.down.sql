@@ -0,0 +1,31 @@
+-- 0001 の時点のスキーマに戻します。外部キーの名前は作成した方法によって異なるため、information_schema から取得します。
+-- 組み込み以外のロールを持つユーザーがいる場合、role を ENUM に戻せずに失敗します。
+SET @ddl = (SELECT COALESCE(MAX(CONCAT('ALTER TABLE todos DROP FOREIGN KEY ', constraint_name)), 'DO 0')
+	FROM information_schema.key_column_usage
+	WHERE table_schema = DATABASE() AND table_name = 'todos' AND column_name = 'workspace_id'
+		AND referenced_table_name IS NOT NULL);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+ALTER TABLE todos DROP COLUMN workspace_id;
+
+SET @ddl = (SELECT COALESCE(MAX(CONCAT('ALTER TABLE users DROP FOREIGN KEY ', constraint_name)), 'DO 0')
+	FROM information_schema.key_column_usage
+	WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'role'
+		AND referenced_table_name IS NOT NULL);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+ALTER TABLE users
+	MODIFY role ENUM('user', 'admin') NOT NULL DEFAULT 'user',
+	DROP COLUMN email_verified_at,
+	DROP COLUMN token_version,
+	DROP COLUMN deletion_at,
+	DROP COLUMN disabled_at;
+
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
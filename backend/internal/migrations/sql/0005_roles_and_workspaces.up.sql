@@ -0,0 +1,147 @@
+-- ロール・ワークスペースのテーブルと、0001 の users と todos に後から追加した列を作成します。
+-- 0001 は手動で作成されたデータベースでは何もしないため、列は ALTER TABLE で追加します。
+-- 以前の 0001 で列を作成済みのデータベースでも再実行できるよう、information_schema で存在を確認してから追加します。
+
+CREATE TABLE IF NOT EXISTS roles (
+	name VARCHAR(64) PRIMARY KEY,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	built_in BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role_name VARCHAR(64) NOT NULL,
+	permission VARCHAR(64) NOT NULL,
+	PRIMARY KEY (role_name, permission),
+	FOREIGN KEY (role_name) REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+	workspace_id INT NOT NULL,
+	user_id INT NOT NULL,
+	role ENUM('owner', 'admin', 'member') NOT NULL DEFAULT 'member',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (workspace_id, user_id),
+	FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- users
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'email_verified_at') = 0,
+	'ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'token_version') = 0,
+	'ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'deletion_at') = 0,
+	'ALTER TABLE users ADD COLUMN deletion_at DATETIME NULL',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'disabled_at') = 0,
+	'ALTER TABLE users ADD COLUMN disabled_at DATETIME NULL',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- 既存のユーザーのロールを外部キーで参照できるよう、組み込みロールを先に作成します。
+-- 説明と権限はアプリケーションの起動時に EnsureBuiltInRoles で設定されます
+INSERT IGNORE INTO roles (name, built_in) VALUES ('user', TRUE), ('admin', TRUE);
+SET @ddl = IF(
+	(SELECT data_type FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'role') = 'enum',
+	'ALTER TABLE users MODIFY role VARCHAR(64) NOT NULL DEFAULT ''user''',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'role'
+			AND referenced_table_name IS NOT NULL) = 0,
+	'ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- todos
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'todos' AND column_name = 'workspace_id') = 0,
+	'ALTER TABLE todos ADD COLUMN workspace_id INT NULL AFTER user_id',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- ワークスペース導入前の ToDo は、ワークスペースに所属していないユーザーごとに作成したワークスペースへ移します。
+-- 作成したワークスペースとユーザーを対応付けるため、一時的な列を使います
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'workspaces' AND column_name = 'legacy_user_id') = 0,
+	'ALTER TABLE workspaces ADD COLUMN legacy_user_id INT NULL',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+INSERT INTO workspaces (name, legacy_user_id)
+	SELECT CONCAT(u.username, '''s workspace'), u.id FROM users u
+	WHERE EXISTS (SELECT 1 FROM todos t WHERE t.user_id = u.id AND t.workspace_id IS NULL)
+		AND NOT EXISTS (SELECT 1 FROM workspace_members m WHERE m.user_id = u.id);
+INSERT IGNORE INTO workspace_members (workspace_id, user_id, role)
+	SELECT id, legacy_user_id, 'owner' FROM workspaces WHERE legacy_user_id IS NOT NULL;
+ALTER TABLE workspaces DROP COLUMN legacy_user_id;
+UPDATE todos t
+	JOIN (SELECT user_id, MIN(workspace_id) AS workspace_id FROM workspace_members GROUP BY user_id) m ON m.user_id = t.user_id
+	SET t.workspace_id = m.workspace_id
+	WHERE t.workspace_id IS NULL;
+ALTER TABLE todos MODIFY workspace_id INT NOT NULL;
+
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND table_name = 'todos' AND column_name = 'workspace_id'
+			AND referenced_table_name IS NOT NULL) = 0,
+	'ALTER TABLE todos ADD CONSTRAINT fk_todos_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE',
+	'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// UserIdentity は外部のIDプロバイダー（OIDC）のアカウントとユーザーの紐付けです。
+// Issuer と Subject の組でIDプロバイダー上のアカウントを一意に識別します。
+type UserIdentity struct {
+	ID        uint      `json:"id"`
+	UserID    int       `json:"user_id"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCAuthRequest は認可リクエストごとに発行する state・nonce・PKCE code_verifier です。
+// コールバック時に state で検索し、1回だけ使用できます。
+type OIDCAuthRequest struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// ワークスペース内で有効な権限。ワークスペースのロールから決まります（WorkspacePermissions を参照）。
+const (
+	PermTodosReadAny  = "todos.read.any"  // ワークスペース内の他のユーザーのTodoを閲覧できる
+	PermTodosWriteAny = "todos.write.any" // ワークスペース内の他のユーザーのTodoを更新・削除できる
+)
+
+// グローバルな権限の一覧。ロールには以下の権限を任意に組み合わせて付与します。
+const (
+	PermUsersRead        = "users.read"        // ユーザー一覧・詳細を閲覧できる
+	PermUsersManage      = "users.manage"      // ユーザーのロール変更・無効化・削除ができる
+	PermUsersImpersonate = "users.impersonate" // 権限を持たないユーザーになりすましてサポートできる
+	PermRolesManage      = "roles.manage"      // カスタムロールを定義できる
+	PermAuditRead        = "audit.read"        // セキュリティイベントの記録を閲覧できる
+)
+
+// AllPermissions はグローバルロールに付与できる全権限です。
+var AllPermissions = []string{
+	PermUsersRead,
+	PermUsersManage,
+	PermUsersImpersonate,
+	PermRolesManage,
+	PermAuditRead,
+}
+
+// IsValidPermission は定義済みの権限かどうかを返します。
+func IsValidPermission(p string) bool {
+	for _, perm := range AllPermissions {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet はユーザーが持つ権限の集合です。
+type PermissionSet map[string]struct{}
+
+// NewPermissionSet は権限の一覧から PermissionSet を作成します。
+func NewPermissionSet(perms []string) PermissionSet {
+	set := make(PermissionSet, len(perms))
+	for _, p := range perms {
+		set[p] = struct{}{}
+	}
+	return set
+}
+
+// Has は指定した権限を持つかどうかを返します。
+func (s PermissionSet) Has(perm string) bool {
+	_, ok := s[perm]
+	return ok
+}
+
+// Role はロールと付与された権限を表します。
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	BuiltIn     bool      `json:"built_in"` // 組み込みロールは変更・削除できない
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BuiltInRoles は起動時に必ず存在させる組み込みロールです。
+var BuiltInRoles = []Role{
+	{Name: RoleUser, Description: "Default role for registered users", Permissions: []string{}, BuiltIn: true},
+	{Name: RoleAdmin, Description: "Full access to all resources", Permissions: AllPermissions, BuiltIn: true},
+}
+
+type RoleCreateRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=64,alphanum"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type RoleUpdateRequest struct {
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// セキュリティイベントの種類です。
+const (
+	EventLoginSucceeded         = "login.succeeded"
+	EventLoginFailed            = "login.failed"
+	EventPasswordChanged        = "password.changed"
+	EventPasswordResetRequested = "password.reset_requested"
+	EventPasswordReset          = "password.reset"
+	EventPasswordResetForced    = "password.reset_forced"
+	EventEmailChangeRequested   = "email.change_requested"
+	EventAccountDeletion        = "account.deletion_scheduled"
+	EventRoleChanged            = "role.changed"
+	EventUserDisabled           = "user.disabled"
+	EventUserEnabled            = "user.enabled"
+	EventUserDeleted            = "user.deleted"
+	EventTokenRejected          = "auth.token_rejected"
+	EventImpersonationStarted   = "impersonation.started"
+	EventImpersonationAction    = "impersonation.action"
+)
+
+// ClientInfo はリクエスト元の端末の情報です。セキュリティイベントの記録に使います。
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// SecurityEvent はログインや権限変更などのセキュリティ上重要な操作の記録です。
+// UserID は操作の対象となったユーザー、ActorID は操作を行ったユーザー（本人の操作では UserID と同じ）です。
+// ユーザーが削除されても記録は残します。
+type SecurityEvent struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	UserID    *int              `json:"user_id"`
+	ActorID   *int              `json:"actor_id"`
+	IPAddress string            `json:"ip_address"`
+	UserAgent string            `json:"user_agent"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// SecurityEventFilter は管理者向けセキュリティイベント一覧の検索条件です。
+type SecurityEventFilter struct {
+	UserID    int       `form:"user_id" binding:"omitempty,min=1"`
+	ActorID   int       `form:"actor_id" binding:"omitempty,min=1"`
+	Type      string    `form:"type"`
+	IPAddress string    `form:"ip"`
+	Since     time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page      int       `form:"page" binding:"omitempty,min=1"`
+	PerPage   int       `form:"per_page" binding:"omitempty,min=1,max=100"`
+}
+
+// SecurityEventListResult はページングされたセキュリティイベント一覧です。
+type SecurityEventListResult struct {
+	Events  []*SecurityEvent `json:"events"`
+	Total   int              `json:"total"`
+	Page    int              `json:"page"`
+	PerPage int              `json:"per_page"`
+}
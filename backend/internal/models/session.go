@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Session はログインごとに発行されるセッションを表します。
+// JWT の "sid" クレームでセッションを参照し、失効したセッションのトークンは拒否されます。
+type Session struct {
+	ID     string `json:"id"`
+	UserID int    `json:"-"`
+	// TokenVersion は発行時のユーザーの token_version です。パスワード変更などで古くなったセッションは一覧に表示しません。
+	TokenVersion int        `json:"-"`
+	Device       string     `json:"device"`
+	IPAddress    string     `json:"ip_address"`
+	UserAgent    string     `json:"user_agent"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"-"`
+	// ImpersonatorID は管理者によるなりすましのセッションの場合に、なりすましている管理者のIDです。
+	ImpersonatorID *int `json:"impersonator_id,omitempty"`
+	Current        bool `json:"current"` // リクエストに使用しているセッションかどうか
+}
@@ -6,10 +6,11 @@ import (
 )
 
 type Todo struct {
-	ID        int       `json:"id,omitempty"`             // 主キー
-	UserID    int       `json:"user_id"`                  // 💡 追加: ユーザーID (必須)
-	Title     string    `json:"title" binding:"required"` // タスクのタイトル（必須）
-	Completed bool      `json:"completed"`                // 完了状態
-	CreatedAt time.Time `json:"created_at"`               // 作成日時
-	UpdatedAt time.Time `json:"updated_at,omitempty"`     // 💡 追加: 更新日時
+	ID          int       `json:"id,omitempty"`             // 主キー
+	UserID      int       `json:"user_id"`                  // 💡 追加: ユーザーID (必須)
+	WorkspaceID int       `json:"workspace_id"`             // 所属するワークスペース
+	Title       string    `json:"title" binding:"required"` // タスクのタイトル（必須）
+	Completed   bool      `json:"completed"`                // 完了状態
+	CreatedAt   time.Time `json:"created_at"`               // 作成日時
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`     // 💡 追加: 更新日時
 }
@@ -2,23 +2,33 @@ package models
 
 import "time"
 
+// 組み込みロール。カスタムロールは roles テーブルで定義します。
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User はユーザーのデータベース構造体を表します。
 // JSONタグ: クライアントとの通信用
 // bindingタグ: Ginでのリクエストバリデーション用
 type User struct {
-	ID           int       `json:"id,omitempty"`
-	Username     string    `json:"username" binding:"required,min=8"`        // 8文字以上
-	Email        string    `json:"email" binding:"required,email"`           // email形式
-	PasswordHash string    `json:"-"`                                        // JSONに出さない
-	Role         string    `json:"role" binding:"required,oneof=user admin"` // user または admin
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              int        `json:"id,omitempty"`
+	Username        string     `json:"username" binding:"required,min=8"` // 8文字以上
+	Email           string     `json:"email" binding:"required,email"`    // email形式
+	PasswordHash    string     `json:"-"`                                 // JSONに出さない
+	Role            string     `json:"role" binding:"required"`           // roles.name を参照
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`                 // 未確認の場合は nil
+	TokenVersion    int        `json:"-"`                                 // パスワード変更時に加算し、既存のJWTを無効化する
+	DeletionAt      *time.Time `json:"deletion_at,omitempty"`             // 退会手続き中の場合、完全に削除される日時
+	DisabledAt      *time.Time `json:"disabled_at,omitempty"`             // 管理者によって無効化された日時
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type UserRegisterRequest struct {
 	Username string `json:"username" binding:"required,min=8"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"` // 生パスワード
+	Password string `json:"password" binding:"required"` // 生パスワード。長さなどの条件はパスワードポリシーで検証する
 }
 
 type UserLoginRequest struct {
@@ -30,21 +40,116 @@ type UserForgotPasswordRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+// PasswordResetToken はパスワードリセットトークンです。
+// Token は発行時にメールで送る平文のトークンで、データベースにはそのハッシュのみを保存します。
 type PasswordResetToken struct {
 	ID        uint       `json:"id"`
 	UserID    uint       `json:"user_id"`
-	Token     string     `json:"token"`
+	Token     string     `json:"-"`
 	ExpiresAt time.Time  `json:"expires_at"`
 	UsedAt    *time.Time `json:"used_at"`
 	CreatedAt time.Time  `json:"created_at"`
 }
 
+// EmailVerificationToken の用途
+const (
+	VerificationPurposeVerify      = "verify"       // 登録時のメールアドレス確認
+	VerificationPurposeChangeEmail = "change_email" // メールアドレス変更の確認
+)
+
+// EmailVerificationToken はメールアドレス確認用のトークンを表します。
+// Email には確認メールを送信したアドレスを保持します。
+// Token は発行時にメールで送る平文のトークンで、データベースにはそのハッシュのみを保存します。
+type EmailVerificationToken struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	Email     string     `json:"email"`
+	Purpose   string     `json:"purpose"`
+	Token     string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MagicLinkToken はパスワードなしでログインするための使い捨てトークンを表します。
+// Token は発行時にメールで送る平文のトークンで、データベースにはそのハッシュのみを保存します。
+type MagicLinkToken struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	Token     string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type UserMagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type UserResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 type UserResetPasswordRequest struct {
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"`
+}
+
+type UserUpdateProfileRequest struct {
+	Username string `json:"username" binding:"required,min=8"`
+}
+
+type UserChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type UserDeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"` // 本人確認用の現在のパスワード
+}
+
+type UserChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"` // 本人確認用の現在のパスワード
+}
+
+// UserListFilter は管理者向けユーザー一覧の検索条件です。
+type UserListFilter struct {
+	Query   string `form:"q"` // ユーザー名・メールアドレスの部分一致
+	Role    string `form:"role"`
+	Page    int    `form:"page" binding:"omitempty,min=1"`
+	PerPage int    `form:"per_page" binding:"omitempty,min=1,max=100"`
+}
+
+// UserListResult はページングされたユーザー一覧です。
+type UserListResult struct {
+	Users   []*User `json:"users"`
+	Total   int     `json:"total"`
+	Page    int     `json:"page"`
+	PerPage int     `json:"per_page"`
+}
+
+type AdminUpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,max=64"`
 }
 
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role" binding:"required,oneof=user admin"`
+	UserID       uint   `json:"user_id"`
+	Email        string `json:"email"`
+	Role         string `json:"role" binding:"required"`
+	TokenVersion int    `json:"ver"`
+	SessionID    string `json:"sid"`
+	// ActorID はなりすましトークンの場合に、なりすましている管理者のIDです（"act" クレーム）。通常のトークンでは0です。
+	ActorID uint `json:"act,omitempty"`
+}
+
+// ImpersonationRequest は管理者がユーザーになりすます際のリクエストです。理由は監査のために記録します。
+type ImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// ImpersonationResponse はなりすまし用のトークンです。
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
 }
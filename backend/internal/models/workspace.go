@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// ワークスペース内のロール
+const (
+	WorkspaceRoleOwner  = "owner"  // ワークスペースの所有者。メンバーの管理とワークスペース内の全Todoへのアクセスが可能
+	WorkspaceRoleAdmin  = "admin"  // ワークスペースの管理者。オーナー以外のメンバーの管理と全Todoへのアクセスが可能
+	WorkspaceRoleMember = "member" // 一般メンバー。自分のTodoのみ操作可能
+)
+
+// PermWorkspaceMembersManage はワークスペースのメンバーを管理できる権限です。
+const PermWorkspaceMembersManage = "workspace.members.manage"
+
+// workspaceRolePermissions はワークスペースのロールごとの権限です。
+// Todo に関する権限はグローバルロールではなく、ワークスペースのロールから決まります。
+var workspaceRolePermissions = map[string][]string{
+	WorkspaceRoleOwner:  {PermTodosReadAny, PermTodosWriteAny, PermWorkspaceMembersManage},
+	WorkspaceRoleAdmin:  {PermTodosReadAny, PermTodosWriteAny, PermWorkspaceMembersManage},
+	WorkspaceRoleMember: {},
+}
+
+// WorkspacePermissions はワークスペースのロールに対応する権限を返します。
+func WorkspacePermissions(role string) PermissionSet {
+	return NewPermissionSet(workspaceRolePermissions[role])
+}
+
+// Workspace はチームごとのワークスペースを表します。Todo はいずれかのワークスペースに属します。
+type Workspace struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"` // 一覧取得時のログイン中ユーザーのロール
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WorkspaceMember はワークスペースのメンバーシップを表します。
+type WorkspaceMember struct {
+	WorkspaceID int       `json:"workspace_id"`
+	UserID      int       `json:"user_id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WorkspaceCreateRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type WorkspaceAddMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner admin member"`
+}
+
+type WorkspaceUpdateMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member"`
+}
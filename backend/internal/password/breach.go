@@ -0,0 +1,99 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// hashPrefixLength は k-匿名性の問い合わせで渡す SHA-1 ハッシュの先頭の文字数です（Have I Been Pwned と同じ5文字）。
+const hashPrefixLength = 5
+
+// BreachChecker は漏洩したパスワードの一覧を k-匿名性の方式で問い合わせます。
+// パスワードそのものではなく SHA-1 ハッシュの先頭5文字だけを渡し、
+// 同じ先頭を持つハッシュの残りの部分（大文字の16進数）の一覧を受け取ります。
+type BreachChecker interface {
+	Range(prefix string) ([]string, error)
+}
+
+// IsBreached はパスワードが漏洩済みの一覧に含まれるかを返します。
+// 問い合わせ先にはハッシュの先頭しか渡さず、残りの部分との照合は手元で行います。
+func IsBreached(checker BreachChecker, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+
+	suffixes, err := checker.Range(prefix)
+	if err != nil {
+		return false, fmt.Errorf("failed to query breached passwords: %w", err)
+	}
+	for _, s := range suffixes {
+		if s == suffix {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HashPrefixList はメモリに読み込んだ漏洩パスワードのハッシュ一覧です。
+type HashPrefixList struct {
+	ranges map[string][]string
+}
+
+// LoadHashPrefixList はファイルから漏洩パスワードのハッシュ一覧を読み込みます。
+// ファイルは Have I Been Pwned の配布形式（1行に "SHA1ハッシュ:件数"）で、件数は省略できます。
+// 空行と "#" で始まる行は無視します。
+func LoadHashPrefixList(path string) (*HashPrefixList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	list := &HashPrefixList{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of breached password list", lineNo)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of breached password list", lineNo)
+		}
+		prefix := hash[:hashPrefixLength]
+		list.ranges[prefix] = append(list.ranges[prefix], hash[hashPrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read breached password list: %w", err)
+	}
+
+	for _, suffixes := range list.ranges {
+		sort.Strings(suffixes)
+	}
+	return list, nil
+}
+
+// Range は先頭が prefix のハッシュの残りの部分を返します。
+func (l *HashPrefixList) Range(prefix string) ([]string, error) {
+	return l.ranges[strings.ToUpper(prefix)], nil
+}
+
+// Len は読み込んだハッシュの件数を返します。
+func (l *HashPrefixList) Len() int {
+	n := 0
+	for _, suffixes := range l.ranges {
+		n += len(suffixes)
+	}
+	return n
+}
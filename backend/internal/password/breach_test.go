@@ -0,0 +1,85 @@
+package password_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/password"
+)
+
+// sha1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+const breachedHash = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+func writeList(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+type failingChecker struct{}
+
+func (failingChecker) Range(string) ([]string, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestLoadHashPrefixList(t *testing.T) {
+	t.Run("Parses hashes with and without counts", func(t *testing.T) {
+		list, err := password.LoadHashPrefixList(writeList(t, "# comment\n\n"+
+			breachedHash+":3861493\n"+
+			"5baa6ffffffffffffffffffffffffffffffffff0\n"+
+			"  7C4A8D09CA3762AF61E59520943DC26494F8941B:1  \n"))
+		require.NoError(t, err)
+		assert.Equal(t, 3, list.Len())
+
+		suffixes, err := list.Range("5baa6")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF0"}, suffixes, "suffixes are upper-cased and sorted")
+
+		suffixes, err = list.Range("00000")
+		require.NoError(t, err)
+		assert.Empty(t, suffixes)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Too short", "5BAA61E4C9B93F3F:1\n"},
+		{"Too long", breachedHash + "00:1\n"},
+		{"Not hexadecimal", "ZBAA61E4C9B93F3F0682250B6CF8331B7EE68FD8\n"},
+		{"Count only", ":12\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := password.LoadHashPrefixList(writeList(t, "# header\n"+tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "line 2")
+		})
+	}
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := password.LoadHashPrefixList(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Error(t, err)
+	})
+}
+
+func TestIsBreached(t *testing.T) {
+	list, err := password.LoadHashPrefixList(writeList(t, breachedHash+":10\n"))
+	require.NoError(t, err)
+
+	breached, err := password.IsBreached(list, "password")
+	require.NoError(t, err)
+	assert.True(t, breached)
+
+	breached, err = password.IsBreached(list, "Velvet-Canyon-Ember-19")
+	require.NoError(t, err)
+	assert.False(t, breached)
+
+	_, err = password.IsBreached(failingChecker{}, "password")
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ハッシュのアルゴリズムです。
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	// ErrMismatchedPassword はパスワードがハッシュと一致しない場合のエラーです。
+	ErrMismatchedPassword = errors.New("password does not match")
+	// ErrUnknownHashFormat は保存されているハッシュの形式が分からない場合のエラーです。
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+)
+
+// Argon2Params は Argon2id のパラメータです。
+type Argon2Params struct {
+	Memory      uint32 // 使用するメモリ（KiB）
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Hasher はパスワードのハッシュ化の設定です。
+// ハッシュは "$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>"（PHC形式）や "$2a$10$..."（bcrypt）のように
+// アルゴリズムとパラメータを含む形式で保存するため、設定を変更しても既存のハッシュを検証できます。
+type Hasher struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+// DefaultArgon2Params は OWASP の推奨値に基づく Argon2id のパラメータを返します。
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// DefaultHasherConfig はデフォルトのハッシュ設定（Argon2id）を返します。
+func DefaultHasherConfig() *Hasher {
+	return &Hasher{
+		Algorithm:  AlgorithmArgon2id,
+		Argon2:     DefaultArgon2Params(),
+		BcryptCost: bcrypt.DefaultCost,
+	}
+}
+
+var defaultHasher atomic.Pointer[Hasher]
+
+// DefaultHasher は HashPassword などが使うハッシュ設定を返します。SetDefaultHasher で設定していない場合はデフォルトの設定です。
+func DefaultHasher() *Hasher {
+	if h := defaultHasher.Load(); h != nil {
+		return h
+	}
+	return DefaultHasherConfig()
+}
+
+// SetDefaultHasher は DefaultHasher が返すハッシュ設定を変更します。起動時に設定から呼び出します。
+func SetDefaultHasher(h *Hasher) {
+	defaultHasher.Store(h)
+}
+
+// Hash はパスワードを設定されたアルゴリズムでハッシュ化します。
+func (h *Hasher) Hash(password string) (string, error) {
+	switch h.Algorithm {
+	case AlgorithmBcrypt:
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+	case AlgorithmArgon2id:
+		salt := make([]byte, h.Argon2.SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, h.Argon2.Iterations, h.Argon2.Memory, h.Argon2.Parallelism, h.Argon2.KeyLength)
+		return encodeArgon2id(h.Argon2, salt, key), nil
+	default:
+		return "", fmt.Errorf("unknown password hash algorithm %q", h.Algorithm)
+	}
+}
+
+// NeedsRehash は保存されているハッシュが現在の設定と異なるアルゴリズム・パラメータで作られているかを返します。
+// 形式が分からないハッシュは再ハッシュの対象にしません（検証にも失敗するため）。
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	switch {
+	case isBcrypt(encoded):
+		if h.Algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err == nil && cost != h.BcryptCost
+	case strings.HasPrefix(encoded, "$"+AlgorithmArgon2id+"$"):
+		if h.Algorithm != AlgorithmArgon2id {
+			return true
+		}
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false
+		}
+		return params.Memory != h.Argon2.Memory ||
+			params.Iterations != h.Argon2.Iterations ||
+			params.Parallelism != h.Argon2.Parallelism ||
+			uint32(len(salt)) != h.Argon2.SaltLength ||
+			uint32(len(key)) != h.Argon2.KeyLength
+	default:
+		return false
+	}
+}
+
+// Verify はパスワードが保存されているハッシュと一致するかを確認します。
+// ハッシュに含まれるアルゴリズムとパラメータを使うため、現在の設定には依存しません。
+func Verify(encoded, password string) error {
+	switch {
+	case isBcrypt(encoded):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrMismatchedPassword
+			}
+			return err
+		}
+		return nil
+	case strings.HasPrefix(encoded, "$"+AlgorithmArgon2id+"$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return ErrMismatchedPassword
+		}
+		return nil
+	default:
+		return ErrUnknownHashFormat
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// encodeArgon2id は Argon2id のハッシュを PHC 形式の文字列にします。
+func encodeArgon2id(p Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2id は PHC 形式の Argon2id のハッシュを読み取ります。
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	// argon2.IDKey は反復回数・並列度が0の場合にパニックするため、検証の前に弾く
+	if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,138 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"go-next-todo/backend/internal/password"
+)
+
+// testArgon2Params はテストを速くするため小さくしたパラメータです。
+func testArgon2Params() password.Argon2Params {
+	return password.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func argon2Hasher() *password.Hasher {
+	return &password.Hasher{Algorithm: password.AlgorithmArgon2id, Argon2: testArgon2Params(), BcryptCost: bcrypt.MinCost}
+}
+
+func bcryptHasher() *password.Hasher {
+	return &password.Hasher{Algorithm: password.AlgorithmBcrypt, Argon2: testArgon2Params(), BcryptCost: bcrypt.MinCost}
+}
+
+func TestHasher_RoundTrip(t *testing.T) {
+	for _, h := range []*password.Hasher{argon2Hasher(), bcryptHasher()} {
+		t.Run(h.Algorithm, func(t *testing.T) {
+			encoded, err := h.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotContains(t, encoded, "correct horse")
+
+			assert.NoError(t, password.Verify(encoded, "correct horse"))
+			assert.ErrorIs(t, password.Verify(encoded, "wrong horse"), password.ErrMismatchedPassword)
+			assert.False(t, h.NeedsRehash(encoded))
+
+			again, err := h.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotEqual(t, encoded, again, "each hash uses a new salt")
+		})
+	}
+
+	t.Run("PHC format", func(t *testing.T) {
+		encoded, err := argon2Hasher().Hash("correct horse")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"), encoded)
+	})
+
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		_, err := (&password.Hasher{Algorithm: "md5"}).Hash("correct horse")
+		assert.Error(t, err)
+	})
+}
+
+func TestVerify_MalformedHashes(t *testing.T) {
+	valid, err := argon2Hasher().Hash("correct horse")
+	require.NoError(t, err)
+	parts := strings.Split(valid, "$")
+	salt, key := parts[4], parts[5]
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"Empty", ""},
+		{"Plain text", "correct horse"},
+		{"Unknown algorithm", "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key},
+		{"Missing key", "$argon2id$v=19$m=64,t=1,p=1$" + salt},
+		{"Extra section", valid + "$extra"},
+		{"Missing version", "$argon2id$m=64,t=1,p=1$" + salt + "$" + key + "$"},
+		{"Unsupported version", "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key},
+		{"Non-numeric parameters", "$argon2id$v=19$m=a,t=1,p=1$" + salt + "$" + key},
+		{"Zero iterations", "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key},
+		{"Zero parallelism", "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key},
+		{"Zero memory", "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key},
+		{"Parallelism out of range", "$argon2id$v=19$m=64,t=1,p=256$" + salt + "$" + key},
+		{"Invalid salt encoding", "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key},
+		{"Invalid key encoding", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$!!!"},
+		{"Empty key", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$"},
+		{"Truncated bcrypt", "$2a$04$short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := password.Verify(tt.encoded, "correct horse")
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, password.ErrMismatchedPassword)
+			if strings.HasPrefix(tt.encoded, "$argon2id$") {
+				assert.False(t, argon2Hasher().NeedsRehash(tt.encoded), "malformed hashes are not rehashed")
+			}
+		})
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	argon2Hash, err := argon2Hasher().Hash("correct horse")
+	require.NoError(t, err)
+	bcryptHash, err := bcryptHasher().Hash("correct horse")
+	require.NoError(t, err)
+
+	t.Run("bcrypt is upgraded to argon2id", func(t *testing.T) {
+		h := argon2Hasher()
+		assert.True(t, h.NeedsRehash(bcryptHash))
+
+		// 再ハッシュ後も同じパスワードで検証できる
+		upgraded, err := h.Hash("correct horse")
+		require.NoError(t, err)
+		assert.NoError(t, password.Verify(upgraded, "correct horse"))
+		assert.False(t, h.NeedsRehash(upgraded))
+	})
+
+	t.Run("argon2id is rehashed when switching to bcrypt", func(t *testing.T) {
+		assert.True(t, bcryptHasher().NeedsRehash(argon2Hash))
+	})
+
+	t.Run("bcrypt cost change", func(t *testing.T) {
+		h := bcryptHasher()
+		h.BcryptCost = bcrypt.MinCost + 1
+		assert.True(t, h.NeedsRehash(bcryptHash))
+	})
+
+	changes := map[string]func(p *password.Argon2Params){
+		"Memory":      func(p *password.Argon2Params) { p.Memory *= 2 },
+		"Iterations":  func(p *password.Argon2Params) { p.Iterations++ },
+		"Parallelism": func(p *password.Argon2Params) { p.Parallelism++ },
+		"Salt length": func(p *password.Argon2Params) { p.SaltLength = 32 },
+		"Key length":  func(p *password.Argon2Params) { p.KeyLength = 64 },
+	}
+	for name, change := range changes {
+		t.Run("argon2id "+name+" change", func(t *testing.T) {
+			h := argon2Hasher()
+			change(&h.Argon2)
+			assert.True(t, h.NeedsRehash(argon2Hash))
+			// パラメータを変えても既存のハッシュは検証できる
+			assert.NoError(t, password.Verify(argon2Hash, "correct horse"))
+		})
+	}
+}
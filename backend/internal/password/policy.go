@@ -0,0 +1,147 @@
+// Package password はパスワードポリシーの検証と、漏洩済みパスワードの確認を提供します。
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 違反の種類を表すコードです。フロントエンドはこのコードでメッセージを出し分けます。
+const (
+	ViolationTooShort          = "too_short"
+	ViolationTooLong           = "too_long"
+	ViolationMissingUpper      = "missing_uppercase"
+	ViolationMissingLower      = "missing_lowercase"
+	ViolationMissingDigit      = "missing_digit"
+	ViolationMissingSymbol     = "missing_symbol"
+	ViolationSimilarToUsername = "similar_to_username"
+	ViolationSimilarToEmail    = "similar_to_email"
+	ViolationTooWeak           = "too_weak"
+	ViolationBreached          = "breached"
+)
+
+// minSimilarityLength はユーザー名・メールアドレスとの類似を確認する最小の文字数です。短すぎる名前は偶然含まれることがあるため除外します。
+const minSimilarityLength = 3
+
+// Violation はパスワードポリシーの違反1件です。
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PolicyError はパスワードがポリシーを満たさない場合のエラーです。違反をすべて保持します。
+type PolicyError struct {
+	Violations []Violation `json:"violations"`
+}
+
+func (e *PolicyError) Error() string {
+	codes := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		codes[i] = v.Code
+	}
+	return "password does not meet policy: " + strings.Join(codes, ", ")
+}
+
+// Policy はパスワードに求める条件です。
+type Policy struct {
+	MinLength     int // 最小の文字数
+	MaxLength     int // 最大のバイト数（bcrypt を使う場合は72バイトを超えるパスワードを扱えない）
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	MinScore      int           // Strength の最小スコア（0で無効）
+	Breached      BreachChecker // nil の場合は漏洩チェックを行わない
+}
+
+// DefaultPolicy はデフォルトのパスワードポリシーを返します。
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength: 8,
+		MaxLength: 72,
+		MinScore:  2,
+	}
+}
+
+// Validate はパスワードがポリシーを満たすかを検証します。
+// 満たさない場合は違反をすべて含む *PolicyError を返します。漏洩チェックの問い合わせに失敗した場合はそのエラーを返します。
+// username と email はパスワードとの類似の確認と、強度の推定に使います。
+func (p Policy) Validate(pw, username, email string) error {
+	var violations []Violation
+	add := func(code, format string, args ...any) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(pw); n < p.MinLength {
+		add(ViolationTooShort, "Password must be at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 && len(pw) > p.MaxLength {
+		add(ViolationTooLong, "Password must be at most %d bytes", p.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		add(ViolationMissingUpper, "Password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		add(ViolationMissingLower, "Password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(ViolationMissingDigit, "Password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(ViolationMissingSymbol, "Password must contain a symbol")
+	}
+
+	localPart, _, _ := strings.Cut(email, "@")
+	if similar(pw, username) {
+		add(ViolationSimilarToUsername, "Password must not contain your username")
+	}
+	if similar(pw, localPart) || similar(pw, email) {
+		add(ViolationSimilarToEmail, "Password must not contain your email address")
+	}
+
+	if p.MinScore > 0 {
+		if score := Strength(pw, username, email, localPart); score < p.MinScore {
+			add(ViolationTooWeak, "Password is too easy to guess (strength %d of 4, at least %d required)", score, p.MinScore)
+		}
+	}
+
+	if p.Breached != nil {
+		breached, err := IsBreached(p.Breached, pw)
+		if err != nil {
+			return err
+		}
+		if breached {
+			add(ViolationBreached, "Password has appeared in a data breach and cannot be used")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// similar はパスワードがユーザー情報を含むか、ユーザー情報の一部になっているかを返します。大文字と小文字は区別しません。
+func similar(pw, input string) bool {
+	if utf8.RuneCountInString(input) < minSimilarityLength || pw == "" {
+		return false
+	}
+	p, in := strings.ToLower(pw), strings.ToLower(input)
+	return strings.Contains(p, in) || strings.Contains(p, reverse(in)) || strings.Contains(in, p)
+}
@@ -0,0 +1,253 @@
+package password
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// maxEstimateLength は強度の推定に使う先頭の文字数です。推定は文字数の2乗に比例するため上限を設けます。
+const maxEstimateLength = 100
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords はよく使われるパスワード・単語と、その推測されやすさの順位です。
+var commonPasswords = loadRankedWords(commonPasswordsFile)
+
+// keyboardRows はキーボード上で隣り合うキーの並びです。
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// leetSubstitutions は "p@ssw0rd" のような置き換えを元の文字に戻すための対応表です。
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '3': 'e', '1': 'i', '!': 'i', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't',
+}
+
+func loadRankedWords(list string) map[string]int {
+	ranked := make(map[string]int)
+	rank := 1
+	for _, line := range strings.Split(list, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if _, ok := ranked[word]; !ok {
+			ranked[word] = rank
+		}
+		rank++
+	}
+	return ranked
+}
+
+// Strength は zxcvbn と同じ 0〜4 のスコアでパスワードの強度を推定します。
+// パスワードを辞書の単語・繰り返し・連続した文字・キーボードの並び・年号に分解し、
+// 最も少ない推測回数で当てられる分解を攻撃者の手順とみなします。
+// userInputs にはユーザー名やメールアドレスなど、そのユーザーについて推測されやすい単語を渡します。
+func Strength(password string, userInputs ...string) int {
+	return scoreFromGuesses(estimateGuessesLog10(password, userInputs))
+}
+
+// scoreFromGuesses は推測回数（常用対数）をスコアに変換します。閾値は zxcvbn に合わせています。
+func scoreFromGuesses(log10Guesses float64) int {
+	switch {
+	case log10Guesses < 3:
+		return 0
+	case log10Guesses < 6:
+		return 1
+	case log10Guesses < 8:
+		return 2
+	case log10Guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// estimateGuessesLog10 はパスワードを当てるまでの推測回数の常用対数を推定します。
+func estimateGuessesLog10(password string, userInputs []string) float64 {
+	runes := []rune(password)
+	if len(runes) > maxEstimateLength {
+		runes = runes[:maxEstimateLength]
+	}
+	n := len(runes)
+	if n == 0 {
+		return 0
+	}
+
+	dictionary := commonPasswords
+	if len(userInputs) > 0 {
+		dictionary = make(map[string]int, len(commonPasswords)+len(userInputs))
+		for word, rank := range commonPasswords {
+			dictionary[word] = rank
+		}
+		for _, input := range userInputs {
+			if input = strings.ToLower(input); len([]rune(input)) >= 3 {
+				dictionary[input] = 1
+			}
+		}
+	}
+
+	// best[i] は先頭 i 文字を当てるまでの最小の推測回数（常用対数）
+	best := make([]float64, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(1)
+		for start := 0; start < i; start++ {
+			guesses := best[start] + segmentGuessesLog10(runes[start:i], dictionary)
+			// 分解の数が多いほど組み合わせ方の推測が必要になる分を加算する
+			if start > 0 {
+				guesses += math.Log10(2)
+			}
+			if guesses < best[i] {
+				best[i] = guesses
+			}
+		}
+	}
+	return best[n]
+}
+
+// segmentGuessesLog10 はパスワードの一部分を当てるまでの推測回数（常用対数）を返します。
+func segmentGuessesLog10(segment []rune, dictionary map[string]int) float64 {
+	guesses := bruteForceLog10(segment)
+	if len(segment) >= 3 {
+		if g, ok := dictionaryLog10(segment, dictionary); ok {
+			guesses = math.Min(guesses, g)
+		}
+		if isRepeat(segment) {
+			guesses = math.Min(guesses, math.Log10(float64(charsetSize(segment[0])*len(segment))))
+		}
+		if isSequence(segment) {
+			guesses = math.Min(guesses, math.Log10(float64(charsetSize(segment[0])*len(segment))))
+		}
+		if year, ok := asYear(segment); ok && year >= 1900 && year <= 2099 {
+			guesses = math.Min(guesses, math.Log10(200))
+		}
+	}
+	if len(segment) >= 4 && isKeyboardRun(segment) {
+		guesses = math.Min(guesses, math.Log10(float64(40*len(segment))))
+	}
+	return guesses
+}
+
+// bruteForceLog10 は1文字ずつ総当たりした場合の推測回数（常用対数）を返します。
+func bruteForceLog10(segment []rune) float64 {
+	var total float64
+	for _, r := range segment {
+		total += math.Log10(float64(charsetSize(r)))
+	}
+	return total
+}
+
+// charsetSize は文字の種類ごとの候補数です。
+func charsetSize(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return 10
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+// dictionaryLog10 は辞書の単語（大文字・leet表記・逆順を含む）として当てる場合の推測回数（常用対数）を返します。
+func dictionaryLog10(segment []rune, dictionary map[string]int) (float64, bool) {
+	lower := strings.ToLower(string(segment))
+	variations := 1.0
+	if lower != string(segment) {
+		variations *= 2
+	}
+
+	candidates := []struct {
+		word       string
+		variations float64
+	}{
+		{lower, variations},
+		{reverse(lower), variations * 2},
+	}
+	if unleeted := unleet(lower); unleeted != lower {
+		candidates = append(candidates, struct {
+			word       string
+			variations float64
+		}{unleeted, variations * 2})
+	}
+
+	found := false
+	best := math.Inf(1)
+	for _, c := range candidates {
+		if rank, ok := dictionary[c.word]; ok {
+			found = true
+			best = math.Min(best, math.Log10(float64(rank)*c.variations))
+		}
+	}
+	return best, found
+}
+
+func unleet(s string) string {
+	return strings.Map(func(r rune) rune {
+		if sub, ok := leetSubstitutions[r]; ok {
+			return sub
+		}
+		return r
+	}, s)
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// isRepeat は "aaaa" のような同じ文字の繰り返しかどうかを返します。
+func isRepeat(segment []rune) bool {
+	for _, r := range segment[1:] {
+		if r != segment[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSequence は "abcd" や "4321" のような1つずつ増減する並びかどうかを返します。
+func isSequence(segment []rune) bool {
+	delta := segment[1] - segment[0]
+	if delta != 1 && delta != -1 {
+		return false
+	}
+	for i := 2; i < len(segment); i++ {
+		if segment[i]-segment[i-1] != delta {
+			return false
+		}
+	}
+	return true
+}
+
+// isKeyboardRun は "qwerty" や "lkjh" のようなキーボードの並びかどうかを返します。
+func isKeyboardRun(segment []rune) bool {
+	s := strings.ToLower(string(segment))
+	for _, row := range keyboardRows {
+		if strings.Contains(row, s) || strings.Contains(reverse(row), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// asYear は4桁の数字を年として読み取ります。
+func asYear(segment []rune) (int, bool) {
+	if len(segment) != 4 {
+		return 0, false
+	}
+	year := 0
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+		year = year*10 + int(r-'0')
+	}
+	return year, true
+}
@@ -0,0 +1,96 @@
+// Package repositories はデータベース操作を行うリポジトリを提供します。
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"go-next-todo/backend/internal/models"
+)
+
+var ErrMagicLinkTokenNotFound = errors.New("magic link token not found")
+
+type MagicLinkTokenRepository interface {
+	Save(token *models.MagicLinkToken) error
+	FindByToken(token string) (*models.MagicLinkToken, error)
+	InvalidateForUser(userID uint) error
+	MarkUsed(id uint) error
+	CleanupExpired() error
+}
+
+type MySQLMagicLinkTokenRepo struct {
+	DB *sql.DB
+}
+
+func NewMySQLMagicLinkTokenRepo(db *sql.DB) *MySQLMagicLinkTokenRepo {
+	return &MySQLMagicLinkTokenRepo{DB: db}
+}
+
+// Save はトークンのハッシュを保存します。マジックリンクはそのままログインに使えるため、平文では保存しません。
+func (r *MySQLMagicLinkTokenRepo) Save(t *models.MagicLinkToken) error {
+	_, err := r.DB.Exec(
+		"INSERT INTO magic_link_tokens (user_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		t.UserID, hashToken(t.Token), t.ExpiresAt,
+	)
+	return err
+}
+
+// FindByToken は平文のトークンに一致するマジックリンクのトークンを返します。
+func (r *MySQLMagicLinkTokenRepo) FindByToken(token string) (*models.MagicLinkToken, error) {
+	row := r.DB.QueryRow(
+		"SELECT id, user_id, expires_at, used_at, created_at FROM magic_link_tokens WHERE token_hash = ?",
+		hashToken(token),
+	)
+
+	var mt models.MagicLinkToken
+	var usedAt sql.NullTime
+	if err := row.Scan(&mt.ID, &mt.UserID, &mt.ExpiresAt, &usedAt, &mt.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMagicLinkTokenNotFound
+		}
+		return nil, err
+	}
+	if usedAt.Valid {
+		mt.UsedAt = &usedAt.Time
+	}
+	return &mt, nil
+}
+
+// InvalidateForUser はユーザーの未使用のトークンをすべて使用済みにします。
+// 新しいログインリンクを発行する前に呼び出し、有効なリンクが常に最新の1つだけになるようにします。
+func (r *MySQLMagicLinkTokenRepo) InvalidateForUser(userID uint) error {
+	_, err := r.DB.Exec(
+		"UPDATE magic_link_tokens SET used_at = NOW() WHERE user_id = ? AND used_at IS NULL",
+		userID,
+	)
+	return err
+}
+
+// MarkUsed はトークンを使用済みにします。同時に使用された場合に1回だけ成功するよう、
+// 未使用のトークンのみを更新し、更新できなかった場合は ErrMagicLinkTokenNotFound を返します。
+func (r *MySQLMagicLinkTokenRepo) MarkUsed(id uint) error {
+	result, err := r.DB.Exec(
+		"UPDATE magic_link_tokens SET used_at = NOW() WHERE id = ? AND used_at IS NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrMagicLinkTokenNotFound
+	}
+	return nil
+}
+
+func (r *MySQLMagicLinkTokenRepo) CleanupExpired() error {
+	_, err := r.DB.Exec(`
+		DELETE FROM magic_link_tokens
+		WHERE used_at IS NOT NULL
+		   OR expires_at < NOW()
+	`)
+	return err
+}
@@ -0,0 +1,767 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-next-todo/backend/internal/models"
+)
+
+// MemoryStore はユーザー・Todo・パスワードリセットトークンなど、アプリケーションのデータをメモリ上に保持するストアです。
+// MySQL なしでサービスやハンドラーをテストするためのもので、ユーザー・Todo・リセットトークンが MySQL の実装と
+// 同じ振る舞いをするかは storetest パッケージの共通テストで確認します。
+//
+// 各ストアは1つの MemoryStore のデータを共有するため、ユーザーを削除するとその Todo とリセットトークンも削除されるなど、
+// 外部キーの CASCADE やトランザクションを使う操作も MySQL と同じ結果になります。複数の goroutine から同時に使えます。
+type MemoryStore struct {
+	mu                 sync.Mutex
+	users              map[int]*models.User
+	todos              map[int]*models.Todo
+	resetTokens        map[uint]*memoryResetToken
+	roles              map[string]*models.Role
+	verificationTokens map[uint]*memoryVerificationToken
+	magicLinkTokens    map[uint]*memoryMagicLinkToken
+	sessions           map[string]*models.Session
+	securityEvents     []*models.SecurityEvent
+	workspaces         map[int]*models.Workspace
+	members            []*models.WorkspaceMember
+	authRequests       map[string]*models.OIDCAuthRequest
+	identities         []*models.UserIdentity
+	lastUserID         int
+	lastTodoID         int
+	lastTokenID        uint
+	lastVerificationID uint
+	lastMagicLinkID    uint
+	lastWorkspaceID    int
+	lastIdentityID     uint
+}
+
+// memoryResetToken は保存したリセットトークンです。MySQL と同じく平文のトークンは保持しません。
+type memoryResetToken struct {
+	token     models.PasswordResetToken
+	tokenHash string
+}
+
+// NewMemoryStore は空の MemoryStore を作成します。ロールは組み込みロール（models.BuiltInRoles）だけが存在するものとして扱います。
+func NewMemoryStore() *MemoryStore {
+	m := &MemoryStore{
+		users:              map[int]*models.User{},
+		todos:              map[int]*models.Todo{},
+		resetTokens:        map[uint]*memoryResetToken{},
+		roles:              map[string]*models.Role{},
+		verificationTokens: map[uint]*memoryVerificationToken{},
+		magicLinkTokens:    map[uint]*memoryMagicLinkToken{},
+		sessions:           map[string]*models.Session{},
+		workspaces:         map[int]*models.Workspace{},
+		authRequests:       map[string]*models.OIDCAuthRequest{},
+	}
+	for _, role := range models.BuiltInRoles {
+		m.roles[role.Name] = cloneRole(&role)
+	}
+	return m
+}
+
+// Todos は m のデータを使う TodoStore を返します。
+func (m *MemoryStore) Todos() *MemoryTodoStore {
+	return &MemoryTodoStore{m: m}
+}
+
+// Users は m のデータを使う UserStore を返します。
+func (m *MemoryStore) Users() *MemoryUserStore {
+	return &MemoryUserStore{m: m}
+}
+
+// ResetTokens は m のデータを使う ResetTokenRepository を返します。
+func (m *MemoryStore) ResetTokens() *MemoryResetTokenRepo {
+	return &MemoryResetTokenRepo{m: m}
+}
+
+// Roles は m のデータを使う RoleRepository を返します。
+func (m *MemoryStore) Roles() *MemoryRoleRepo {
+	return &MemoryRoleRepo{m: m}
+}
+
+// VerificationTokens は m のデータを使う VerificationTokenRepository を返します。
+func (m *MemoryStore) VerificationTokens() *MemoryVerificationTokenRepo {
+	return &MemoryVerificationTokenRepo{m: m}
+}
+
+// MagicLinkTokens は m のデータを使う MagicLinkTokenRepository を返します。
+func (m *MemoryStore) MagicLinkTokens() *MemoryMagicLinkTokenRepo {
+	return &MemoryMagicLinkTokenRepo{m: m}
+}
+
+// Sessions は m のデータを使う SessionRepository を返します。
+func (m *MemoryStore) Sessions() *MemorySessionRepo {
+	return &MemorySessionRepo{m: m}
+}
+
+// SecurityEvents は m のデータを使う SecurityEventRepository を返します。
+func (m *MemoryStore) SecurityEvents() *MemorySecurityEventRepo {
+	return &MemorySecurityEventRepo{m: m}
+}
+
+// Workspaces は m のデータを使う WorkspaceRepository を返します。
+func (m *MemoryStore) Workspaces() *MemoryWorkspaceRepo {
+	return &MemoryWorkspaceRepo{m: m}
+}
+
+// OIDC は m のデータを使う OIDCRepository を返します。
+func (m *MemoryStore) OIDC() *MemoryOIDCRepo {
+	return &MemoryOIDCRepo{m: m}
+}
+
+// now は保存する日時を返します。MySQL の DATETIME と同じく秒未満を切り捨てます。
+func now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+// cloneTime は日時のポインタを複製します。保存したデータを呼び出し元が書き換えられないようにするためです。
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+func cloneUser(u *models.User) *models.User {
+	c := *u
+	c.EmailVerifiedAt = cloneTime(u.EmailVerifiedAt)
+	c.DeletionAt = cloneTime(u.DeletionAt)
+	c.DisabledAt = cloneTime(u.DisabledAt)
+	return &c
+}
+
+func cloneTodo(t *models.Todo) *models.Todo {
+	c := *t
+	return &c
+}
+
+// MemoryTodoStore は MemoryStore のデータを使う TodoStore です。
+type MemoryTodoStore struct {
+	m *MemoryStore
+}
+
+// Create は新しいTodoを保存します。t.WorkspaceID のワークスペースに作成されます。
+func (s *MemoryTodoStore) Create(ctx context.Context, t *models.Todo) (*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not insert todo: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.users[t.UserID]; !ok {
+		return nil, fmt.Errorf("could not insert todo: user %d does not exist", t.UserID)
+	}
+	s.m.lastTodoID++
+	created := &models.Todo{
+		ID:          s.m.lastTodoID,
+		UserID:      t.UserID,
+		WorkspaceID: t.WorkspaceID,
+		Title:       t.Title,
+		Completed:   t.Completed,
+		CreatedAt:   now(),
+	}
+	created.UpdatedAt = created.CreatedAt
+	s.m.todos[created.ID] = created
+	return cloneTodo(created), nil
+}
+
+// FindAll はワークスペース内のすべてのTodoを新しい順に返します。
+func (s *MemoryTodoStore) FindAll(ctx context.Context, workspaceID int) ([]*models.Todo, error) {
+	return s.find(ctx, func(t *models.Todo) bool { return t.WorkspaceID == workspaceID })
+}
+
+// FindByID はワークスペース内の指定されたIDのTodoを返します。別のワークスペースのTodoは ErrTodoNotFound になります。
+func (s *MemoryTodoStore) FindByID(ctx context.Context, workspaceID, id int) (*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not query todo: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	t, ok := s.m.todos[id]
+	if !ok || t.WorkspaceID != workspaceID {
+		return nil, ErrTodoNotFound
+	}
+	return cloneTodo(t), nil
+}
+
+// FindByUserID はワークスペース内でユーザーが作成したTodoを新しい順に返します。
+func (s *MemoryTodoStore) FindByUserID(ctx context.Context, workspaceID, userID int) ([]*models.Todo, error) {
+	return s.find(ctx, func(t *models.Todo) bool { return t.WorkspaceID == workspaceID && t.UserID == userID })
+}
+
+// FindByUserIDAcrossWorkspaces はすべてのワークスペースからユーザーが作成したTodoを新しい順に返します。
+func (s *MemoryTodoStore) FindByUserIDAcrossWorkspaces(ctx context.Context, userID int) ([]*models.Todo, error) {
+	return s.find(ctx, func(t *models.Todo) bool { return t.UserID == userID })
+}
+
+// find は match に一致するTodoを作成日時の新しい順に返します。一致するものがない場合も空のスライスを返します。
+func (s *MemoryTodoStore) find(ctx context.Context, match func(*models.Todo) bool) ([]*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not query todos: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	todos := []*models.Todo{}
+	for _, t := range s.m.todos {
+		if match(t) {
+			todos = append(todos, cloneTodo(t))
+		}
+	}
+	// 作成日時が同じ場合は後から作成したものを先にする
+	slices.SortFunc(todos, func(a, b *models.Todo) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+	return todos, nil
+}
+
+// Update はワークスペース内の指定されたIDのTodoのタイトルと完了状態を更新します。
+func (s *MemoryTodoStore) Update(ctx context.Context, workspaceID, id int, t *models.Todo) (*models.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not update todo: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	existing, ok := s.m.todos[id]
+	if !ok || existing.WorkspaceID != workspaceID {
+		return nil, ErrTodoNotFound
+	}
+	existing.Title = t.Title
+	existing.Completed = t.Completed
+	existing.UpdatedAt = now()
+	return cloneTodo(existing), nil
+}
+
+// Delete はワークスペース内の指定されたIDのTodoを削除します。
+func (s *MemoryTodoStore) Delete(ctx context.Context, workspaceID, id int) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("could not delete todo: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	existing, ok := s.m.todos[id]
+	if !ok || existing.WorkspaceID != workspaceID {
+		return ErrTodoNotFound
+	}
+	delete(s.m.todos, id)
+	return nil
+}
+
+// MemoryUserStore は MemoryStore のデータを使う UserStore です。
+type MemoryUserStore struct {
+	m *MemoryStore
+}
+
+// Store は s とデータを共有している MemoryStore を返します。
+func (s *MemoryUserStore) Store() *MemoryStore {
+	return s.m
+}
+
+// Create は新しいユーザーを保存し、u.ID を設定して返します。
+// メールアドレスが既に使われている場合は ErrDuplicateEmail、ユーザー名が既に使われている場合は ErrDuplicateUsername を返します。
+func (s *MemoryUserStore) Create(ctx context.Context, u *models.User) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not insert user: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, existing := range s.m.users {
+		if strings.EqualFold(existing.Email, u.Email) {
+			return nil, ErrDuplicateEmail
+		}
+	}
+	for _, existing := range s.m.users {
+		if strings.EqualFold(existing.Username, u.Username) {
+			return nil, ErrDuplicateUsername
+		}
+	}
+	if _, ok := s.m.roles[u.Role]; !ok {
+		return nil, fmt.Errorf("could not insert user: %w", ErrRoleNotFound)
+	}
+
+	s.m.lastUserID++
+	stored := &models.User{
+		ID:           s.m.lastUserID,
+		Username:     u.Username,
+		Email:        u.Email,
+		PasswordHash: u.PasswordHash,
+		Role:         u.Role,
+		CreatedAt:    now(),
+	}
+	stored.UpdatedAt = stored.CreatedAt
+	s.m.users[stored.ID] = stored
+
+	u.ID = stored.ID
+	return u, nil
+}
+
+// FindByEmail はメールアドレスでユーザーを検索します。MySQL の照合順序と同じく大文字・小文字を区別しません。
+func (s *MemoryUserStore) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not query user: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, u := range s.m.users {
+		if strings.EqualFold(u.Email, email) {
+			return cloneUser(u), nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// FindByID はIDでユーザーを検索します。
+func (s *MemoryUserStore) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not query user: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	u, ok := s.m.users[int(id)]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return cloneUser(u), nil
+}
+
+// update はユーザーが存在する場合に fn で更新し、更新日時を設定します。存在しない場合は ErrUserNotFound を返します。
+func (s *MemoryUserStore) update(ctx context.Context, userID uint, fn func(u *models.User) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("could not update user: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	u, ok := s.m.users[int(userID)]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if err := fn(u); err != nil {
+		return err
+	}
+	u.UpdatedAt = now()
+	return nil
+}
+
+// UpdatePassword はユーザーのパスワードを更新し、token_version を加算します。
+func (s *MemoryUserStore) UpdatePassword(ctx context.Context, userID uint, newHash string) error {
+	return s.update(ctx, userID, func(u *models.User) error {
+		u.PasswordHash = newHash
+		u.TokenVersion++
+		return nil
+	})
+}
+
+// ResetPasswordWithToken はリセットトークンの消費とパスワードの更新をまとめて行います。
+// トークンが使用済み・期限切れの場合は ErrResetTokenNotFound を返し、ユーザーが存在しない場合はトークンを消費しません。
+func (s *MemoryUserStore) ResetPasswordWithToken(ctx context.Context, tokenID, userID uint, newHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	t, ok := s.m.resetTokens[tokenID]
+	if !ok || t.token.UserID != userID || t.token.UsedAt != nil || !t.token.ExpiresAt.After(time.Now()) {
+		return ErrResetTokenNotFound
+	}
+	u, ok := s.m.users[int(userID)]
+	if !ok {
+		return ErrUserNotFound
+	}
+	usedAt := now()
+	t.token.UsedAt = &usedAt
+	u.PasswordHash = newHash
+	u.TokenVersion++
+	u.UpdatedAt = usedAt
+	return nil
+}
+
+// UpdatePasswordHash は保存されているハッシュが oldHash の場合だけ newHash に置き換えます。token_version は変えません。
+func (s *MemoryUserStore) UpdatePasswordHash(ctx context.Context, userID uint, oldHash, newHash string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("could not update password hash: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if u, ok := s.m.users[int(userID)]; ok && u.PasswordHash == oldHash {
+		u.PasswordHash = newHash
+	}
+	return nil
+}
+
+// MarkEmailVerified はユーザーのメールアドレスを確認済みにします。
+func (s *MemoryUserStore) MarkEmailVerified(ctx context.Context, userID uint) error {
+	return s.update(ctx, userID, func(u *models.User) error {
+		verifiedAt := now()
+		u.EmailVerifiedAt = &verifiedAt
+		return nil
+	})
+}
+
+// UpdateUsername はユーザー名を更新します。他のユーザーが使っている場合は ErrDuplicateUsername を返します。
+func (s *MemoryUserStore) UpdateUsername(ctx context.Context, userID uint, username string) error {
+	return s.update(ctx, userID, func(u *models.User) error {
+		for _, other := range s.m.users {
+			if other.ID != u.ID && strings.EqualFold(other.Username, username) {
+				return ErrDuplicateUsername
+			}
+		}
+		u.Username = username
+		return nil
+	})
+}
+
+// UpdateEmail はメールアドレスを更新し、確認済みにします。他のユーザーが使っている場合は ErrDuplicateEmail を返します。
+func (s *MemoryUserStore) UpdateEmail(ctx context.Context, userID uint, email string) error {
+	return s.update(ctx, userID, func(u *models.User) error {
+		for _, other := range s.m.users {
+			if other.ID != u.ID && strings.EqualFold(other.Email, email) {
+				return ErrDuplicateEmail
+			}
+		}
+		verifiedAt := now()
+		u.Email = email
+		u.EmailVerifiedAt = &verifiedAt
+		return nil
+	})
+}
+
+// ChangeEmailWithToken は確認トークンの消費とメールアドレスの変更をまとめて行います。
+// トークンが使用済み・期限切れの場合は ErrVerificationTokenNotFound を返し、アドレスが使用済みの場合はトークンを消費しません。
+func (s *MemoryUserStore) ChangeEmailWithToken(ctx context.Context, tokenID, userID uint, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	t, ok := s.m.verificationTokens[tokenID]
+	if !ok || t.token.UserID != userID || t.token.UsedAt != nil || !t.token.ExpiresAt.After(time.Now()) {
+		return ErrVerificationTokenNotFound
+	}
+	u, ok := s.m.users[int(userID)]
+	if !ok {
+		return ErrUserNotFound
+	}
+	for _, other := range s.m.users {
+		if other.ID != u.ID && strings.EqualFold(other.Email, email) {
+			return ErrDuplicateEmail
+		}
+	}
+	usedAt := now()
+	t.token.UsedAt = &usedAt
+	u.Email = email
+	u.EmailVerifiedAt = &usedAt
+	u.UpdatedAt = usedAt
+	return nil
+}
+
+// ScheduleDeletion はユーザーを退会手続き中にし、token_version を加算します。
+func (s *MemoryUserStore) ScheduleDeletion(ctx context.Context, userID uint, deletionAt time.Time) error {
+	return s.update(ctx, userID, func(u *models.User) error {
+		at := deletionAt.Truncate(time.Second)
+		u.DeletionAt = &at
+		u.TokenVersion++
+		return nil
+	})
+}
+
+// CancelDeletion は退会手続きを取り消します。ユーザーが存在しなくてもエラーにしません。
+func (s *MemoryUserStore) CancelDeletion(ctx context.Context, userID uint) error {
+	err := s.update(ctx, userID, func(u *models.User) error {
+		u.DeletionAt = nil
+		return nil
+	})
+	if err == ErrUserNotFound {
+		return nil
+	}
+	return err
+}
+
+// Delete はユーザーと、そのユーザーのTodo・トークン・セッション・ワークスペースのメンバーシップなどを削除します。
+// MySQL と同じく、セキュリティイベントは削除しません。
+func (s *MemoryUserStore) Delete(ctx context.Context, userID uint) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("could not delete user: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	return s.deleteLocked(userID)
+}
+
+func (s *MemoryUserStore) deleteLocked(userID uint) error {
+	if _, ok := s.m.users[int(userID)]; !ok {
+		return ErrUserNotFound
+	}
+	delete(s.m.users, int(userID))
+	for id, t := range s.m.todos {
+		if t.UserID == int(userID) {
+			delete(s.m.todos, id)
+		}
+	}
+	for id, t := range s.m.resetTokens {
+		if t.token.UserID == userID {
+			delete(s.m.resetTokens, id)
+		}
+	}
+	for id, t := range s.m.verificationTokens {
+		if t.token.UserID == userID {
+			delete(s.m.verificationTokens, id)
+		}
+	}
+	for id, t := range s.m.magicLinkTokens {
+		if t.token.UserID == userID {
+			delete(s.m.magicLinkTokens, id)
+		}
+	}
+	for id, sess := range s.m.sessions {
+		if sess.UserID == int(userID) {
+			delete(s.m.sessions, id)
+		}
+	}
+	s.m.members = slices.DeleteFunc(s.m.members, func(m *models.WorkspaceMember) bool { return m.UserID == int(userID) })
+	s.m.identities = slices.DeleteFunc(s.m.identities, func(i *models.UserIdentity) bool { return i.UserID == int(userID) })
+	return nil
+}
+
+// DeleteScheduledBefore は削除予定日時が before 以前のユーザーをすべて削除し、削除件数を返します。
+func (s *MemoryUserStore) DeleteScheduledBefore(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("could not query scheduled deletions: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	deleted := 0
+	for _, u := range s.m.users {
+		if u.DeletionAt != nil && !u.DeletionAt.After(before) {
+			if err := s.deleteLocked(uint(u.ID)); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// List は検索条件に一致するユーザーをIDの順にページングして返します。2つ目の戻り値は条件に一致する総件数です。
+// MySQL の実装と同じく、検索語の % や _ はワイルドカードとして扱わず、そのままの文字列として部分一致させます。
+func (s *MemoryUserStore) List(ctx context.Context, filter models.UserListFilter) ([]*models.User, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, fmt.Errorf("could not query users: %w", err)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	query := strings.ToLower(filter.Query)
+	var matched []*models.User
+	for _, u := range s.m.users {
+		if query != "" && !strings.Contains(strings.ToLower(u.Username), query) && !strings.Contains(strings.ToLower(u.Email), query) {
+			continue
+		}
+		if filter.Role != "" && u.Role != filter.Role {
+			continue
+		}
+		matched = append(matched, u)
+	}
+	slices.SortFunc(matched, func(a, b *models.User) int { return a.ID - b.ID })
+
+	users := []*models.User{}
+	offset := (filter.Page - 1) * filter.PerPage
+	for i := offset; i >= 0 && i < len(matched) && i < offset+filter.PerPage; i++ {
+		users = append(users, cloneUser(matched[i]))
+	}
+	return users, len(matched), nil
+}
+
+// UpdateRole はユーザーのロールを変更します。存在しないロールの場合は ErrRoleNotFound を返します。
+// MySQL の実装と同じく、ユーザーが存在しなくてもエラーにしません。
+func (s *MemoryUserStore) UpdateRole(ctx context.Context, userID uint, role string) error {
+	if !s.m.hasRole(role) {
+		return ErrRoleNotFound
+	}
+	err := s.update(ctx, userID, func(u *models.User) error {
+		u.Role = role
+		return nil
+	})
+	if err == ErrUserNotFound {
+		return nil
+	}
+	return err
+}
+
+func (m *MemoryStore) hasRole(role string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.roles[role]
+	return ok
+}
+
+// SetDisabled はユーザーを無効化・有効化します。無効化時は token_version を加算します。
+// MySQL の実装と同じく、ユーザーが存在しなくてもエラーにしません。
+func (s *MemoryUserStore) SetDisabled(ctx context.Context, userID uint, disabled bool) error {
+	err := s.update(ctx, userID, func(u *models.User) error {
+		if disabled {
+			disabledAt := now()
+			u.DisabledAt = &disabledAt
+			u.TokenVersion++
+		} else {
+			u.DisabledAt = nil
+		}
+		return nil
+	})
+	if err == ErrUserNotFound {
+		return nil
+	}
+	return err
+}
+
+// MemoryResetTokenRepo は MemoryStore のデータを使う ResetTokenRepository です。
+type MemoryResetTokenRepo struct {
+	m *MemoryStore
+}
+
+// Save はトークンのハッシュを保存します。
+func (r *MemoryResetTokenRepo) Save(ctx context.Context, t *models.PasswordResetToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.users[int(t.UserID)]; !ok {
+		return fmt.Errorf("could not save reset token: user %d does not exist", t.UserID)
+	}
+	hash := hashToken(t.Token)
+	for _, existing := range r.m.resetTokens {
+		if existing.tokenHash == hash {
+			return fmt.Errorf("could not save reset token: duplicate token")
+		}
+	}
+	r.m.lastTokenID++
+	r.m.resetTokens[r.m.lastTokenID] = &memoryResetToken{
+		token: models.PasswordResetToken{
+			ID:        r.m.lastTokenID,
+			UserID:    t.UserID,
+			ExpiresAt: t.ExpiresAt.Truncate(time.Second),
+			CreatedAt: now(),
+		},
+		tokenHash: hash,
+	}
+	return nil
+}
+
+func cloneResetToken(t *memoryResetToken) *models.PasswordResetToken {
+	c := t.token
+	c.UsedAt = cloneTime(t.token.UsedAt)
+	return &c
+}
+
+// FindByToken は平文のトークンに一致するリセットトークンを返します。
+func (r *MemoryResetTokenRepo) FindByToken(ctx context.Context, token string) (*models.PasswordResetToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	hash := hashToken(token)
+	for _, t := range r.m.resetTokens {
+		if t.tokenHash == hash {
+			return cloneResetToken(t), nil
+		}
+	}
+	return nil, ErrResetTokenNotFound
+}
+
+// FindByUserID はユーザーのリセットトークン履歴を新しい順に返します。
+func (r *MemoryResetTokenRepo) FindByUserID(ctx context.Context, userID uint) ([]*models.PasswordResetToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	tokens := []*models.PasswordResetToken{}
+	for _, t := range r.m.resetTokens {
+		if t.token.UserID == userID {
+			tokens = append(tokens, cloneResetToken(t))
+		}
+	}
+	slices.SortFunc(tokens, func(a, b *models.PasswordResetToken) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return int(b.ID) - int(a.ID)
+	})
+	return tokens, nil
+}
+
+// InvalidateForUser はユーザーの未使用のリセットトークンをすべて使用済みにします。
+func (r *MemoryResetTokenRepo) InvalidateForUser(ctx context.Context, userID uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	usedAt := now()
+	for _, t := range r.m.resetTokens {
+		if t.token.UserID == userID && t.token.UsedAt == nil {
+			t.token.UsedAt = cloneTime(&usedAt)
+		}
+	}
+	return nil
+}
+
+// MarkUsed は未使用のトークンを使用済みにします。使用済みまたは存在しない場合は ErrResetTokenNotFound を返します。
+func (r *MemoryResetTokenRepo) MarkUsed(ctx context.Context, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	t, ok := r.m.resetTokens[id]
+	if !ok || t.token.UsedAt != nil {
+		return ErrResetTokenNotFound
+	}
+	usedAt := now()
+	t.token.UsedAt = &usedAt
+	return nil
+}
+
+// CleanupExpired は使用済み・期限切れのトークンを削除します。
+func (r *MemoryResetTokenRepo) CleanupExpired(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for id, t := range r.m.resetTokens {
+		if t.token.UsedAt != nil || t.token.ExpiresAt.Before(time.Now()) {
+			delete(r.m.resetTokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,467 @@
+package repositories
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"go-next-todo/backend/internal/models"
+)
+
+// memoryVerificationToken は保存したメール確認トークンです。MySQL と同じく平文のトークンは保持しません。
+type memoryVerificationToken struct {
+	token     models.EmailVerificationToken
+	tokenHash string
+}
+
+// memoryMagicLinkToken は保存したマジックリンクのトークンです。MySQL と同じく平文のトークンは保持しません。
+type memoryMagicLinkToken struct {
+	token     models.MagicLinkToken
+	tokenHash string
+}
+
+// MemoryVerificationTokenRepo は MemoryStore のデータを使う VerificationTokenRepository です。
+type MemoryVerificationTokenRepo struct {
+	m *MemoryStore
+}
+
+// Save はトークンのハッシュを保存します。
+func (r *MemoryVerificationTokenRepo) Save(t *models.EmailVerificationToken) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.users[int(t.UserID)]; !ok {
+		return fmt.Errorf("could not save verification token: user %d does not exist", t.UserID)
+	}
+	hash := hashToken(t.Token)
+	for _, existing := range r.m.verificationTokens {
+		if existing.tokenHash == hash {
+			return fmt.Errorf("could not save verification token: duplicate token")
+		}
+	}
+	r.m.lastVerificationID++
+	r.m.verificationTokens[r.m.lastVerificationID] = &memoryVerificationToken{
+		token: models.EmailVerificationToken{
+			ID:        r.m.lastVerificationID,
+			UserID:    t.UserID,
+			Email:     t.Email,
+			Purpose:   t.Purpose,
+			ExpiresAt: t.ExpiresAt.Truncate(time.Second),
+			CreatedAt: now(),
+		},
+		tokenHash: hash,
+	}
+	return nil
+}
+
+// FindByToken は平文のトークンに一致する確認トークンを返します。
+func (r *MemoryVerificationTokenRepo) FindByToken(token string) (*models.EmailVerificationToken, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	hash := hashToken(token)
+	for _, t := range r.m.verificationTokens {
+		if t.tokenHash == hash {
+			c := t.token
+			c.UsedAt = cloneTime(t.token.UsedAt)
+			return &c, nil
+		}
+	}
+	return nil, ErrVerificationTokenNotFound
+}
+
+// MarkUsed は未使用のトークンを使用済みにします。使用済みまたは存在しない場合は ErrVerificationTokenNotFound を返します。
+func (r *MemoryVerificationTokenRepo) MarkUsed(id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	t, ok := r.m.verificationTokens[id]
+	if !ok || t.token.UsedAt != nil {
+		return ErrVerificationTokenNotFound
+	}
+	usedAt := now()
+	t.token.UsedAt = &usedAt
+	return nil
+}
+
+// CleanupExpired は使用済み・期限切れのトークンを削除します。
+func (r *MemoryVerificationTokenRepo) CleanupExpired() error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for id, t := range r.m.verificationTokens {
+		if t.token.UsedAt != nil || t.token.ExpiresAt.Before(time.Now()) {
+			delete(r.m.verificationTokens, id)
+		}
+	}
+	return nil
+}
+
+// MemoryMagicLinkTokenRepo は MemoryStore のデータを使う MagicLinkTokenRepository です。
+type MemoryMagicLinkTokenRepo struct {
+	m *MemoryStore
+}
+
+// Save はトークンのハッシュを保存します。
+func (r *MemoryMagicLinkTokenRepo) Save(t *models.MagicLinkToken) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.users[int(t.UserID)]; !ok {
+		return fmt.Errorf("could not save magic link token: user %d does not exist", t.UserID)
+	}
+	hash := hashToken(t.Token)
+	for _, existing := range r.m.magicLinkTokens {
+		if existing.tokenHash == hash {
+			return fmt.Errorf("could not save magic link token: duplicate token")
+		}
+	}
+	r.m.lastMagicLinkID++
+	r.m.magicLinkTokens[r.m.lastMagicLinkID] = &memoryMagicLinkToken{
+		token: models.MagicLinkToken{
+			ID:        r.m.lastMagicLinkID,
+			UserID:    t.UserID,
+			ExpiresAt: t.ExpiresAt.Truncate(time.Second),
+			CreatedAt: now(),
+		},
+		tokenHash: hash,
+	}
+	return nil
+}
+
+// FindByToken は平文のトークンに一致するマジックリンクのトークンを返します。
+func (r *MemoryMagicLinkTokenRepo) FindByToken(token string) (*models.MagicLinkToken, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	hash := hashToken(token)
+	for _, t := range r.m.magicLinkTokens {
+		if t.tokenHash == hash {
+			c := t.token
+			c.UsedAt = cloneTime(t.token.UsedAt)
+			return &c, nil
+		}
+	}
+	return nil, ErrMagicLinkTokenNotFound
+}
+
+// InvalidateForUser はユーザーの未使用のトークンをすべて使用済みにします。
+func (r *MemoryMagicLinkTokenRepo) InvalidateForUser(userID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	usedAt := now()
+	for _, t := range r.m.magicLinkTokens {
+		if t.token.UserID == userID && t.token.UsedAt == nil {
+			t.token.UsedAt = cloneTime(&usedAt)
+		}
+	}
+	return nil
+}
+
+// MarkUsed は未使用のトークンを使用済みにします。使用済みまたは存在しない場合は ErrMagicLinkTokenNotFound を返します。
+func (r *MemoryMagicLinkTokenRepo) MarkUsed(id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	t, ok := r.m.magicLinkTokens[id]
+	if !ok || t.token.UsedAt != nil {
+		return ErrMagicLinkTokenNotFound
+	}
+	usedAt := now()
+	t.token.UsedAt = &usedAt
+	return nil
+}
+
+// CleanupExpired は使用済み・期限切れのトークンを削除します。
+func (r *MemoryMagicLinkTokenRepo) CleanupExpired() error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for id, t := range r.m.magicLinkTokens {
+		if t.token.UsedAt != nil || t.token.ExpiresAt.Before(time.Now()) {
+			delete(r.m.magicLinkTokens, id)
+		}
+	}
+	return nil
+}
+
+func cloneSession(s *models.Session) *models.Session {
+	c := *s
+	c.RevokedAt = cloneTime(s.RevokedAt)
+	if s.ImpersonatorID != nil {
+		id := *s.ImpersonatorID
+		c.ImpersonatorID = &id
+	}
+	return &c
+}
+
+// MemorySessionRepo は MemoryStore のデータを使う SessionRepository です。
+type MemorySessionRepo struct {
+	m *MemoryStore
+}
+
+// Create はセッションを保存します。
+func (r *MemorySessionRepo) Create(s *models.Session) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.users[s.UserID]; !ok {
+		return fmt.Errorf("could not create session: user %d does not exist", s.UserID)
+	}
+	if _, ok := r.m.sessions[s.ID]; ok {
+		return fmt.Errorf("could not create session: duplicate session ID")
+	}
+	stored := cloneSession(s)
+	stored.CreatedAt = now()
+	stored.LastSeenAt = s.LastSeenAt.Truncate(time.Second)
+	stored.ExpiresAt = s.ExpiresAt.Truncate(time.Second)
+	stored.RevokedAt = nil
+	stored.Current = false
+	r.m.sessions[s.ID] = stored
+	return nil
+}
+
+// FindByID はIDでセッションを検索します。失効・期限切れのセッションも返します。
+func (r *MemorySessionRepo) FindByID(id string) (*models.Session, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	s, ok := r.m.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return cloneSession(s), nil
+}
+
+// ListActiveForUser はユーザーの有効なセッションを最終アクセスの新しい順に返します。
+// パスワード変更などで token_version が変わる前に発行されたセッションは含みません。
+func (r *MemorySessionRepo) ListActiveForUser(userID int) ([]*models.Session, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	sessions := []*models.Session{}
+	u, ok := r.m.users[userID]
+	if !ok {
+		return sessions, nil
+	}
+	for _, s := range r.m.sessions {
+		if s.UserID == userID && s.TokenVersion == u.TokenVersion && s.RevokedAt == nil && s.ExpiresAt.After(time.Now()) {
+			sessions = append(sessions, cloneSession(s))
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *models.Session) int { return b.LastSeenAt.Compare(a.LastSeenAt) })
+	return sessions, nil
+}
+
+// Touch はセッションの最終アクセス日時とIPアドレスを更新します。
+func (r *MemorySessionRepo) Touch(id, ipAddress string, at time.Time) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if s, ok := r.m.sessions[id]; ok {
+		s.LastSeenAt = at.Truncate(time.Second)
+		s.IPAddress = ipAddress
+	}
+	return nil
+}
+
+// Revoke はユーザーのセッションを失効させます。他のユーザーのセッションや失効済みのセッションは ErrSessionNotFound になります。
+func (r *MemorySessionRepo) Revoke(userID int, id string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	s, ok := r.m.sessions[id]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	revokedAt := now()
+	s.RevokedAt = &revokedAt
+	return nil
+}
+
+// CleanupExpired は期限切れのセッションを削除します。
+func (r *MemorySessionRepo) CleanupExpired() error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for id, s := range r.m.sessions {
+		if s.ExpiresAt.Before(time.Now()) {
+			delete(r.m.sessions, id)
+		}
+	}
+	return nil
+}
+
+func cloneSecurityEvent(e *models.SecurityEvent) *models.SecurityEvent {
+	c := *e
+	if e.UserID != nil {
+		id := *e.UserID
+		c.UserID = &id
+	}
+	if e.ActorID != nil {
+		id := *e.ActorID
+		c.ActorID = &id
+	}
+	if len(e.Metadata) > 0 {
+		c.Metadata = maps.Clone(e.Metadata)
+	} else {
+		c.Metadata = nil
+	}
+	return &c
+}
+
+// MemorySecurityEventRepo は MemoryStore のデータを使う SecurityEventRepository です。
+type MemorySecurityEventRepo struct {
+	m *MemoryStore
+}
+
+// Create はセキュリティイベントを保存し、e.ID を設定します。
+func (r *MemorySecurityEventRepo) Create(e *models.SecurityEvent) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	stored := cloneSecurityEvent(e)
+	stored.ID = int64(len(r.m.securityEvents) + 1)
+	stored.CreatedAt = now()
+	r.m.securityEvents = append(r.m.securityEvents, stored)
+	e.ID = stored.ID
+	return nil
+}
+
+// List は検索条件に一致するセキュリティイベントを新しい順にページングして返します。2つ目の戻り値は条件に一致する総件数です。
+func (r *MemorySecurityEventRepo) List(filter models.SecurityEventFilter) ([]*models.SecurityEvent, int, error) {
+	matched := r.find(func(e *models.SecurityEvent) bool {
+		switch {
+		case filter.UserID != 0 && (e.UserID == nil || *e.UserID != filter.UserID):
+			return false
+		case filter.ActorID != 0 && (e.ActorID == nil || *e.ActorID != filter.ActorID):
+			return false
+		case filter.Type != "" && e.Type != filter.Type:
+			return false
+		case filter.IPAddress != "" && e.IPAddress != filter.IPAddress:
+			return false
+		case !filter.Since.IsZero() && e.CreatedAt.Before(filter.Since):
+			return false
+		case !filter.Until.IsZero() && !e.CreatedAt.Before(filter.Until):
+			return false
+		}
+		return true
+	})
+
+	events := []*models.SecurityEvent{}
+	offset := (filter.Page - 1) * filter.PerPage
+	for i := offset; i >= 0 && i < len(matched) && i < offset+filter.PerPage; i++ {
+		events = append(events, matched[i])
+	}
+	return events, len(matched), nil
+}
+
+// ListForUser はユーザーに関するセキュリティイベントを新しい順に最大 limit 件返します。
+func (r *MemorySecurityEventRepo) ListForUser(userID, limit int) ([]*models.SecurityEvent, error) {
+	events := r.find(func(e *models.SecurityEvent) bool { return e.UserID != nil && *e.UserID == userID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// find は match に一致するイベントを新しい順に返します。
+func (r *MemorySecurityEventRepo) find(match func(*models.SecurityEvent) bool) []*models.SecurityEvent {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	events := []*models.SecurityEvent{}
+	for i := len(r.m.securityEvents) - 1; i >= 0; i-- {
+		if e := r.m.securityEvents[i]; match(e) {
+			events = append(events, cloneSecurityEvent(e))
+		}
+	}
+	return events
+}
+
+// MemoryOIDCRepo は MemoryStore のデータを使う OIDCRepository です。
+type MemoryOIDCRepo struct {
+	m *MemoryStore
+}
+
+// SaveAuthRequest は認可リクエストの state 等を保存します。
+func (r *MemoryOIDCRepo) SaveAuthRequest(req *models.OIDCAuthRequest) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.authRequests[req.State]; ok {
+		return fmt.Errorf("could not save oidc auth request: duplicate state")
+	}
+	c := *req
+	c.ExpiresAt = req.ExpiresAt.Truncate(time.Second)
+	r.m.authRequests[req.State] = &c
+	return nil
+}
+
+// ConsumeAuthRequest は state に対応する認可リクエストを取得して削除します。
+// 同じ state は2回使用できません。
+func (r *MemoryOIDCRepo) ConsumeAuthRequest(state string) (*models.OIDCAuthRequest, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	req, ok := r.m.authRequests[state]
+	if !ok {
+		return nil, ErrOIDCStateNotFound
+	}
+	delete(r.m.authRequests, state)
+	return req, nil
+}
+
+// FindIdentity は issuer と subject で紐付け済みのアカウントを検索します。
+func (r *MemoryOIDCRepo) FindIdentity(issuer, subject string) (*models.UserIdentity, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, id := range r.m.identities {
+		if id.Issuer == issuer && id.Subject == subject {
+			c := *id
+			return &c, nil
+		}
+	}
+	return nil, ErrIdentityNotFound
+}
+
+// LinkIdentity は外部アカウントをユーザーに紐付けます。
+func (r *MemoryOIDCRepo) LinkIdentity(identity *models.UserIdentity) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.users[identity.UserID]; !ok {
+		return fmt.Errorf("could not link identity: user %d does not exist", identity.UserID)
+	}
+	for _, id := range r.m.identities {
+		if id.Issuer == identity.Issuer && id.Subject == identity.Subject {
+			return ErrDuplicateIdentity
+		}
+	}
+	r.m.lastIdentityID++
+	r.m.identities = append(r.m.identities, &models.UserIdentity{
+		ID:        r.m.lastIdentityID,
+		UserID:    identity.UserID,
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: now(),
+	})
+	return nil
+}
+
+// CleanupExpired は期限切れの認可リクエストを削除します。
+func (r *MemoryOIDCRepo) CleanupExpired() error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for state, req := range r.m.authRequests {
+		if req.ExpiresAt.Before(time.Now()) {
+			delete(r.m.authRequests, state)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-next-todo/backend/internal/models"
+)
+
+func cloneRole(r *models.Role) *models.Role {
+	c := *r
+	c.Permissions = slices.Clone(r.Permissions)
+	if c.Permissions == nil {
+		c.Permissions = []string{}
+	}
+	slices.Sort(c.Permissions)
+	c.Permissions = slices.Compact(c.Permissions)
+	return &c
+}
+
+// MemoryRoleRepo は MemoryStore のデータを使う RoleRepository です。
+type MemoryRoleRepo struct {
+	m *MemoryStore
+}
+
+// List はすべてのロールを、MySQL の実装と同じく組み込みロール・名前の順に権限付きで返します。
+func (r *MemoryRoleRepo) List() ([]*models.Role, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	roles := []*models.Role{}
+	for _, role := range r.m.roles {
+		roles = append(roles, cloneRole(role))
+	}
+	slices.SortFunc(roles, func(a, b *models.Role) int {
+		if a.BuiltIn != b.BuiltIn {
+			if a.BuiltIn {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return roles, nil
+}
+
+// FindByName は名前でロールを検索します。
+func (r *MemoryRoleRepo) FindByName(name string) (*models.Role, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	role, ok := r.m.roles[name]
+	if !ok {
+		return nil, ErrRoleNotFound
+	}
+	return cloneRole(role), nil
+}
+
+// PermissionsFor はロールに付与された権限を返します。存在しないロールの場合は空のスライスを返します。
+func (r *MemoryRoleRepo) PermissionsFor(name string) ([]string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	role, ok := r.m.roles[name]
+	if !ok {
+		return []string{}, nil
+	}
+	return cloneRole(role).Permissions, nil
+}
+
+// Create はロールを作成します。
+func (r *MemoryRoleRepo) Create(role *models.Role) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.roles[role.Name]; ok {
+		return ErrDuplicateRole
+	}
+	stored := cloneRole(role)
+	stored.CreatedAt = now()
+	stored.UpdatedAt = stored.CreatedAt
+	r.m.roles[role.Name] = stored
+	return nil
+}
+
+// Update はロールの説明と権限を更新します。
+func (r *MemoryRoleRepo) Update(role *models.Role) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	existing, ok := r.m.roles[role.Name]
+	if !ok {
+		return ErrRoleNotFound
+	}
+	existing.Description = role.Description
+	existing.Permissions = cloneRole(role).Permissions
+	existing.UpdatedAt = now()
+	return nil
+}
+
+// Upsert はロールが無ければ作成し、あれば説明と権限を上書きします。
+func (r *MemoryRoleRepo) Upsert(role *models.Role) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	stored := cloneRole(role)
+	stored.CreatedAt = now()
+	if existing, ok := r.m.roles[role.Name]; ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	stored.UpdatedAt = now()
+	r.m.roles[role.Name] = stored
+	return nil
+}
+
+// Delete はロールを削除します。MySQL の外部キー制約と同じく、ユーザーに割り当てられているロールは削除できません。
+func (r *MemoryRoleRepo) Delete(name string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.roles[name]; !ok {
+		return ErrRoleNotFound
+	}
+	for _, u := range r.m.users {
+		if u.Role == name {
+			return fmt.Errorf("could not delete role: role %q is assigned to users", name)
+		}
+	}
+	delete(r.m.roles, name)
+	return nil
+}
+
+// CountUsers はロールが割り当てられているユーザー数を返します。
+func (r *MemoryRoleRepo) CountUsers(name string) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	n := 0
+	for _, u := range r.m.users {
+		if u.Role == name {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,20 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/repositories/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Backend {
+		s := repositories.NewMemoryStore()
+		return storetest.Backend{
+			Users:       s.Users(),
+			Todos:       s.Todos(),
+			ResetTokens: s.ResetTokens(),
+			Workspaces:  [2]int{1, 2},
+		}
+	})
+}
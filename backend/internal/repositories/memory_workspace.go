@@ -0,0 +1,233 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+
+	"go-next-todo/backend/internal/models"
+)
+
+// MemoryWorkspaceRepo は MemoryStore のデータを使う WorkspaceRepository です。
+type MemoryWorkspaceRepo struct {
+	m *MemoryStore
+}
+
+// Create はワークスペースを作成し、ownerID のユーザーをオーナーとして登録します。
+func (r *MemoryWorkspaceRepo) Create(ctx context.Context, name string, ownerID int) (*models.Workspace, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.users[ownerID]; !ok {
+		return nil, ErrUserNotFound
+	}
+	return r.createLocked(name, ownerID), nil
+}
+
+// CreateWithoutOwner はメンバーのいないワークスペースを作成します。
+// MySQL のテストデータで workspaces に直接 INSERT するのと同じ状態を作るために使います。
+func (r *MemoryWorkspaceRepo) CreateWithoutOwner(name string) *models.Workspace {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	r.m.lastWorkspaceID++
+	ws := &models.Workspace{ID: r.m.lastWorkspaceID, Name: name, CreatedAt: now()}
+	ws.UpdatedAt = ws.CreatedAt
+	r.m.workspaces[ws.ID] = ws
+	c := *ws
+	return &c
+}
+
+// createLocked はワークスペースとオーナーのメンバーシップを作成し、オーナーのロール付きで返します。
+func (r *MemoryWorkspaceRepo) createLocked(name string, ownerID int) *models.Workspace {
+	r.m.lastWorkspaceID++
+	ws := &models.Workspace{ID: r.m.lastWorkspaceID, Name: name, CreatedAt: now()}
+	ws.UpdatedAt = ws.CreatedAt
+	r.m.workspaces[ws.ID] = ws
+	r.m.members = append(r.m.members, &models.WorkspaceMember{
+		WorkspaceID: ws.ID,
+		UserID:      ownerID,
+		Role:        models.WorkspaceRoleOwner,
+		CreatedAt:   ws.CreatedAt,
+	})
+
+	c := *ws
+	c.Role = models.WorkspaceRoleOwner
+	return &c
+}
+
+// FirstOrCreateForUser はユーザーが最初に参加したワークスペースを返します。
+// どのワークスペースにも所属していない場合は name のワークスペースを作成し、ユーザーをオーナーにします。
+func (r *MemoryWorkspaceRepo) FirstOrCreateForUser(ctx context.Context, userID int, name string) (*models.Workspace, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.users[userID]; !ok {
+		return nil, ErrUserNotFound
+	}
+	if ws, err := r.firstLocked(userID); err == nil {
+		return ws, nil
+	}
+	return r.createLocked(name, userID), nil
+}
+
+// FindByID はIDでワークスペースを検索します。
+func (r *MemoryWorkspaceRepo) FindByID(ctx context.Context, id int) (*models.Workspace, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	ws, ok := r.m.workspaces[id]
+	if !ok {
+		return nil, ErrWorkspaceNotFound
+	}
+	c := *ws
+	return &c, nil
+}
+
+// ListForUser はユーザーが所属するワークスペースを、ユーザーのロール付きでIDの順に返します。
+func (r *MemoryWorkspaceRepo) ListForUser(ctx context.Context, userID int) ([]*models.Workspace, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	workspaces := []*models.Workspace{}
+	for _, m := range r.m.members {
+		if m.UserID == userID {
+			c := *r.m.workspaces[m.WorkspaceID]
+			c.Role = m.Role
+			workspaces = append(workspaces, &c)
+		}
+	}
+	slices.SortFunc(workspaces, func(a, b *models.Workspace) int { return a.ID - b.ID })
+	return workspaces, nil
+}
+
+// FirstForUser はユーザーが最初に参加したワークスペースを返します。
+func (r *MemoryWorkspaceRepo) FirstForUser(ctx context.Context, userID int) (*models.Workspace, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	return r.firstLocked(userID)
+}
+
+// firstLocked は参加した順で最初のワークスペースをユーザーのロール付きで返します。
+func (r *MemoryWorkspaceRepo) firstLocked(userID int) (*models.Workspace, error) {
+	for _, m := range r.m.members {
+		if m.UserID == userID {
+			c := *r.m.workspaces[m.WorkspaceID]
+			c.Role = m.Role
+			return &c, nil
+		}
+	}
+	return nil, ErrWorkspaceNotFound
+}
+
+// memberLocked はユーザー名とメールアドレスを設定したメンバーシップの複製を返します。
+func (r *MemoryWorkspaceRepo) memberLocked(m *models.WorkspaceMember) *models.WorkspaceMember {
+	c := *m
+	if u, ok := r.m.users[m.UserID]; ok {
+		c.Username = u.Username
+		c.Email = u.Email
+	}
+	return &c
+}
+
+// FindMember はワークスペースのメンバーシップを返します。メンバーでない場合は ErrNotMember を返します。
+func (r *MemoryWorkspaceRepo) FindMember(ctx context.Context, workspaceID, userID int) (*models.WorkspaceMember, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, m := range r.m.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			return r.memberLocked(m), nil
+		}
+	}
+	return nil, ErrNotMember
+}
+
+// ListMembers はワークスペースのメンバー一覧を参加した順に返します。
+func (r *MemoryWorkspaceRepo) ListMembers(ctx context.Context, workspaceID int) ([]*models.WorkspaceMember, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	members := []*models.WorkspaceMember{}
+	for _, m := range r.m.members {
+		if m.WorkspaceID == workspaceID {
+			members = append(members, r.memberLocked(m))
+		}
+	}
+	slices.SortStableFunc(members, func(a, b *models.WorkspaceMember) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return a.UserID - b.UserID
+	})
+	return members, nil
+}
+
+// AddMember はユーザーをワークスペースに追加します。
+// ワークスペースまたはユーザーが存在しない場合は、MySQL の実装と同じく ErrWorkspaceNotFound を返します。
+func (r *MemoryWorkspaceRepo) AddMember(ctx context.Context, workspaceID, userID int, role string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, m := range r.m.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			return ErrAlreadyMember
+		}
+	}
+	if _, ok := r.m.workspaces[workspaceID]; !ok {
+		return ErrWorkspaceNotFound
+	}
+	if _, ok := r.m.users[userID]; !ok {
+		return ErrWorkspaceNotFound
+	}
+	r.m.members = append(r.m.members, &models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        role,
+		CreatedAt:   now(),
+	})
+	return nil
+}
+
+// UpdateMemberRole はメンバーのロールを変更します。
+// 最後のオーナーを別のロールに変更する場合は ErrLastOwner を返します。
+func (r *MemoryWorkspaceRepo) UpdateMemberRole(ctx context.Context, workspaceID, userID int, role string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if err := checkOwnerRemains(r.memberRolesLocked(workspaceID), userID, role); err != nil {
+		return err
+	}
+	for _, m := range r.m.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			m.Role = role
+		}
+	}
+	return nil
+}
+
+// RemoveMember はユーザーをワークスペースから外します。
+// 最後のオーナーを外す場合は ErrLastOwner を返します。
+func (r *MemoryWorkspaceRepo) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if err := checkOwnerRemains(r.memberRolesLocked(workspaceID), userID, ""); err != nil {
+		return err
+	}
+	r.m.members = slices.DeleteFunc(r.m.members, func(m *models.WorkspaceMember) bool {
+		return m.WorkspaceID == workspaceID && m.UserID == userID
+	})
+	return nil
+}
+
+// memberRolesLocked はワークスペースのメンバーのロールをユーザーIDごとに返します。
+func (r *MemoryWorkspaceRepo) memberRolesLocked(workspaceID int) map[int]string {
+	roles := map[int]string{}
+	for _, m := range r.m.members {
+		if m.WorkspaceID == workspaceID {
+			roles[m.UserID] = m.Role
+		}
+	}
+	return roles
+}
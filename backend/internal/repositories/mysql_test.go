@@ -0,0 +1,44 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/repositories/storetest"
+	"go-next-todo/backend/testutil"
+)
+
+func TestMySQLStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Backend {
+		db, _, todoRepo, userRepo := testutil.SetupTestDB(t)
+		t.Cleanup(func() { db.Close() })
+
+		const otherWorkspaceID = testutil.DefaultWorkspaceID + 1
+		_, err := db.Exec("INSERT INTO workspaces (id, name) VALUES (?, ?)", otherWorkspaceID, "Other Workspace")
+		require.NoError(t, err)
+
+		return storetest.Backend{
+			Users:       userRepo,
+			Todos:       todoRepo,
+			ResetTokens: repositories.NewMySQLResetTokenRepo(db),
+			Workspaces:  [2]int{testutil.DefaultWorkspaceID, otherWorkspaceID},
+		}
+	})
+}
+
+func TestMySQLStore_QueryTimeout(t *testing.T) {
+	db, _, todoRepo, _ := testutil.SetupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	prev := repositories.QueryTimeout()
+	repositories.SetQueryTimeout(time.Nanosecond)
+	t.Cleanup(func() { repositories.SetQueryTimeout(prev) })
+
+	_, err := todoRepo.FindAll(context.Background(), testutil.DefaultWorkspaceID)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,112 @@
+// Package repositories はデータベース操作を行うリポジトリを提供します。
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+
+	"go-next-todo/backend/internal/models"
+)
+
+var (
+	ErrOIDCStateNotFound = errors.New("oidc state not found")
+	ErrIdentityNotFound  = errors.New("identity not found")
+	ErrDuplicateIdentity = errors.New("duplicate identity")
+)
+
+type OIDCRepository interface {
+	SaveAuthRequest(req *models.OIDCAuthRequest) error
+	ConsumeAuthRequest(state string) (*models.OIDCAuthRequest, error)
+	FindIdentity(issuer, subject string) (*models.UserIdentity, error)
+	LinkIdentity(identity *models.UserIdentity) error
+	CleanupExpired() error
+}
+
+type MySQLOIDCRepo struct {
+	DB *sql.DB
+}
+
+func NewMySQLOIDCRepo(db *sql.DB) *MySQLOIDCRepo {
+	return &MySQLOIDCRepo{DB: db}
+}
+
+// SaveAuthRequest は認可リクエストの state 等を保存します。
+func (r *MySQLOIDCRepo) SaveAuthRequest(req *models.OIDCAuthRequest) error {
+	_, err := r.DB.Exec(
+		"INSERT INTO oidc_auth_requests (state, nonce, code_verifier, expires_at) VALUES (?, ?, ?, ?)",
+		req.State, req.Nonce, req.CodeVerifier, req.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("could not save oidc auth request: %w", err)
+	}
+	return nil
+}
+
+// ConsumeAuthRequest は state に対応する認可リクエストを取得して削除します。
+// 同じ state は2回使用できません。
+func (r *MySQLOIDCRepo) ConsumeAuthRequest(state string) (*models.OIDCAuthRequest, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var req models.OIDCAuthRequest
+	err = tx.QueryRow(
+		"SELECT state, nonce, code_verifier, expires_at FROM oidc_auth_requests WHERE state = ? FOR UPDATE", state,
+	).Scan(&req.State, &req.Nonce, &req.CodeVerifier, &req.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOIDCStateNotFound
+		}
+		return nil, fmt.Errorf("could not query oidc auth request: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM oidc_auth_requests WHERE state = ?", state); err != nil {
+		return nil, fmt.Errorf("could not delete oidc auth request: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit oidc auth request: %w", err)
+	}
+	return &req, nil
+}
+
+// FindIdentity は issuer と subject で紐付け済みのアカウントを検索します。
+func (r *MySQLOIDCRepo) FindIdentity(issuer, subject string) (*models.UserIdentity, error) {
+	var id models.UserIdentity
+	err := r.DB.QueryRow(
+		"SELECT id, user_id, issuer, subject, email, created_at FROM user_identities WHERE issuer = ? AND subject = ?",
+		issuer, subject,
+	).Scan(&id.ID, &id.UserID, &id.Issuer, &id.Subject, &id.Email, &id.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("could not query identity: %w", err)
+	}
+	return &id, nil
+}
+
+// LinkIdentity は外部アカウントをユーザーに紐付けます。
+func (r *MySQLOIDCRepo) LinkIdentity(identity *models.UserIdentity) error {
+	_, err := r.DB.Exec(
+		"INSERT INTO user_identities (user_id, issuer, subject, email) VALUES (?, ?, ?, ?)",
+		identity.UserID, identity.Issuer, identity.Subject, identity.Email,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return ErrDuplicateIdentity
+		}
+		return fmt.Errorf("could not link identity: %w", err)
+	}
+	return nil
+}
+
+// CleanupExpired は期限切れの認可リクエストを削除します。
+func (r *MySQLOIDCRepo) CleanupExpired() error {
+	_, err := r.DB.Exec("DELETE FROM oidc_auth_requests WHERE expires_at < NOW()")
+	return err
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultQueryTimeout は SetQueryTimeout で設定していない場合の、1回のデータベース操作にかける最大時間です。
+const DefaultQueryTimeout = 5 * time.Second
+
+var queryTimeout atomic.Int64
+
+func init() {
+	queryTimeout.Store(int64(DefaultQueryTimeout))
+}
+
+// SetQueryTimeout は1回のデータベース操作にかける最大時間を変更します。起動時に設定から呼び出します。
+// 0 の場合は期限を設けず、呼び出し元の context.Context のキャンセルだけに従います。
+func SetQueryTimeout(d time.Duration) {
+	queryTimeout.Store(int64(d))
+}
+
+// QueryTimeout は現在の1回のデータベース操作にかける最大時間を返します。
+func QueryTimeout() time.Duration {
+	return time.Duration(queryTimeout.Load())
+}
+
+// withQueryTimeout は ctx に1回のデータベース操作の期限を設定します。
+// HTTP リクエストが中断された場合も ctx がキャンセルされるため、実行中のクエリを打ち切れます。
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d := time.Duration(queryTimeout.Load()); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
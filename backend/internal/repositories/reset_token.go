@@ -2,9 +2,11 @@
 package repositories
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
-	"log"
 
 	"go-next-todo/backend/internal/models"
 )
@@ -12,10 +14,12 @@ import (
 var ErrResetTokenNotFound = errors.New("reset token not found")
 
 type ResetTokenRepository interface {
-	Save(token *models.PasswordResetToken) error
-	FindByToken(token string) (*models.PasswordResetToken, error)
-	MarkUsed(id uint) error
-	CleanupExpired() error
+	Save(ctx context.Context, token *models.PasswordResetToken) error
+	FindByToken(ctx context.Context, token string) (*models.PasswordResetToken, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*models.PasswordResetToken, error)
+	InvalidateForUser(ctx context.Context, userID uint) error
+	MarkUsed(ctx context.Context, id uint) error
+	CleanupExpired(ctx context.Context) error
 }
 
 type MySQLResetTokenRepo struct {
@@ -26,77 +30,116 @@ func NewMySQLResetTokenRepo(db *sql.DB) *MySQLResetTokenRepo {
 	return &MySQLResetTokenRepo{DB: db}
 }
 
-func (r *MySQLResetTokenRepo) Save(t *models.PasswordResetToken) error {
-	_, err := r.DB.Exec(
-		"INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES (?, ?, ?)",
-		t.UserID, t.Token, t.ExpiresAt,
+// hashToken はリセットトークンなど、メールで送信するトークンのSHA-256ハッシュを返します。
+// データベースにはハッシュのみを保存し、漏洩してもトークンとして使えないようにします。
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Save はトークンのハッシュを保存します。
+func (r *MySQLResetTokenRepo) Save(ctx context.Context, t *models.PasswordResetToken) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	_, err := r.DB.ExecContext(ctx,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		t.UserID, hashToken(t.Token), t.ExpiresAt,
 	)
 	return err
 }
 
-func (r *MySQLResetTokenRepo) FindByToken(token string) (*models.PasswordResetToken, error) {
-	log.Println("[FindByToken] START ----------------------------")
-	log.Println("[FindByToken] Searching token:", token)
-
-	query := "SELECT id, user_id, token, expires_at, used_at FROM password_reset_tokens WHERE token = ?"
-	log.Println("[FindByToken] SQL:", query)
-
-	row := r.DB.QueryRow(query, token)
+// FindByToken は平文のトークンに一致するリセットトークンを返します。
+func (r *MySQLResetTokenRepo) FindByToken(ctx context.Context, token string) (*models.PasswordResetToken, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	row := r.DB.QueryRowContext(ctx,
+		"SELECT id, user_id, expires_at, used_at, created_at FROM password_reset_tokens WHERE token_hash = ?",
+		hashToken(token),
+	)
 
 	var pr models.PasswordResetToken
 	var usedAt sql.NullTime
-
-	err := row.Scan(&pr.ID, &pr.UserID, &pr.Token, &pr.ExpiresAt, &usedAt)
-
-	// --- Scan エラーの詳細表示 ---
-	if err != nil {
+	if err := row.Scan(&pr.ID, &pr.UserID, &pr.ExpiresAt, &usedAt, &pr.CreatedAt); err != nil {
 		if err == sql.ErrNoRows {
-			log.Println("[FindByToken] No rows found for token:", token)
 			return nil, ErrResetTokenNotFound
 		}
-
-		log.Println("[FindByToken] SCAN ERROR:", err)
 		return nil, err
 	}
-
-	// --- 取得されたデータのログ ---
-	log.Println("[FindByToken] Found record:")
-	log.Println("    ID        =", pr.ID)
-	log.Println("    UserID    =", pr.UserID)
-	log.Println("    Token     =", pr.Token)
-	log.Println("    ExpiresAt =", pr.ExpiresAt)
-
 	if usedAt.Valid {
 		pr.UsedAt = &usedAt.Time
-		log.Println("    UsedAt    =", pr.UsedAt)
-	} else {
-		log.Println("    UsedAt    = NULL")
 	}
+	return &pr, nil
+}
 
-	log.Println("[FindByToken] END ------------------------------")
+// FindByUserID はユーザーのリセットトークン履歴を新しい順に返します。
+func (r *MySQLResetTokenRepo) FindByUserID(ctx context.Context, userID uint) ([]*models.PasswordResetToken, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT id, user_id, expires_at, used_at, created_at FROM password_reset_tokens WHERE user_id = ? ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*models.PasswordResetToken{}
+	for rows.Next() {
+		var pr models.PasswordResetToken
+		var usedAt sql.NullTime
+		if err := rows.Scan(&pr.ID, &pr.UserID, &pr.ExpiresAt, &usedAt, &pr.CreatedAt); err != nil {
+			return nil, err
+		}
+		if usedAt.Valid {
+			pr.UsedAt = &usedAt.Time
+		}
+		tokens = append(tokens, &pr)
+	}
+	return tokens, rows.Err()
+}
 
-	return &pr, nil
+// InvalidateForUser はユーザーの未使用のリセットトークンをすべて使用済みにします。
+// 新しいトークンを発行する前に呼び出し、有効なトークンが常に最新の1つだけになるようにします。
+func (r *MySQLResetTokenRepo) InvalidateForUser(ctx context.Context, userID uint) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	_, err := r.DB.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = ? AND used_at IS NULL",
+		userID,
+	)
+	return err
 }
 
-func (r *MySQLResetTokenRepo) CleanupExpired() error {
-	_, err := r.DB.Exec(`
+func (r *MySQLResetTokenRepo) CleanupExpired(ctx context.Context) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	_, err := r.DB.ExecContext(ctx, `
 		DELETE FROM password_reset_tokens
 		WHERE used_at IS NOT NULL
 		   OR expires_at < NOW()
 	`)
-	if err != nil {
-		log.Println("[CleanupExpired] ERROR:", err)
-		return err
-	}
-
-	log.Println("[CleanupExpired] Expired or used tokens cleaned")
-	return nil
+	return err
 }
 
-func (r *MySQLResetTokenRepo) MarkUsed(id uint) error {
-	_, err := r.DB.Exec(
-		"UPDATE password_reset_tokens SET used_at = NOW() WHERE id = ?",
+// MarkUsed はトークンを使用済みにします。同時に使用された場合に1回だけ成功するよう、
+// 未使用のトークンのみを更新し、更新できなかった場合は ErrResetTokenNotFound を返します。
+func (r *MySQLResetTokenRepo) MarkUsed(ctx context.Context, id uint) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	result, err := r.DB.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = NOW() WHERE id = ? AND used_at IS NULL",
 		id,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrResetTokenNotFound
+	}
+	return nil
 }
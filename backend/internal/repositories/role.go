@@ -0,0 +1,205 @@
+// Package repositories はデータベース操作を行うリポジトリを提供します。
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-sql-driver/mysql"
+
+	"go-next-todo/backend/internal/models"
+)
+
+var (
+	ErrRoleNotFound  = errors.New("role not found")
+	ErrDuplicateRole = errors.New("duplicate role")
+)
+
+type RoleRepository interface {
+	List() ([]*models.Role, error)
+	FindByName(name string) (*models.Role, error)
+	PermissionsFor(name string) ([]string, error)
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(name string) error
+	CountUsers(name string) (int, error)
+	Upsert(role *models.Role) error
+}
+
+type MySQLRoleRepo struct {
+	DB *sql.DB
+}
+
+func NewMySQLRoleRepo(db *sql.DB) *MySQLRoleRepo {
+	return &MySQLRoleRepo{DB: db}
+}
+
+// List はすべてのロールを権限付きで返します。
+func (r *MySQLRoleRepo) List() ([]*models.Role, error) {
+	rows, err := r.DB.Query("SELECT name, description, built_in, created_at, updated_at FROM roles ORDER BY built_in DESC, name")
+	if err != nil {
+		slog.Error("failed to query roles", "error", err)
+		return nil, fmt.Errorf("could not query roles: %w", err)
+	}
+	roles := []*models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, &role.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan role: %w", err)
+		}
+		roles = append(roles, &role)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating roles: %w", err)
+	}
+
+	for _, role := range roles {
+		if role.Permissions, err = r.PermissionsFor(role.Name); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// FindByName は名前でロールを検索します。
+func (r *MySQLRoleRepo) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.DB.QueryRow(
+		"SELECT name, description, built_in, created_at, updated_at FROM roles WHERE name = ?", name,
+	).Scan(&role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("could not query role: %w", err)
+	}
+	if role.Permissions, err = r.PermissionsFor(name); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// PermissionsFor はロールに付与された権限を返します。
+func (r *MySQLRoleRepo) PermissionsFor(name string) ([]string, error) {
+	rows, err := r.DB.Query("SELECT permission FROM role_permissions WHERE role_name = ? ORDER BY permission", name)
+	if err != nil {
+		return nil, fmt.Errorf("could not query role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	perms := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("could not scan role permission: %w", err)
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+// Create はロールを作成します。
+func (r *MySQLRoleRepo) Create(role *models.Role) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO roles (name, description, built_in) VALUES (?, ?, ?)", role.Name, role.Description, role.BuiltIn); err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+			return ErrDuplicateRole
+		}
+		return fmt.Errorf("could not insert role: %w", err)
+	}
+	if err := replacePermissions(tx, role.Name, role.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update はロールの説明と権限を更新します。
+func (r *MySQLRoleRepo) Update(role *models.Role) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT 1 FROM roles WHERE name = ? FOR UPDATE", role.Name).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	if _, err := tx.Exec("UPDATE roles SET description = ?, updated_at = CURRENT_TIMESTAMP WHERE name = ?", role.Description, role.Name); err != nil {
+		return fmt.Errorf("could not update role: %w", err)
+	}
+	if err := replacePermissions(tx, role.Name, role.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Upsert はロールが無ければ作成し、あれば権限を上書きします。組み込みロールの同期に使用します。
+func (r *MySQLRoleRepo) Upsert(role *models.Role) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT INTO roles (name, description, built_in) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE description = VALUES(description), built_in = VALUES(built_in)",
+		role.Name, role.Description, role.BuiltIn,
+	); err != nil {
+		return fmt.Errorf("could not upsert role: %w", err)
+	}
+	if err := replacePermissions(tx, role.Name, role.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete はロールを削除します。
+func (r *MySQLRoleRepo) Delete(name string) error {
+	res, err := r.DB.Exec("DELETE FROM roles WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("could not delete role: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// CountUsers はロールが割り当てられているユーザー数を返します。
+func (r *MySQLRoleRepo) CountUsers(name string) (int, error) {
+	var n int
+	if err := r.DB.QueryRow("SELECT COUNT(*) FROM users WHERE role = ?", name).Scan(&n); err != nil {
+		return 0, fmt.Errorf("could not count users: %w", err)
+	}
+	return n, nil
+}
+
+// replacePermissions はロールの権限をすべて置き換えます。
+func replacePermissions(tx *sql.Tx, roleName string, perms []string) error {
+	if _, err := tx.Exec("DELETE FROM role_permissions WHERE role_name = ?", roleName); err != nil {
+		return fmt.Errorf("could not clear role permissions: %w", err)
+	}
+	for _, p := range perms {
+		if _, err := tx.Exec("INSERT INTO role_permissions (role_name, permission) VALUES (?, ?)", roleName, p); err != nil {
+			return fmt.Errorf("could not insert role permission: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package repositories はデータベース操作を行うリポジトリを提供します。
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go-next-todo/backend/internal/models"
+)
+
+type SecurityEventRepository interface {
+	Create(event *models.SecurityEvent) error
+	List(filter models.SecurityEventFilter) ([]*models.SecurityEvent, int, error)
+	ListForUser(userID, limit int) ([]*models.SecurityEvent, error)
+}
+
+type MySQLSecurityEventRepo struct {
+	DB *sql.DB
+}
+
+func NewMySQLSecurityEventRepo(db *sql.DB) *MySQLSecurityEventRepo {
+	return &MySQLSecurityEventRepo{DB: db}
+}
+
+// securityEventColumns はセキュリティイベント取得時に SELECT するカラムの一覧です。scanSecurityEvent と順序を合わせてください。
+const securityEventColumns = "id, event_type, user_id, actor_id, ip_address, user_agent, metadata, created_at"
+
+// scanSecurityEvent は securityEventColumns の順序で1行を読み取ります。
+func scanSecurityEvent(row rowScanner) (*models.SecurityEvent, error) {
+	var e models.SecurityEvent
+	var userID, actorID sql.NullInt64
+	var metadata []byte
+	if err := row.Scan(&e.ID, &e.Type, &userID, &actorID, &e.IPAddress, &e.UserAgent, &metadata, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		e.UserID = &id
+	}
+	if actorID.Valid {
+		id := int(actorID.Int64)
+		e.ActorID = &id
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &e.Metadata); err != nil {
+			return nil, fmt.Errorf("could not decode event metadata: %w", err)
+		}
+	}
+	return &e, nil
+}
+
+// Create はセキュリティイベントを保存します。
+func (r *MySQLSecurityEventRepo) Create(e *models.SecurityEvent) error {
+	var metadata []byte
+	if len(e.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(e.Metadata); err != nil {
+			return fmt.Errorf("could not encode event metadata: %w", err)
+		}
+	}
+	result, err := r.DB.Exec(
+		"INSERT INTO security_events (event_type, user_id, actor_id, ip_address, user_agent, metadata) VALUES (?, ?, ?, ?, ?, ?)",
+		e.Type, e.UserID, e.ActorID, e.IPAddress, e.UserAgent, metadata,
+	)
+	if err != nil {
+		return fmt.Errorf("could not insert security event: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("could not get last insert ID: %w", err)
+	}
+	e.ID = id
+	return nil
+}
+
+// List は検索条件に一致するセキュリティイベントを新しい順にページングして返します。2つ目の戻り値は条件に一致する総件数です。
+func (r *MySQLSecurityEventRepo) List(filter models.SecurityEventFilter) ([]*models.SecurityEvent, int, error) {
+	var conds []string
+	var args []interface{}
+	if filter.UserID != 0 {
+		conds = append(conds, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.ActorID != 0 {
+		conds = append(conds, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Type != "" {
+		conds = append(conds, "event_type = ?")
+		args = append(args, filter.Type)
+	}
+	if filter.IPAddress != "" {
+		conds = append(conds, "ip_address = ?")
+		args = append(args, filter.IPAddress)
+	}
+	if !filter.Since.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, filter.Until)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.DB.QueryRow("SELECT COUNT(*) FROM security_events"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("could not count security events: %w", err)
+	}
+
+	query := "SELECT " + securityEventColumns + " FROM security_events" + where + " ORDER BY id DESC LIMIT ? OFFSET ?"
+	events, err := r.query(query, append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// ListForUser はユーザーに関するセキュリティイベントを新しい順に最大 limit 件返します。
+func (r *MySQLSecurityEventRepo) ListForUser(userID, limit int) ([]*models.SecurityEvent, error) {
+	return r.query("SELECT "+securityEventColumns+" FROM security_events WHERE user_id = ? ORDER BY id DESC LIMIT ?", userID, limit)
+}
+
+func (r *MySQLSecurityEventRepo) query(query string, args ...interface{}) ([]*models.SecurityEvent, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query security events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*models.SecurityEvent{}
+	for rows.Next() {
+		e, err := scanSecurityEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan security event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating security events: %w", err)
+	}
+	return events, nil
+}
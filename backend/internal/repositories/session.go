@@ -0,0 +1,139 @@
+// Package repositories はデータベース操作を行うリポジトリを提供します。
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-next-todo/backend/internal/models"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	FindByID(id string) (*models.Session, error)
+	ListActiveForUser(userID int) ([]*models.Session, error)
+	Touch(id, ipAddress string, at time.Time) error
+	Revoke(userID int, id string) error
+	CleanupExpired() error
+}
+
+type MySQLSessionRepo struct {
+	DB *sql.DB
+}
+
+func NewMySQLSessionRepo(db *sql.DB) *MySQLSessionRepo {
+	return &MySQLSessionRepo{DB: db}
+}
+
+// sessionColumns はセッション取得時に SELECT するカラムの一覧です。scanSession と順序を合わせてください。
+const sessionColumns = "id, user_id, token_version, device, ip_address, user_agent, created_at, last_seen_at, expires_at, revoked_at, impersonator_id"
+
+// scanSession は sessionColumns の順序で1行を読み取ります。
+func scanSession(row rowScanner) (*models.Session, error) {
+	var s models.Session
+	var revokedAt sql.NullTime
+	var impersonatorID sql.NullInt64
+	if err := row.Scan(&s.ID, &s.UserID, &s.TokenVersion, &s.Device, &s.IPAddress, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &revokedAt, &impersonatorID); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	if impersonatorID.Valid {
+		id := int(impersonatorID.Int64)
+		s.ImpersonatorID = &id
+	}
+	return &s, nil
+}
+
+// Create はセッションを保存します。
+func (r *MySQLSessionRepo) Create(s *models.Session) error {
+	_, err := r.DB.Exec(
+		"INSERT INTO sessions (id, user_id, token_version, device, ip_address, user_agent, last_seen_at, expires_at, impersonator_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		s.ID, s.UserID, s.TokenVersion, s.Device, s.IPAddress, s.UserAgent, s.LastSeenAt, s.ExpiresAt, s.ImpersonatorID,
+	)
+	if err != nil {
+		return fmt.Errorf("could not create session: %w", err)
+	}
+	return nil
+}
+
+// FindByID はIDでセッションを検索します。失効・期限切れのセッションも返します。
+func (r *MySQLSessionRepo) FindByID(id string) (*models.Session, error) {
+	s, err := scanSession(r.DB.QueryRow("SELECT "+sessionColumns+" FROM sessions WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("could not query session: %w", err)
+	}
+	return s, nil
+}
+
+// ListActiveForUser はユーザーの有効なセッションを最終アクセスの新しい順に返します。
+// パスワード変更などで token_version が変わる前に発行されたセッションは含みません。
+func (r *MySQLSessionRepo) ListActiveForUser(userID int) ([]*models.Session, error) {
+	rows, err := r.DB.Query(`
+		SELECT s.id, s.user_id, s.token_version, s.device, s.ip_address, s.user_agent, s.created_at, s.last_seen_at, s.expires_at, s.revoked_at, s.impersonator_id
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id AND u.token_version = s.token_version
+		WHERE s.user_id = ? AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		ORDER BY s.last_seen_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// Touch はセッションの最終アクセス日時とIPアドレスを更新します。
+func (r *MySQLSessionRepo) Touch(id, ipAddress string, at time.Time) error {
+	_, err := r.DB.Exec("UPDATE sessions SET last_seen_at = ?, ip_address = ? WHERE id = ?", at, ipAddress, id)
+	if err != nil {
+		return fmt.Errorf("could not touch session: %w", err)
+	}
+	return nil
+}
+
+// Revoke はユーザーのセッションを失効させます。他のユーザーのセッションや失効済みのセッションは ErrSessionNotFound になります。
+func (r *MySQLSessionRepo) Revoke(userID int, id string) error {
+	result, err := r.DB.Exec(
+		"UPDATE sessions SET revoked_at = NOW() WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("could not revoke session: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// CleanupExpired は期限切れのセッションを削除します。
+func (r *MySQLSessionRepo) CleanupExpired() error {
+	_, err := r.DB.Exec("DELETE FROM sessions WHERE expires_at < NOW()")
+	return err
+}
@@ -0,0 +1,519 @@
+// Package storetest は repositories の TodoStore・UserStore・ResetTokenRepository の実装が満たすべき振る舞いを確認する共通テストです。
+//
+// MySQL とメモリ上の実装の両方をこのテストで確認し、サービスのテストでどちらを使っても同じ結果になるようにします。
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+)
+
+// Backend はテストするストアの組です。3つのストアは同じデータを共有している必要があります。
+type Backend struct {
+	Users       repositories.UserStore
+	Todos       repositories.TodoStore
+	ResetTokens repositories.ResetTokenRepository
+	// Workspaces はTodoを作成できる2つのワークスペースのIDです。
+	Workspaces [2]int
+}
+
+// NewBackend はテストごとに、このパッケージのテストが作成するデータを含まない Backend を作成します。
+// 後片付けが必要な場合は t.Cleanup で登録してください。
+type NewBackend func(t *testing.T) Backend
+
+// emailDomain はテストで作成するユーザーのメールアドレスのドメインです。
+// 既存のユーザーがいるデータベースでも、List の検索条件でテストのユーザーだけを絞り込めるようにします。
+const emailDomain = "@storetest.example.com"
+
+// Run は TodoStore・UserStore・ResetTokenRepository の共通テストを実行します。
+func Run(t *testing.T, newBackend NewBackend) {
+	t.Run("TodoStore", func(t *testing.T) { testTodoStore(t, newBackend) })
+	t.Run("UserStore", func(t *testing.T) { testUserStore(t, newBackend) })
+	t.Run("ResetTokenRepository", func(t *testing.T) { testResetTokens(t, newBackend) })
+}
+
+func createUser(t *testing.T, b Backend, name string) *models.User {
+	t.Helper()
+	u, err := b.Users.Create(context.Background(), &models.User{
+		Username:     "storetest_" + name,
+		Email:        name + emailDomain,
+		PasswordHash: "hash-" + name,
+		Role:         models.RoleUser,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, u.ID)
+	return u
+}
+
+func createTodo(t *testing.T, b Backend, workspaceID int, user *models.User, title string) *models.Todo {
+	t.Helper()
+	todo, err := b.Todos.Create(context.Background(), &models.Todo{UserID: user.ID, WorkspaceID: workspaceID, Title: title})
+	require.NoError(t, err)
+	return todo
+}
+
+func titles(todos []*models.Todo) []string {
+	out := make([]string, len(todos))
+	for i, t := range todos {
+		out[i] = t.Title
+	}
+	return out
+}
+
+func testTodoStore(t *testing.T, newBackend NewBackend) {
+	ctx := context.Background()
+
+	t.Run("Creates and finds a todo", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+
+		created, err := b.Todos.Create(ctx, &models.Todo{UserID: alice.ID, WorkspaceID: b.Workspaces[0], Title: "Write tests", Completed: true})
+		require.NoError(t, err)
+		assert.NotZero(t, created.ID)
+		assert.Equal(t, "Write tests", created.Title)
+		assert.True(t, created.Completed)
+		assert.False(t, created.CreatedAt.IsZero())
+
+		found, err := b.Todos.FindByID(ctx, b.Workspaces[0], created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, found.ID)
+		assert.Equal(t, alice.ID, found.UserID)
+		assert.Equal(t, b.Workspaces[0], found.WorkspaceID)
+	})
+
+	t.Run("Hides todos of other workspaces", func(t *testing.T) {
+		b := newBackend(t)
+		todo := createTodo(t, b, b.Workspaces[0], createUser(t, b, "alice"), "Private")
+
+		_, err := b.Todos.FindByID(ctx, b.Workspaces[1], todo.ID)
+		assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+		_, err = b.Todos.FindByID(ctx, b.Workspaces[0], todo.ID+1000)
+		assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+		_, err = b.Todos.Update(ctx, b.Workspaces[1], todo.ID, &models.Todo{Title: "Changed"})
+		assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+		assert.ErrorIs(t, b.Todos.Delete(ctx, b.Workspaces[1], todo.ID), repositories.ErrTodoNotFound)
+	})
+
+	t.Run("Lists todos by workspace and user", func(t *testing.T) {
+		b := newBackend(t)
+		alice, bob := createUser(t, b, "alice"), createUser(t, b, "bob")
+		createTodo(t, b, b.Workspaces[0], alice, "alice-1")
+		createTodo(t, b, b.Workspaces[0], bob, "bob-1")
+		createTodo(t, b, b.Workspaces[1], alice, "alice-2")
+
+		all, err := b.Todos.FindAll(ctx, b.Workspaces[0])
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"alice-1", "bob-1"}, titles(all))
+
+		mine, err := b.Todos.FindByUserID(ctx, b.Workspaces[0], alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice-1"}, titles(mine))
+
+		everywhere, err := b.Todos.FindByUserIDAcrossWorkspaces(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"alice-1", "alice-2"}, titles(everywhere))
+	})
+
+	t.Run("Returns empty lists instead of nil", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+
+		todos, err := b.Todos.FindByUserID(ctx, b.Workspaces[1], alice.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, todos)
+		assert.Empty(t, todos)
+	})
+
+	t.Run("Updates and deletes a todo", func(t *testing.T) {
+		b := newBackend(t)
+		todo := createTodo(t, b, b.Workspaces[0], createUser(t, b, "alice"), "Draft")
+
+		updated, err := b.Todos.Update(ctx, b.Workspaces[0], todo.ID, &models.Todo{Title: "Final", Completed: true})
+		require.NoError(t, err)
+		assert.Equal(t, "Final", updated.Title)
+		assert.True(t, updated.Completed)
+
+		require.NoError(t, b.Todos.Delete(ctx, b.Workspaces[0], todo.ID))
+		_, err = b.Todos.FindByID(ctx, b.Workspaces[0], todo.ID)
+		assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+		assert.ErrorIs(t, b.Todos.Delete(ctx, b.Workspaces[0], todo.ID), repositories.ErrTodoNotFound)
+	})
+
+	t.Run("Does not share returned todos", func(t *testing.T) {
+		b := newBackend(t)
+		todo := createTodo(t, b, b.Workspaces[0], createUser(t, b, "alice"), "Original")
+
+		todo.Title = "Changed by caller"
+		found, err := b.Todos.FindByID(ctx, b.Workspaces[0], todo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Original", found.Title)
+	})
+
+	t.Run("Handles concurrent writes", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := b.Todos.Create(ctx, &models.Todo{UserID: alice.ID, WorkspaceID: b.Workspaces[0], Title: fmt.Sprintf("todo-%d", i)})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		todos, err := b.Todos.FindByUserID(ctx, b.Workspaces[0], alice.ID)
+		require.NoError(t, err)
+		assert.Len(t, todos, 10)
+	})
+
+	t.Run("Stops when the context is cancelled", func(t *testing.T) {
+		b := newBackend(t)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := b.Todos.FindAll(cancelled, b.Workspaces[0])
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func testUserStore(t *testing.T, newBackend NewBackend) {
+	ctx := context.Background()
+
+	t.Run("Creates and finds a user", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+
+		byID, err := b.Users.FindByID(ctx, uint(alice.ID))
+		require.NoError(t, err)
+		assert.Equal(t, "storetest_alice", byID.Username)
+		assert.Equal(t, "alice"+emailDomain, byID.Email)
+		assert.Equal(t, "hash-alice", byID.PasswordHash)
+		assert.Equal(t, models.RoleUser, byID.Role)
+		assert.Nil(t, byID.EmailVerifiedAt)
+		assert.Nil(t, byID.DeletionAt)
+		assert.Nil(t, byID.DisabledAt)
+
+		byEmail, err := b.Users.FindByEmail(ctx, "alice"+emailDomain)
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, byEmail.ID)
+	})
+
+	t.Run("Reports missing and duplicate users", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+
+		_, err := b.Users.FindByEmail(ctx, "nobody"+emailDomain)
+		assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+		_, err = b.Users.FindByID(ctx, uint(alice.ID)+1000)
+		assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+		_, err = b.Users.Create(ctx, &models.User{Username: "storetest_other", Email: alice.Email, PasswordHash: "x", Role: models.RoleUser})
+		assert.ErrorIs(t, err, repositories.ErrDuplicateEmail)
+		_, err = b.Users.Create(ctx, &models.User{Username: alice.Username, Email: "other" + emailDomain, PasswordHash: "x", Role: models.RoleUser})
+		assert.ErrorIs(t, err, repositories.ErrDuplicateUsername)
+	})
+
+	t.Run("Changing the password revokes tokens", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+
+		require.NoError(t, b.Users.UpdatePassword(ctx, uint(alice.ID), "new-hash"))
+		u, err := b.Users.FindByID(ctx, uint(alice.ID))
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", u.PasswordHash)
+		assert.Equal(t, alice.TokenVersion+1, u.TokenVersion)
+
+		assert.Error(t, b.Users.UpdatePassword(ctx, uint(alice.ID)+1000, "new-hash"))
+	})
+
+	t.Run("Rehashing keeps tokens and skips concurrent changes", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+
+		require.NoError(t, b.Users.UpdatePasswordHash(ctx, uint(alice.ID), "stale-hash", "rehashed"))
+		u, err := b.Users.FindByID(ctx, uint(alice.ID))
+		require.NoError(t, err)
+		assert.Equal(t, "hash-alice", u.PasswordHash)
+
+		require.NoError(t, b.Users.UpdatePasswordHash(ctx, uint(alice.ID), "hash-alice", "rehashed"))
+		u, err = b.Users.FindByID(ctx, uint(alice.ID))
+		require.NoError(t, err)
+		assert.Equal(t, "rehashed", u.PasswordHash)
+		assert.Equal(t, alice.TokenVersion, u.TokenVersion)
+	})
+
+	t.Run("Updates profile fields", func(t *testing.T) {
+		b := newBackend(t)
+		alice, bob := createUser(t, b, "alice"), createUser(t, b, "bob")
+		id := uint(alice.ID)
+
+		require.NoError(t, b.Users.MarkEmailVerified(ctx, id))
+		require.NoError(t, b.Users.UpdateUsername(ctx, id, "storetest_alice2"))
+		assert.ErrorIs(t, b.Users.UpdateUsername(ctx, id, bob.Username), repositories.ErrDuplicateUsername)
+		require.NoError(t, b.Users.UpdateEmail(ctx, id, "alice2"+emailDomain))
+		assert.ErrorIs(t, b.Users.UpdateEmail(ctx, id, bob.Email), repositories.ErrDuplicateEmail)
+
+		u, err := b.Users.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "storetest_alice2", u.Username)
+		assert.Equal(t, "alice2"+emailDomain, u.Email)
+		assert.NotNil(t, u.EmailVerifiedAt)
+
+		assert.ErrorIs(t, b.Users.MarkEmailVerified(ctx, id+1000), repositories.ErrUserNotFound)
+	})
+
+	t.Run("Schedules and cancels deletion", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+		id := uint(alice.ID)
+
+		require.NoError(t, b.Users.ScheduleDeletion(ctx, id, time.Now().Add(24*time.Hour)))
+		u, err := b.Users.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.NotNil(t, u.DeletionAt)
+		assert.Equal(t, alice.TokenVersion+1, u.TokenVersion)
+
+		require.NoError(t, b.Users.CancelDeletion(ctx, id))
+		u, err = b.Users.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Nil(t, u.DeletionAt)
+	})
+
+	t.Run("Deletes users with their data", func(t *testing.T) {
+		b := newBackend(t)
+		alice, bob := createUser(t, b, "alice"), createUser(t, b, "bob")
+		createTodo(t, b, b.Workspaces[0], alice, "alice-1")
+		createTodo(t, b, b.Workspaces[0], bob, "bob-1")
+		require.NoError(t, b.ResetTokens.Save(ctx, &models.PasswordResetToken{UserID: uint(alice.ID), Token: "alice-token", ExpiresAt: time.Now().Add(time.Hour)}))
+
+		require.NoError(t, b.Users.Delete(ctx, uint(alice.ID)))
+		_, err := b.Users.FindByID(ctx, uint(alice.ID))
+		assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+		assert.ErrorIs(t, b.Users.Delete(ctx, uint(alice.ID)), repositories.ErrUserNotFound)
+
+		todos, err := b.Todos.FindAll(ctx, b.Workspaces[0])
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob-1"}, titles(todos))
+		_, err = b.ResetTokens.FindByToken(ctx, "alice-token")
+		assert.ErrorIs(t, err, repositories.ErrResetTokenNotFound)
+	})
+
+	t.Run("Purges users whose deletion is due", func(t *testing.T) {
+		b := newBackend(t)
+		due, later, active := createUser(t, b, "due"), createUser(t, b, "later"), createUser(t, b, "active")
+		require.NoError(t, b.Users.ScheduleDeletion(ctx, uint(due.ID), time.Now().Add(-time.Hour)))
+		require.NoError(t, b.Users.ScheduleDeletion(ctx, uint(later.ID), time.Now().Add(time.Hour)))
+
+		deleted, err := b.Users.DeleteScheduledBefore(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		_, err = b.Users.FindByID(ctx, uint(due.ID))
+		assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+		for _, u := range []*models.User{later, active} {
+			_, err = b.Users.FindByID(ctx, uint(u.ID))
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("Lists users with filters and paging", func(t *testing.T) {
+		b := newBackend(t)
+		var created []*models.User
+		for _, name := range []string{"carol", "dave", "erin"} {
+			created = append(created, createUser(t, b, name))
+		}
+		require.NoError(t, b.Users.UpdateRole(ctx, uint(created[1].ID), models.RoleAdmin))
+
+		users, total, err := b.Users.List(ctx, models.UserListFilter{Query: emailDomain, Page: 1, PerPage: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, users, 2)
+		assert.Equal(t, created[0].ID, users[0].ID)
+		assert.Equal(t, created[1].ID, users[1].ID)
+
+		users, _, err = b.Users.List(ctx, models.UserListFilter{Query: emailDomain, Page: 2, PerPage: 2})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, created[2].ID, users[0].ID)
+
+		users, total, err = b.Users.List(ctx, models.UserListFilter{Query: emailDomain, Role: models.RoleAdmin, Page: 1, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, users, 1)
+		assert.Equal(t, models.RoleAdmin, users[0].Role)
+
+		users, total, err = b.Users.List(ctx, models.UserListFilter{Query: "no-such-user", Page: 1, PerPage: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.NotNil(t, users)
+	})
+
+	t.Run("Matches wildcard characters literally", func(t *testing.T) {
+		b := newBackend(t)
+		underscore := createUser(t, b, "a_b")
+		createUser(t, b, "axb")
+		percent := createUser(t, b, "100%")
+
+		users, total, err := b.Users.List(ctx, models.UserListFilter{Query: "a_b", Page: 1, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, users, 1)
+		assert.Equal(t, underscore.ID, users[0].ID)
+
+		users, total, err = b.Users.List(ctx, models.UserListFilter{Query: "%", Page: 1, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, users, 1)
+		assert.Equal(t, percent.ID, users[0].ID)
+
+		_, total, err = b.Users.List(ctx, models.UserListFilter{Query: `\`, Page: 1, PerPage: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("Rejects unknown roles", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+		assert.ErrorIs(t, b.Users.UpdateRole(ctx, uint(alice.ID), "no-such-role"), repositories.ErrRoleNotFound)
+	})
+
+	t.Run("Disabling revokes tokens", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+		id := uint(alice.ID)
+
+		require.NoError(t, b.Users.SetDisabled(ctx, id, true))
+		u, err := b.Users.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.NotNil(t, u.DisabledAt)
+		assert.Equal(t, alice.TokenVersion+1, u.TokenVersion)
+
+		require.NoError(t, b.Users.SetDisabled(ctx, id, false))
+		u, err = b.Users.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Nil(t, u.DisabledAt)
+	})
+
+	t.Run("Resets the password with a token once", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+		id := uint(alice.ID)
+		require.NoError(t, b.ResetTokens.Save(ctx, &models.PasswordResetToken{UserID: id, Token: "valid", ExpiresAt: time.Now().Add(time.Hour)}))
+		require.NoError(t, b.ResetTokens.Save(ctx, &models.PasswordResetToken{UserID: id, Token: "expired", ExpiresAt: time.Now().Add(-time.Hour)}))
+		valid, err := b.ResetTokens.FindByToken(ctx, "valid")
+		require.NoError(t, err)
+		expired, err := b.ResetTokens.FindByToken(ctx, "expired")
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, b.Users.ResetPasswordWithToken(ctx, expired.ID, id, "reset-hash"), repositories.ErrResetTokenNotFound)
+		require.NoError(t, b.Users.ResetPasswordWithToken(ctx, valid.ID, id, "reset-hash"))
+		assert.ErrorIs(t, b.Users.ResetPasswordWithToken(ctx, valid.ID, id, "again"), repositories.ErrResetTokenNotFound)
+
+		u, err := b.Users.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "reset-hash", u.PasswordHash)
+		assert.Equal(t, alice.TokenVersion+1, u.TokenVersion)
+
+		used, err := b.ResetTokens.FindByToken(ctx, "valid")
+		require.NoError(t, err)
+		assert.NotNil(t, used.UsedAt)
+	})
+
+	t.Run("Stops when the context is cancelled", func(t *testing.T) {
+		b := newBackend(t)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := b.Users.FindByEmail(cancelled, "alice"+emailDomain)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func testResetTokens(t *testing.T, newBackend NewBackend) {
+	ctx := context.Background()
+
+	save := func(t *testing.T, b Backend, user *models.User, token string, expiresIn time.Duration) *models.PasswordResetToken {
+		t.Helper()
+		require.NoError(t, b.ResetTokens.Save(ctx, &models.PasswordResetToken{UserID: uint(user.ID), Token: token, ExpiresAt: time.Now().Add(expiresIn)}))
+		saved, err := b.ResetTokens.FindByToken(ctx, token)
+		require.NoError(t, err)
+		return saved
+	}
+
+	t.Run("Finds tokens by value and user", func(t *testing.T) {
+		b := newBackend(t)
+		alice, bob := createUser(t, b, "alice"), createUser(t, b, "bob")
+		first := save(t, b, alice, "alice-1", time.Hour)
+		save(t, b, alice, "alice-2", time.Hour)
+		save(t, b, bob, "bob-1", time.Hour)
+
+		assert.Equal(t, uint(alice.ID), first.UserID)
+		assert.Nil(t, first.UsedAt)
+		assert.Empty(t, first.Token, "only the hash of the token is stored")
+
+		_, err := b.ResetTokens.FindByToken(ctx, "unknown")
+		assert.ErrorIs(t, err, repositories.ErrResetTokenNotFound)
+
+		tokens, err := b.ResetTokens.FindByUserID(ctx, uint(alice.ID))
+		require.NoError(t, err)
+		assert.Len(t, tokens, 2)
+	})
+
+	t.Run("Uses a token only once", func(t *testing.T) {
+		b := newBackend(t)
+		token := save(t, b, createUser(t, b, "alice"), "once", time.Hour)
+
+		require.NoError(t, b.ResetTokens.MarkUsed(ctx, token.ID))
+		assert.ErrorIs(t, b.ResetTokens.MarkUsed(ctx, token.ID), repositories.ErrResetTokenNotFound)
+	})
+
+	t.Run("Invalidates the user's unused tokens", func(t *testing.T) {
+		b := newBackend(t)
+		alice, bob := createUser(t, b, "alice"), createUser(t, b, "bob")
+		save(t, b, alice, "alice-1", time.Hour)
+		bobs := save(t, b, bob, "bob-1", time.Hour)
+
+		require.NoError(t, b.ResetTokens.InvalidateForUser(ctx, uint(alice.ID)))
+		invalidated, err := b.ResetTokens.FindByToken(ctx, "alice-1")
+		require.NoError(t, err)
+		assert.NotNil(t, invalidated.UsedAt)
+
+		untouched, err := b.ResetTokens.FindByToken(ctx, "bob-1")
+		require.NoError(t, err)
+		assert.Nil(t, untouched.UsedAt)
+		assert.Equal(t, bobs.ID, untouched.ID)
+	})
+
+	t.Run("Cleans up used and expired tokens", func(t *testing.T) {
+		b := newBackend(t)
+		alice := createUser(t, b, "alice")
+		used := save(t, b, alice, "used", time.Hour)
+		require.NoError(t, b.ResetTokens.MarkUsed(ctx, used.ID))
+		save(t, b, alice, "expired", -time.Hour)
+		save(t, b, alice, "valid", time.Hour)
+
+		require.NoError(t, b.ResetTokens.CleanupExpired(ctx))
+		tokens, err := b.ResetTokens.FindByUserID(ctx, uint(alice.ID))
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		_, err = b.ResetTokens.FindByToken(ctx, "valid")
+		assert.NoError(t, err)
+	})
+}
@@ -2,14 +2,26 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 
+	"go-next-todo/backend/internal/logging"
 	"go-next-todo/backend/internal/models"
 )
 
+// TodoStore はTodoを保存するストアです。MySQL の TodoRepository とメモリ上の MemoryTodoStore があります。
+type TodoStore interface {
+	Create(ctx context.Context, t *models.Todo) (*models.Todo, error)
+	FindAll(ctx context.Context, workspaceID int) ([]*models.Todo, error)
+	FindByID(ctx context.Context, workspaceID, id int) (*models.Todo, error)
+	FindByUserID(ctx context.Context, workspaceID, userID int) ([]*models.Todo, error)
+	FindByUserIDAcrossWorkspaces(ctx context.Context, userID int) ([]*models.Todo, error)
+	Update(ctx context.Context, workspaceID, id int, t *models.Todo) (*models.Todo, error)
+	Delete(ctx context.Context, workspaceID, id int) error
+}
+
 // TodoRepository はデータベース操作を行うための構造体です。
 type TodoRepository struct {
 	DB *sql.DB
@@ -26,13 +38,15 @@ var ErrTodoNotFound = errors.New("todo not found")
 // ErrTodoForbidden はTODOへのアクセスが禁止されている場合のエラーです。
 var ErrTodoForbidden = errors.New("todo access forbidden")
 
-// Create は新しいTodoタスクをデータベースに挿入します。
-func (r *TodoRepository) Create(t *models.Todo) (*models.Todo, error) {
-	query := "INSERT INTO todos (user_id, title, completed) VALUES (?, ?, ?)" // 💡 user_id を追加
+// Create は新しいTodoタスクをデータベースに挿入します。t.WorkspaceID のワークスペースに作成されます。
+func (r *TodoRepository) Create(ctx context.Context, t *models.Todo) (*models.Todo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "INSERT INTO todos (user_id, workspace_id, title, completed) VALUES (?, ?, ?, ?)" // 💡 user_id を追加
 
-	result, err := r.DB.Exec(query, t.UserID, t.Title, t.Completed) // 💡 t.UserID を追加
+	result, err := r.DB.ExecContext(ctx, query, t.UserID, t.WorkspaceID, t.Title, t.Completed) // 💡 t.UserID を追加
 	if err != nil {
-		log.Printf("Failed to insert todo: %v", err)
+		logging.FromContext(ctx).Error("failed to insert todo", "error", err)
 		return nil, fmt.Errorf("could not insert todo: %w", err)
 	}
 
@@ -42,7 +56,7 @@ func (r *TodoRepository) Create(t *models.Todo) (*models.Todo, error) {
 	}
 
 	// 💡 挿入されたTODOをDBから取得し直すことで、正確な created_at/updated_at を反映させる
-	createdTodo, err := r.FindByID(int(id))
+	createdTodo, err := r.FindByID(ctx, t.WorkspaceID, int(id))
 	if err != nil {
 		return nil, fmt.Errorf("could not find created todo: %w", err)
 	}
@@ -50,13 +64,15 @@ func (r *TodoRepository) Create(t *models.Todo) (*models.Todo, error) {
 	return createdTodo, nil
 }
 
-// FindAll はすべてのTodoタスクをデータベースから取得します。
-func (r *TodoRepository) FindAll() ([]*models.Todo, error) {
-	query := "SELECT id, user_id, title, completed, created_at, updated_at FROM todos ORDER BY created_at DESC" // 💡 user_id, updated_at を追加
+// FindAll はワークスペース内のすべてのTodoタスクをデータベースから取得します。
+func (r *TodoRepository) FindAll(ctx context.Context, workspaceID int) ([]*models.Todo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "SELECT id, user_id, workspace_id, title, completed, created_at, updated_at FROM todos WHERE workspace_id = ? ORDER BY created_at DESC" // 💡 user_id, updated_at を追加
 
-	rows, err := r.DB.Query(query)
+	rows, err := r.DB.QueryContext(ctx, query, workspaceID)
 	if err != nil {
-		log.Printf("Failed to query todos: %v", err)
+		logging.FromContext(ctx).Error("failed to query todos", "error", err)
 		return nil, fmt.Errorf("could not query todos: %w", err)
 	}
 	defer rows.Close()
@@ -64,10 +80,10 @@ func (r *TodoRepository) FindAll() ([]*models.Todo, error) {
 	var todos []*models.Todo
 	for rows.Next() {
 		var t models.Todo
-		err := rows.Scan(&t.ID, &t.UserID, &t.Title, &t.Completed, &t.CreatedAt, &t.UpdatedAt) // 💡 t.UserID, t.UpdatedAt を追加
+		err := rows.Scan(&t.ID, &t.UserID, &t.WorkspaceID, &t.Title, &t.Completed, &t.CreatedAt, &t.UpdatedAt) // 💡 t.UserID, t.UpdatedAt を追加
 
 		if err != nil {
-			log.Printf("Failed to scan todo: %v", err)
+			logging.FromContext(ctx).Error("failed to scan todo", "error", err)
 			return nil, fmt.Errorf("could not scan todo: %w", err)
 		}
 		todos = append(todos, &t)
@@ -85,42 +101,66 @@ func (r *TodoRepository) FindAll() ([]*models.Todo, error) {
 	return todos, nil
 }
 
-// FindByID は指定されたIDのTodoタスクをデータベースから取得します。
-func (r *TodoRepository) FindByID(id int) (*models.Todo, error) {
-	query := "SELECT id, user_id, title, completed, created_at, updated_at FROM todos WHERE id = ?" // 💡 user_id, updated_at を追加
+// FindByID はワークスペース内の指定されたIDのTodoタスクをデータベースから取得します。
+// 別のワークスペースのTodoは ErrTodoNotFound になります。
+func (r *TodoRepository) FindByID(ctx context.Context, workspaceID, id int) (*models.Todo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "SELECT id, user_id, workspace_id, title, completed, created_at, updated_at FROM todos WHERE id = ? AND workspace_id = ?" // 💡 user_id, updated_at を追加
 
 	var t models.Todo
-	err := r.DB.QueryRow(query, id).Scan(&t.ID, &t.UserID, &t.Title, &t.Completed, &t.CreatedAt, &t.UpdatedAt) // 💡 t.UserID, t.UpdatedAt を追加
+	err := r.DB.QueryRowContext(ctx, query, id, workspaceID).Scan(&t.ID, &t.UserID, &t.WorkspaceID, &t.Title, &t.Completed, &t.CreatedAt, &t.UpdatedAt) // 💡 t.UserID, t.UpdatedAt を追加
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrTodoNotFound
 		}
-		log.Printf("Failed to query todo by ID: %v", err)
+		logging.FromContext(ctx).Error("failed to query todo by ID", "error", err)
 		return nil, fmt.Errorf("could not query todo: %w", err)
 	}
 
 	return &t, nil
 }
 
-func (r *TodoRepository) FindByUserID(userID int) ([]*models.Todo, error) { // FindAllと同様にポインタのスライスを返すように変更
-	rows, err := r.DB.Query("SELECT id, user_id, title, completed, created_at, updated_at FROM todos WHERE user_id = ? ORDER BY created_at DESC", userID)
+// FindByUserID はワークスペース内でユーザーが作成したTodoタスクを取得します。
+func (r *TodoRepository) FindByUserID(ctx context.Context, workspaceID, userID int) ([]*models.Todo, error) { // FindAllと同様にポインタのスライスを返すように変更
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, user_id, workspace_id, title, completed, created_at, updated_at FROM todos WHERE workspace_id = ? AND user_id = ? ORDER BY created_at DESC", workspaceID, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to query todos by user ID", "error", err)
+		return nil, fmt.Errorf("could not query todos by user ID: %w", err)
+	}
+	return scanTodos(ctx, rows)
+}
+
+// FindByUserIDAcrossWorkspaces はすべてのワークスペースからユーザーが作成したTodoタスクを取得します。
+// 個人データのエクスポート専用で、通常のTodo操作では使用しないでください。
+func (r *TodoRepository) FindByUserIDAcrossWorkspaces(ctx context.Context, userID int) ([]*models.Todo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, user_id, workspace_id, title, completed, created_at, updated_at FROM todos WHERE user_id = ? ORDER BY created_at DESC", userID)
 	if err != nil {
-		log.Printf("Failed to query todos by user ID: %v", err)
+		logging.FromContext(ctx).Error("failed to query todos by user ID", "error", err)
 		return nil, fmt.Errorf("could not query todos by user ID: %w", err)
 	}
+	return scanTodos(ctx, rows)
+}
+
+// scanTodos は rows からTodoを読み取り、rows を閉じます。
+func scanTodos(ctx context.Context, rows *sql.Rows) ([]*models.Todo, error) {
 	defer rows.Close()
 
 	var todos []*models.Todo // ポインタのスライス
 	for rows.Next() {
 		var t models.Todo
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Title, &t.Completed, &t.CreatedAt, &t.UpdatedAt); err != nil {
-			log.Printf("Failed to scan todo by user ID: %v", err)
+		if err := rows.Scan(&t.ID, &t.UserID, &t.WorkspaceID, &t.Title, &t.Completed, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			logging.FromContext(ctx).Error("failed to scan todo by user ID", "error", err)
 			return nil, fmt.Errorf("could not scan todo by user ID: %w", err)
 		}
 		todos = append(todos, &t) // アドレスをappend
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating todos by user ID: %w", err)
 	}
 
@@ -132,13 +172,15 @@ func (r *TodoRepository) FindByUserID(userID int) ([]*models.Todo, error) { // F
 	return todos, nil
 }
 
-// Update は指定されたIDのTodoタスクを更新します。
-func (r *TodoRepository) Update(id int, t *models.Todo) (*models.Todo, error) {
-	query := "UPDATE todos SET title = ?, completed = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?" // 💡 updated_at を追加
+// Update はワークスペース内の指定されたIDのTodoタスクを更新します。
+func (r *TodoRepository) Update(ctx context.Context, workspaceID, id int, t *models.Todo) (*models.Todo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "UPDATE todos SET title = ?, completed = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND workspace_id = ?" // 💡 updated_at を追加
 
-	result, err := r.DB.Exec(query, t.Title, t.Completed, id)
+	result, err := r.DB.ExecContext(ctx, query, t.Title, t.Completed, id, workspaceID)
 	if err != nil {
-		log.Printf("Failed to update todo: %v", err)
+		logging.FromContext(ctx).Error("failed to update todo", "error", err)
 		return nil, fmt.Errorf("could not update todo: %w", err)
 	}
 
@@ -151,16 +193,18 @@ func (r *TodoRepository) Update(id int, t *models.Todo) (*models.Todo, error) {
 		return nil, ErrTodoNotFound
 	}
 
-	return r.FindByID(id)
+	return r.FindByID(ctx, workspaceID, id)
 }
 
-// Delete は指定されたIDのTodoタスクを削除します。
-func (r *TodoRepository) Delete(id int) error {
-	query := "DELETE FROM todos WHERE id = ?"
+// Delete はワークスペース内の指定されたIDのTodoタスクを削除します。
+func (r *TodoRepository) Delete(ctx context.Context, workspaceID, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "DELETE FROM todos WHERE id = ? AND workspace_id = ?"
 
-	result, err := r.DB.Exec(query, id)
+	result, err := r.DB.ExecContext(ctx, query, id, workspaceID)
 	if err != nil {
-		log.Printf("Failed to delete todo: %v", err)
+		logging.FromContext(ctx).Error("failed to delete todo", "error", err)
 		return fmt.Errorf("could not delete todo: %w", err)
 	}
 
@@ -2,18 +2,41 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 
+	"go-next-todo/backend/internal/logging"
 	"go-next-todo/backend/internal/models"
-
-	"golang.org/x/crypto/bcrypt" // パスワードのハッシュ化用
+	"go-next-todo/backend/internal/password"
 )
 
+// UserStore はユーザーを保存するストアです。MySQL の UserRepository とメモリ上の MemoryUserStore があります。
+type UserStore interface {
+	Create(ctx context.Context, u *models.User) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID uint, newHash string) error
+	ResetPasswordWithToken(ctx context.Context, tokenID, userID uint, newHash string) error
+	UpdatePasswordHash(ctx context.Context, userID uint, oldHash, newHash string) error
+	MarkEmailVerified(ctx context.Context, userID uint) error
+	UpdateUsername(ctx context.Context, userID uint, username string) error
+	UpdateEmail(ctx context.Context, userID uint, email string) error
+	ChangeEmailWithToken(ctx context.Context, tokenID, userID uint, email string) error
+	ScheduleDeletion(ctx context.Context, userID uint, deletionAt time.Time) error
+	CancelDeletion(ctx context.Context, userID uint) error
+	Delete(ctx context.Context, userID uint) error
+	DeleteScheduledBefore(ctx context.Context, before time.Time) (int, error)
+	List(ctx context.Context, filter models.UserListFilter) ([]*models.User, int, error)
+	UpdateRole(ctx context.Context, userID uint, role string) error
+	SetDisabled(ctx context.Context, userID uint, disabled bool) error
+}
+
 // UserRepository はデータベース操作を行うための構造体です。
 type UserRepository struct {
 	DB *sql.DB
@@ -24,35 +47,54 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{DB: db}
 }
 
-// HashPassword は与えられたパスワードをbcryptでハッシュ化します。
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// HashPassword は与えられたパスワードを設定されたアルゴリズム（デフォルトは Argon2id）でハッシュ化します。
+func HashPassword(plain string) (string, error) {
+	hashedPassword, err := password.DefaultHasher().Hash(plain)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashedPassword), nil
+	return hashedPassword, nil
 }
 
 // VerifyPassword はハッシュ化されたパスワードと平文のパスワードを比較します。
-func VerifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// ハッシュのアルゴリズムはハッシュ自体から判定するため、bcrypt で保存された古いハッシュも検証できます。
+func VerifyPassword(hashedPassword, plain string) error {
+	return password.Verify(hashedPassword, plain)
+}
+
+// PasswordNeedsRehash は保存されているハッシュが現在のハッシュ設定より古いかどうかを返します。
+func PasswordNeedsRehash(hashedPassword string) bool {
+	return password.DefaultHasher().NeedsRehash(hashedPassword)
 }
 
 var (
-	ErrDuplicateEmail = errors.New("duplicate email")
-	ErrUserNotFound   = errors.New("user not found")
+	ErrDuplicateEmail    = errors.New("duplicate email")
+	ErrDuplicateUsername = errors.New("duplicate username")
+	ErrUserNotFound      = errors.New("user not found")
 )
 
+// duplicateUserError は users の一意制約違反を、重複したキーに応じて ErrDuplicateUsername か ErrDuplicateEmail に変換します。
+// キー名はエラーメッセージ "Duplicate entry '...' for key 'users.username'" の末尾から判別します。
+func duplicateUserError(mysqlErr *mysql.MySQLError) error {
+	if strings.HasSuffix(mysqlErr.Message, "username'") {
+		return ErrDuplicateUsername
+	}
+	return ErrDuplicateEmail
+}
+
 // Create は新しいユーザーをデータベースに挿入します。
-func (r *UserRepository) Create(u *models.User) (*models.User, error) {
+// メールアドレスが重複する場合は ErrDuplicateEmail、ユーザー名が重複する場合は ErrDuplicateUsername を返します。
+func (r *UserRepository) Create(ctx context.Context, u *models.User) (*models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 	query := "INSERT INTO users (username, email, password_hash, role) VALUES (?, ?, ?, ?)"
-	result, err := r.DB.Exec(query, u.Username, u.Email, u.PasswordHash, u.Role)
+	result, err := r.DB.ExecContext(ctx, query, u.Username, u.Email, u.PasswordHash, u.Role)
 	if err != nil {
 		// MySQLの重複エントリーエラーコード1062をチェック
 		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
-			return nil, ErrDuplicateEmail // カスタムエラーを返す
+			return nil, duplicateUserError(mysqlErr)
 		}
-		log.Printf("Failed to insert user: %v", err)
+		logging.FromContext(ctx).Error("failed to insert user", "error", err)
 		return nil, fmt.Errorf("could not insert user: %w", err)
 	}
 
@@ -65,32 +107,84 @@ func (r *UserRepository) Create(u *models.User) (*models.User, error) {
 	return u, nil
 }
 
-// FindByEmail はメールアドレスでユーザーを検索します。
-func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
-	query := "SELECT id, username, email, password_hash, role, created_at, updated_at FROM users WHERE email = ?"
+// userColumns はユーザー取得時に SELECT するカラムの一覧です。scanUser と順序を合わせてください。
+const userColumns = "id, username, email, password_hash, role, email_verified_at, token_version, deletion_at, disabled_at, created_at, updated_at"
+
+// rowScanner は *sql.Row と *sql.Rows の共通インターフェースです。
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser は userColumns の順序で1行を読み取ります。
+func scanUser(row rowScanner) (*models.User, error) {
 	var u models.User
-	err := r.DB.QueryRow(query, email).Scan(
+	var verifiedAt, deletionAt, disabledAt sql.NullTime
+	err := row.Scan(
 		&u.ID,
 		&u.Username,
 		&u.Email,
 		&u.PasswordHash,
 		&u.Role,
+		&verifiedAt,
+		&u.TokenVersion,
+		&deletionAt,
+		&disabledAt,
 		&u.CreatedAt,
 		&u.UpdatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		u.EmailVerifiedAt = &verifiedAt.Time
+	}
+	if deletionAt.Valid {
+		u.DeletionAt = &deletionAt.Time
+	}
+	if disabledAt.Valid {
+		u.DisabledAt = &disabledAt.Time
+	}
+	return &u, nil
+}
+
+// FindByEmail はメールアドレスでユーザーを検索します。
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "SELECT " + userColumns + " FROM users WHERE email = ?"
+	u, err := scanUser(r.DB.QueryRowContext(ctx, query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUserNotFound
 		}
-		log.Printf("Failed to query user by email: %v", err)
+		logging.FromContext(ctx).Error("failed to query user by email", "error", err)
 		return nil, fmt.Errorf("could not query user: %w", err)
 	}
-	return &u, nil
+	return u, nil
+}
+
+// FindByID はIDでユーザーを検索します。
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
+	u, err := scanUser(r.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		logging.FromContext(ctx).Error("failed to query user by id", "error", err)
+		return nil, fmt.Errorf("could not query user: %w", err)
+	}
+	return u, nil
 }
 
 // UpdatePassword はユーザーのパスワードを更新します。
-func (r *UserRepository) UpdatePassword(userID uint, newHash string) error {
-	res, err := r.DB.Exec("UPDATE users SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", newHash, userID)
+// token_version を加算するため、発行済みのJWTはすべて無効になります。
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID uint, newHash string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	res, err := r.DB.ExecContext(ctx, "UPDATE users SET password_hash = ?, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?", newHash, userID)
 	if err != nil {
 		return err
 	}
@@ -103,3 +197,333 @@ func (r *UserRepository) UpdatePassword(userID uint, newHash string) error {
 	}
 	return nil
 }
+
+// ResetPasswordWithToken はリセットトークンの消費とパスワードの更新を1つのトランザクションで行います。
+// トークンが使用済み・期限切れの場合は ErrResetTokenNotFound を返し、パスワードは変更しません。
+// UpdatePassword と同様に token_version を加算するため、発行済みのJWTはすべて無効になります。
+func (r *UserRepository) ResetPasswordWithToken(ctx context.Context, tokenID, userID uint, newHash string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = NOW() WHERE id = ? AND user_id = ? AND used_at IS NULL AND expires_at > NOW()",
+		tokenID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("could not consume reset token: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrResetTokenNotFound
+	}
+
+	res, err = tx.ExecContext(ctx, "UPDATE users SET password_hash = ?, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?", newHash, userID)
+	if err != nil {
+		return fmt.Errorf("could not update password: %w", err)
+	}
+	if n, err = res.RowsAffected(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return tx.Commit()
+}
+
+// UpdatePasswordHash はパスワードを変えずにハッシュだけを置き換えます（ハッシュ設定の変更に伴う再ハッシュ用）。
+// UpdatePassword と異なり token_version は変えないため、既存のセッションはそのまま使えます。
+// 同時に別の更新でパスワードが変わっていた場合に上書きしないよう、元のハッシュが一致する場合だけ更新します。
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, userID uint, oldHash, newHash string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET password_hash = ? WHERE id = ? AND password_hash = ?", newHash, userID, oldHash)
+	if err != nil {
+		return fmt.Errorf("could not update password hash: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailVerified はユーザーのメールアドレスを確認済みにします。
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID uint) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	res, err := r.DB.ExecContext(ctx, "UPDATE users SET email_verified_at = NOW(), updated_at = CURRENT_TIMESTAMP WHERE id = ?", userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateUsername はユーザー名を更新します。
+func (r *UserRepository) UpdateUsername(ctx context.Context, userID uint, username string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	res, err := r.DB.ExecContext(ctx, "UPDATE users SET username = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", username, userID)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+			return ErrDuplicateUsername
+		}
+		logging.FromContext(ctx).Error("failed to update username", "error", err)
+		return fmt.Errorf("could not update username: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateEmail はメールアドレスを更新し、確認済みにします。
+// 確認メールのリンクを経由した変更でのみ呼び出してください。
+func (r *UserRepository) UpdateEmail(ctx context.Context, userID uint, email string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	res, err := r.DB.ExecContext(ctx, "UPDATE users SET email = ?, email_verified_at = NOW(), updated_at = CURRENT_TIMESTAMP WHERE id = ?", email, userID)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+			return ErrDuplicateEmail
+		}
+		logging.FromContext(ctx).Error("failed to update email", "error", err)
+		return fmt.Errorf("could not update email: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ChangeEmailWithToken は確認トークンの消費とメールアドレスの変更を1つのトランザクションで行います。
+// トークンが使用済み・期限切れの場合は ErrVerificationTokenNotFound を返します。
+// 新しいアドレスが使用済みの場合は ErrDuplicateEmail を返し、トークンは消費しません。
+func (r *UserRepository) ChangeEmailWithToken(ctx context.Context, tokenID, userID uint, email string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE email_verification_tokens SET used_at = NOW() WHERE id = ? AND user_id = ? AND used_at IS NULL AND expires_at > NOW()",
+		tokenID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("could not consume verification token: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrVerificationTokenNotFound
+	}
+
+	res, err = tx.ExecContext(ctx, "UPDATE users SET email = ?, email_verified_at = NOW(), updated_at = CURRENT_TIMESTAMP WHERE id = ?", email, userID)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+			return ErrDuplicateEmail
+		}
+		logging.FromContext(ctx).Error("failed to update email", "error", err)
+		return fmt.Errorf("could not update email: %w", err)
+	}
+	if n, err = res.RowsAffected(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return tx.Commit()
+}
+
+// ScheduleDeletion はユーザーを退会手続き中にし、deletionAt 以降に削除されるようにします。
+// token_version を加算するため、発行済みのJWTはすべて無効になります。
+func (r *UserRepository) ScheduleDeletion(ctx context.Context, userID uint, deletionAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	res, err := r.DB.ExecContext(ctx, "UPDATE users SET deletion_at = ?, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?", deletionAt, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// CancelDeletion は退会手続きを取り消します。
+func (r *UserRepository) CancelDeletion(ctx context.Context, userID uint) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET deletion_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?", userID)
+	return err
+}
+
+// Delete はユーザーと関連データを削除します。
+// todos など外部キーで CASCADE 指定されたテーブルはデータベースが削除します。
+func (r *UserRepository) Delete(ctx context.Context, userID uint) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("could not delete reset tokens: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete user", "error", err)
+		return fmt.Errorf("could not delete user: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return tx.Commit()
+}
+
+// DeleteScheduledBefore は削除予定日時が before 以前のユーザーをすべて削除し、削除件数を返します。
+// 期限は1人ずつの削除に設けるため、対象のユーザーが多くても途中で打ち切られません。
+func (r *UserRepository) DeleteScheduledBefore(ctx context.Context, before time.Time) (int, error) {
+	queryCtx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	rows, err := r.DB.QueryContext(queryCtx, "SELECT id FROM users WHERE deletion_at IS NOT NULL AND deletion_at <= ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("could not query scheduled deletions: %w", err)
+	}
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("could not scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating scheduled deletions: %w", err)
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		if err := r.Delete(ctx, id); err != nil {
+			if err == ErrUserNotFound {
+				continue
+			}
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// likeEscaper は LIKE のパターンに使う文字列の \、%、_ をエスケープし、検索語をそのままの文字列として一致させます。
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List は検索条件に一致するユーザーをページングして返します。2つ目の戻り値は条件に一致する総件数です。
+func (r *UserRepository) List(ctx context.Context, filter models.UserListFilter) ([]*models.User, int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	var conds []string
+	var args []interface{}
+	if filter.Query != "" {
+		conds = append(conds, `(username LIKE ? ESCAPE '\\' OR email LIKE ? ESCAPE '\\')`)
+		like := "%" + likeEscaper.Replace(filter.Query) + "%"
+		args = append(args, like, like)
+	}
+	if filter.Role != "" {
+		conds = append(conds, "role = ?")
+		args = append(args, filter.Role)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		logging.FromContext(ctx).Error("failed to count users", "error", err)
+		return nil, 0, fmt.Errorf("could not count users: %w", err)
+	}
+
+	query := "SELECT " + userColumns + " FROM users" + where + " ORDER BY id LIMIT ? OFFSET ?"
+	rows, err := r.DB.QueryContext(ctx, query, append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)...)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to query users", "error", err)
+		return nil, 0, fmt.Errorf("could not query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+	}
+	return users, total, nil
+}
+
+// UpdateRole はユーザーのロールを変更します。
+// 同じ値への更新では影響行数が0になり得るため、存在確認は呼び出し元で行ってください。
+// 存在しないロールを指定した場合は外部キー制約により ErrRoleNotFound を返します。
+func (r *UserRepository) UpdateRole(ctx context.Context, userID uint, role string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET role = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", role, userID)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1452 {
+		return ErrRoleNotFound
+	}
+	return err
+}
+
+// SetDisabled はユーザーを無効化・有効化します。
+// 無効化時は token_version を加算し、発行済みのJWTをすべて無効にします。
+// 存在確認は呼び出し元で行ってください。
+func (r *UserRepository) SetDisabled(ctx context.Context, userID uint, disabled bool) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	query := "UPDATE users SET disabled_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+	if disabled {
+		query = "UPDATE users SET disabled_at = NOW(), token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+	}
+	_, err := r.DB.ExecContext(ctx, query, userID)
+	return err
+}
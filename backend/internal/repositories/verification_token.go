@@ -0,0 +1,85 @@
+// Package repositories はデータベース操作を行うリポジトリを提供します。
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"go-next-todo/backend/internal/models"
+)
+
+var ErrVerificationTokenNotFound = errors.New("verification token not found")
+
+type VerificationTokenRepository interface {
+	Save(token *models.EmailVerificationToken) error
+	FindByToken(token string) (*models.EmailVerificationToken, error)
+	MarkUsed(id uint) error
+	CleanupExpired() error
+}
+
+type MySQLVerificationTokenRepo struct {
+	DB *sql.DB
+}
+
+func NewMySQLVerificationTokenRepo(db *sql.DB) *MySQLVerificationTokenRepo {
+	return &MySQLVerificationTokenRepo{DB: db}
+}
+
+// Save はトークンのハッシュを保存します。
+func (r *MySQLVerificationTokenRepo) Save(t *models.EmailVerificationToken) error {
+	_, err := r.DB.Exec(
+		"INSERT INTO email_verification_tokens (user_id, email, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?, ?)",
+		t.UserID, t.Email, t.Purpose, hashToken(t.Token), t.ExpiresAt,
+	)
+	return err
+}
+
+// FindByToken は平文のトークンに一致する確認トークンを返します。
+func (r *MySQLVerificationTokenRepo) FindByToken(token string) (*models.EmailVerificationToken, error) {
+	row := r.DB.QueryRow(
+		"SELECT id, user_id, email, purpose, expires_at, used_at, created_at FROM email_verification_tokens WHERE token_hash = ?",
+		hashToken(token),
+	)
+
+	var vt models.EmailVerificationToken
+	var usedAt sql.NullTime
+	if err := row.Scan(&vt.ID, &vt.UserID, &vt.Email, &vt.Purpose, &vt.ExpiresAt, &usedAt, &vt.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVerificationTokenNotFound
+		}
+		return nil, err
+	}
+	if usedAt.Valid {
+		vt.UsedAt = &usedAt.Time
+	}
+	return &vt, nil
+}
+
+// MarkUsed はトークンを使用済みにします。同時に使用された場合に1回だけ成功するよう、
+// 未使用のトークンのみを更新し、更新できなかった場合は ErrVerificationTokenNotFound を返します。
+func (r *MySQLVerificationTokenRepo) MarkUsed(id uint) error {
+	result, err := r.DB.Exec(
+		"UPDATE email_verification_tokens SET used_at = NOW() WHERE id = ? AND used_at IS NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrVerificationTokenNotFound
+	}
+	return nil
+}
+
+func (r *MySQLVerificationTokenRepo) CleanupExpired() error {
+	_, err := r.DB.Exec(`
+		DELETE FROM email_verification_tokens
+		WHERE used_at IS NOT NULL
+		   OR expires_at < NOW()
+	`)
+	return err
+}
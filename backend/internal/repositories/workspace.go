@@ -0,0 +1,388 @@
+// Package repositories はデータベース操作を行うリポジトリを提供します。
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/models"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrNotMember         = errors.New("not a workspace member")
+	ErrAlreadyMember     = errors.New("already a workspace member")
+	// ErrLastOwner は最後のオーナーを外そうとした場合のエラーです。
+	ErrLastOwner = errors.New("workspace must have at least one owner")
+)
+
+type WorkspaceRepository interface {
+	Create(ctx context.Context, name string, ownerID int) (*models.Workspace, error)
+	FindByID(ctx context.Context, id int) (*models.Workspace, error)
+	ListForUser(ctx context.Context, userID int) ([]*models.Workspace, error)
+	FirstForUser(ctx context.Context, userID int) (*models.Workspace, error)
+	FirstOrCreateForUser(ctx context.Context, userID int, name string) (*models.Workspace, error)
+	FindMember(ctx context.Context, workspaceID, userID int) (*models.WorkspaceMember, error)
+	ListMembers(ctx context.Context, workspaceID int) ([]*models.WorkspaceMember, error)
+	AddMember(ctx context.Context, workspaceID, userID int, role string) error
+	UpdateMemberRole(ctx context.Context, workspaceID, userID int, role string) error
+	RemoveMember(ctx context.Context, workspaceID, userID int) error
+}
+
+type MySQLWorkspaceRepo struct {
+	DB *sql.DB
+}
+
+func NewMySQLWorkspaceRepo(db *sql.DB) *MySQLWorkspaceRepo {
+	return &MySQLWorkspaceRepo{DB: db}
+}
+
+// Create はワークスペースを作成し、ownerID のユーザーをオーナーとして登録します。
+func (r *MySQLWorkspaceRepo) Create(ctx context.Context, name string, ownerID int) (*models.Workspace, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertWorkspace(ctx, tx, name, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit workspace: %w", err)
+	}
+	return r.ownedWorkspace(ctx, id)
+}
+
+// FirstOrCreateForUser はユーザーが最初に参加したワークスペースを返します。
+// どのワークスペースにも所属していない場合は name のワークスペースを作成し、ユーザーをオーナーにします。
+// 同じユーザーの同時実行でワークスペースが重複しないよう、ユーザーの行をロックして確認と作成を行います。
+func (r *MySQLWorkspaceRepo) FirstOrCreateForUser(ctx context.Context, userID int, name string) (*models.Workspace, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked int
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("could not lock user: %w", err)
+	}
+
+	// ロックの取得後に確認するため、先に作成したトランザクションのメンバーシップが見える
+	var existing int
+	err = tx.QueryRowContext(ctx, "SELECT workspace_id FROM workspace_members WHERE user_id = ? LIMIT 1 FOR UPDATE", userID).Scan(&existing)
+	switch {
+	case err == nil:
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("could not commit workspace: %w", err)
+		}
+		return r.FirstForUser(ctx, userID)
+	case err != sql.ErrNoRows:
+		return nil, fmt.Errorf("could not query workspace membership: %w", err)
+	}
+
+	id, err := insertWorkspace(ctx, tx, name, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit workspace: %w", err)
+	}
+	return r.ownedWorkspace(ctx, id)
+}
+
+// insertWorkspace はトランザクション内でワークスペースを作成し、ownerID のユーザーをオーナーとして登録します。
+func insertWorkspace(ctx context.Context, tx *sql.Tx, name string, ownerID int) (int64, error) {
+	result, err := tx.ExecContext(ctx, "INSERT INTO workspaces (name) VALUES (?)", name)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to insert workspace", "error", err)
+		return 0, fmt.Errorf("could not create workspace: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("could not get last insert ID: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?)",
+		id, ownerID, models.WorkspaceRoleOwner,
+	); err != nil {
+		return 0, fmt.Errorf("could not add workspace owner: %w", err)
+	}
+	return id, nil
+}
+
+// ownedWorkspace は作成したワークスペースをオーナーのロール付きで返します。
+func (r *MySQLWorkspaceRepo) ownedWorkspace(ctx context.Context, id int64) (*models.Workspace, error) {
+	ws, err := r.FindByID(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+	ws.Role = models.WorkspaceRoleOwner
+	return ws, nil
+}
+
+// FindByID はIDでワークスペースを検索します。
+func (r *MySQLWorkspaceRepo) FindByID(ctx context.Context, id int) (*models.Workspace, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	var ws models.Workspace
+	err := r.DB.QueryRowContext(ctx,
+		"SELECT id, name, created_at, updated_at FROM workspaces WHERE id = ?", id,
+	).Scan(&ws.ID, &ws.Name, &ws.CreatedAt, &ws.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("could not query workspace: %w", err)
+	}
+	return &ws, nil
+}
+
+// ListForUser はユーザーが所属するワークスペースを、ユーザーのロール付きで返します。
+func (r *MySQLWorkspaceRepo) ListForUser(ctx context.Context, userID int) ([]*models.Workspace, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT w.id, w.name, m.role, w.created_at, w.updated_at
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = ?
+		ORDER BY w.id`, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to query workspaces", "error", err)
+		return nil, fmt.Errorf("could not query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	workspaces := []*models.Workspace{}
+	for rows.Next() {
+		var ws models.Workspace
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.Role, &ws.CreatedAt, &ws.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan workspace: %w", err)
+		}
+		workspaces = append(workspaces, &ws)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+// FirstForUser はユーザーが最初に参加したワークスペースを返します。
+// ワークスペースが指定されなかったリクエストの既定値として使用します。
+func (r *MySQLWorkspaceRepo) FirstForUser(ctx context.Context, userID int) (*models.Workspace, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	var ws models.Workspace
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT w.id, w.name, m.role, w.created_at, w.updated_at
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = ?
+		ORDER BY m.created_at, w.id
+		LIMIT 1`, userID,
+	).Scan(&ws.ID, &ws.Name, &ws.Role, &ws.CreatedAt, &ws.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("could not query workspace: %w", err)
+	}
+	return &ws, nil
+}
+
+// FindMember はワークスペースのメンバーシップを返します。メンバーでない場合は ErrNotMember を返します。
+func (r *MySQLWorkspaceRepo) FindMember(ctx context.Context, workspaceID, userID int) (*models.WorkspaceMember, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	var m models.WorkspaceMember
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT m.workspace_id, m.user_id, u.username, u.email, m.role, m.created_at
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = ? AND m.user_id = ?`, workspaceID, userID,
+	).Scan(&m.WorkspaceID, &m.UserID, &m.Username, &m.Email, &m.Role, &m.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotMember
+		}
+		return nil, fmt.Errorf("could not query workspace member: %w", err)
+	}
+	return &m, nil
+}
+
+// ListMembers はワークスペースのメンバー一覧を返します。
+func (r *MySQLWorkspaceRepo) ListMembers(ctx context.Context, workspaceID int) ([]*models.WorkspaceMember, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT m.workspace_id, m.user_id, u.username, u.email, m.role, m.created_at
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = ?
+		ORDER BY m.created_at, m.user_id`, workspaceID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to query workspace members", "error", err)
+		return nil, fmt.Errorf("could not query workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*models.WorkspaceMember{}
+	for rows.Next() {
+		var m models.WorkspaceMember
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Username, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan workspace member: %w", err)
+		}
+		members = append(members, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workspace members: %w", err)
+	}
+	return members, nil
+}
+
+// AddMember はユーザーをワークスペースに追加します。
+func (r *MySQLWorkspaceRepo) AddMember(ctx context.Context, workspaceID, userID int, role string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	_, err := r.DB.ExecContext(ctx,
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?)",
+		workspaceID, userID, role,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1062:
+				return ErrAlreadyMember
+			case 1452:
+				return ErrWorkspaceNotFound
+			}
+		}
+		return fmt.Errorf("could not add workspace member: %w", err)
+	}
+	return nil
+}
+
+// UpdateMemberRole はメンバーのロールを変更します。
+// 最後のオーナーを別のロールに変更する場合は ErrLastOwner を返します。
+func (r *MySQLWorkspaceRepo) UpdateMemberRole(ctx context.Context, workspaceID, userID int, role string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	roles, err := lockMemberRoles(ctx, tx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if err := checkOwnerRemains(roles, userID, role); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE workspace_members SET role = ? WHERE workspace_id = ? AND user_id = ?",
+		role, workspaceID, userID,
+	); err != nil {
+		return fmt.Errorf("could not update workspace member: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit workspace member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember はユーザーをワークスペースから外します。
+// 最後のオーナーを外す場合は ErrLastOwner を返します。
+func (r *MySQLWorkspaceRepo) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	roles, err := lockMemberRoles(ctx, tx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if err := checkOwnerRemains(roles, userID, ""); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?",
+		workspaceID, userID,
+	); err != nil {
+		return fmt.Errorf("could not remove workspace member: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit workspace member: %w", err)
+	}
+	return nil
+}
+
+// lockMemberRoles はワークスペースのメンバーの行をロックし、ユーザーIDごとのロールを返します。
+// オーナー数の確認と変更の間に、同時に実行された別の変更が割り込まないようにします。
+func lockMemberRoles(ctx context.Context, tx *sql.Tx, workspaceID int) (map[int]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT user_id, role FROM workspace_members WHERE workspace_id = ? FOR UPDATE", workspaceID,
+	)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to lock workspace members", "error", err)
+		return nil, fmt.Errorf("could not lock workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	roles := map[int]string{}
+	for rows.Next() {
+		var userID int
+		var role string
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, fmt.Errorf("could not scan workspace member: %w", err)
+		}
+		roles[userID] = role
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workspace members: %w", err)
+	}
+	return roles, nil
+}
+
+// checkOwnerRemains は userID のメンバーのロールを newRole に変更しても、オーナーが1人以上残ることを確認します。
+// newRole が空の場合はメンバーから外す操作として確認します。メンバーでない場合は ErrNotMember を返します。
+func checkOwnerRemains(roles map[int]string, userID int, newRole string) error {
+	current, ok := roles[userID]
+	if !ok {
+		return ErrNotMember
+	}
+	if current != models.WorkspaceRoleOwner || newRole == models.WorkspaceRoleOwner {
+		return nil
+	}
+	owners := 0
+	for _, role := range roles {
+		if role == models.WorkspaceRoleOwner {
+			owners++
+		}
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -1,22 +1,120 @@
 package routes
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"go-next-todo/backend/internal/handlers"
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
 	"go-next-todo/backend/internal/services"
+	"go-next-todo/backend/internal/tracing"
 )
 
+// RequestIDHeader はリクエストIDを受け渡すヘッダーです。
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength は受け入れるリクエストIDの最大長です。
+const maxRequestIDLength = 128
+
+// RequestLogger はリクエストIDを決め、リクエストIDを持つロガーを context に設定して、リクエストごとにアクセスログを出力するミドルウェアです。
+// X-Request-ID ヘッダーが送られた場合はその値を引き継ぎ（ロードバランサーなどで付与されたIDで追跡できるように）、なければ生成します。
+// リクエストIDはレスポンスの X-Request-ID ヘッダーでも返します。
+// パスにトークンを含むエンドポイントがあるため、アクセスログには実際のパスではなくルートのパターンを出力します。
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		reqLogger := logger.With("request_id", requestID)
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			reqLogger = reqLogger.With("trace_id", traceID)
+		}
+		ctx := logging.WithRequestID(logging.WithLogger(c.Request.Context(), reqLogger), requestID)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if userID := c.GetInt("user_id"); userID != 0 {
+			attrs = append(attrs, slog.Int("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		reqLogger.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// validRequestID はクライアントから受け取ったリクエストIDをそのままログに出力できるかを返します。
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID はランダムなリクエストIDを生成します。
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
 // AuthMiddleware はJWTトークンを検証し、ユーザー情報をコンテキストに設定するミドルウェアです。
-func AuthMiddleware(jwtService *services.JWTService) gin.HandlerFunc {
+// パスワード変更などで無効化されたトークンは拒否し、ロール等はデータベースの最新値を設定します。
+// 権限はロールからリクエストごとに解決し、"user_permissions" に設定します。
+// トークンのセッションが失効している場合も拒否し、有効なセッションのIDを "session_id" に設定します。
+// Authorization ヘッダーがない場合はCookieモードの Cookie からトークンを読み取り、"auth_via_cookie" を設定します。
+// 署名は正しいものの失効済みのトークンが使われた場合は、セキュリティイベントに記録します。
+// なりすましトークンの場合は、なりすましている管理者のIDを "impersonator_id" に設定します。
+func AuthMiddleware(jwtService *services.JWTService, userService *services.UserService, roleService *services.RoleService, sessionService *services.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
-			return
+			cookie, err := c.Cookie(handlers.AuthCookieName)
+			if err != nil || cookie == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+				c.Abort()
+				return
+			}
+			tokenString = "Bearer " + cookie
+			c.Set("auth_via_cookie", true)
 		}
 		// "Bearer " プレフィックスを削除
 		if !strings.HasPrefix(tokenString, "Bearer ") {
@@ -33,9 +131,204 @@ func AuthMiddleware(jwtService *services.JWTService) gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", int(claims.UserID))
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		session, err := sessionService.Validate(c.Request.Context(), claims, c.ClientIP())
+		if err != nil {
+			if err == services.ErrSessionInvalid {
+				recordTokenRejected(c, userService, claims, "session_invalid")
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired jwt token"})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate session"})
+			c.Abort()
+			return
+		}
+
+		user, err := userService.ValidateSession(c.Request.Context(), claims)
+		if err != nil {
+			if err == services.ErrSessionInvalid {
+				recordTokenRejected(c, userService, claims, "token_revoked")
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired jwt token"})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate session"})
+			c.Abort()
+			return
+		}
+
+		perms, err := roleService.Permissions(user.Role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", user.ID)
+		c.Set("user_email", user.Email)
+		c.Set("user_role", user.Role)
+		c.Set("user_permissions", perms)
+		c.Set("session_id", session.ID)
+		if claims.ActorID != 0 {
+			c.Set("impersonator_id", int(claims.ActorID))
+		}
+		c.Next()
+	}
+}
+
+// recordTokenRejected は失効済みのトークンによるアクセスをセキュリティイベントに記録します。
+func recordTokenRejected(c *gin.Context, userService *services.UserService, claims *models.JWTClaims, reason string) {
+	userID := int(claims.UserID)
+	userService.RecordSecurityEvent(c.Request.Context(), &models.SecurityEvent{
+		Type:      models.EventTokenRejected,
+		UserID:    &userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Metadata:  map[string]string{"reason": reason, "path": c.FullPath()},
+	})
+}
+
+// ImpersonationMiddleware はなりすましトークンによるリクエストを検証し、すべての操作をログとセキュリティイベントに記録するミドルウェアです。
+// なりすましている管理者が無効化された・権限を失った場合は拒否します。AuthMiddleware の後に使用してください。
+func ImpersonationMiddleware(impersonationService *services.ImpersonationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID := c.GetInt("impersonator_id")
+		if actorID == 0 {
+			c.Next()
+			return
+		}
+
+		if err := impersonationService.ValidateImpersonator(c.Request.Context(), uint(actorID)); err != nil {
+			if err == services.ErrImpersonatorInvalid {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired jwt token"})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate impersonation"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		subjectID := c.GetInt("user_id")
+		logging.FromContext(c.Request.Context()).Info("impersonated request",
+			"actor_id", actorID, "subject_id", subjectID, "method", c.Request.Method, "route", c.FullPath(), "status", c.Writer.Status())
+		impersonationService.RecordAction(c.Request.Context(), uint(actorID), uint(subjectID), c.Request.Method, c.Request.URL.Path, c.Writer.Status(),
+			models.ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+	}
+}
+
+// ForbidImpersonation はなりすまし中には許可しない操作（パスワード変更・退会・データのエクスポート・セッションの失効など）を拒否するミドルウェアです。
+// ImpersonationMiddleware の後に使用してください。
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetInt("impersonator_id") != 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CSRFMiddleware はCookieで認証されたリクエストのうち、状態を変更するリクエストをダブルサブミット方式で検証するミドルウェアです。
+// X-CSRF-Token ヘッダーの値が csrf_token Cookie と一致しない場合は拒否します。
+// Authorization ヘッダーで認証されたリクエストはブラウザが自動で送信しないため検証しません。AuthMiddleware の後に使用してください。
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("auth_via_cookie") {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(handlers.CSRFCookieName)
+		header := c.GetHeader(handlers.CSRFHeader)
+		if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail はメールアドレス確認モードが "todos" の場合に、未確認ユーザーのリクエストを拒否するミドルウェアです。
+// AuthMiddleware の後に使用してください。
+func RequireVerifiedEmail(userService *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userService.EmailVerificationMode() != services.EmailVerificationBeforeTodos {
+			c.Next()
+			return
+		}
+
+		verified, err := userService.IsEmailVerified(c.Request.Context(), uint(c.GetInt("user_id")))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address not verified"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission はログイン中のユーザーが指定した権限をすべて持つ場合のみ通過させるミドルウェアです。
+// AuthMiddleware の後に使用してください。
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userPerms, _ := c.Get("user_permissions")
+		set, ok := userPerms.(models.PermissionSet)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			c.Abort()
+			return
+		}
+		for _, p := range perms {
+			if !set.Has(p) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// WorkspaceHeader はリクエスト対象のワークスペースを指定するヘッダーです。
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceMiddleware は X-Workspace-ID ヘッダーで指定されたワークスペースのメンバーシップを検証し、
+// "workspace_id"・"workspace_role"・"workspace_permissions" をコンテキストに設定するミドルウェアです。
+// ヘッダーがない場合はユーザーの既定のワークスペースを使用します。AuthMiddleware の後に使用してください。
+func WorkspaceMiddleware(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		member, err := workspaceService.Resolve(c.Request.Context(), c.GetInt("user_id"), c.GetHeader(WorkspaceHeader))
+		if err != nil {
+			switch err {
+			case services.ErrInvalidWorkspaceID:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+			case repositories.ErrNotMember:
+				c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve workspace"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("workspace_id", member.WorkspaceID)
+		c.Set("workspace_role", member.Role)
+		c.Set("workspace_permissions", models.WorkspacePermissions(member.Role))
 		c.Next()
 	}
 }
@@ -3,69 +3,207 @@ package routes
 
 import (
 	"database/sql"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
+	"go-next-todo/backend/internal/config"
 	"go-next-todo/backend/internal/handlers"
+	"go-next-todo/backend/internal/health"
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/metrics"
+	"go-next-todo/backend/internal/migrations"
+	"go-next-todo/backend/internal/models"
 	"go-next-todo/backend/internal/repositories"
 	"go-next-todo/backend/internal/services"
+	"go-next-todo/backend/internal/tracing"
 )
 
+// Stores はルーターが使うリポジトリです。本番では MySQLStores で作成し、
+// テストでは repositories.MemoryStore のリポジトリに差し替えて MySQL なしでハンドラーを動かせます。
+type Stores struct {
+	Users              repositories.UserStore
+	Todos              repositories.TodoStore
+	Roles              repositories.RoleRepository
+	ResetTokens        repositories.ResetTokenRepository
+	VerificationTokens repositories.VerificationTokenRepository
+	Workspaces         repositories.WorkspaceRepository
+	OIDC               repositories.OIDCRepository
+	MagicLinkTokens    repositories.MagicLinkTokenRepository
+	Sessions           repositories.SessionRepository
+	SecurityEvents     repositories.SecurityEventRepository
+}
+
+// MySQLStores は db を使う MySQL のリポジトリを作成します。
+func MySQLStores(db *sql.DB) Stores {
+	return Stores{
+		Users:              repositories.NewUserRepository(db),
+		Todos:              repositories.NewTodoRepository(db),
+		Roles:              repositories.NewMySQLRoleRepo(db),
+		ResetTokens:        repositories.NewMySQLResetTokenRepo(db),
+		VerificationTokens: repositories.NewMySQLVerificationTokenRepo(db),
+		Workspaces:         repositories.NewMySQLWorkspaceRepo(db),
+		OIDC:               repositories.NewMySQLOIDCRepo(db),
+		MagicLinkTokens:    repositories.NewMySQLMagicLinkTokenRepo(db),
+		Sessions:           repositories.NewMySQLSessionRepo(db),
+		SecurityEvents:     repositories.NewMySQLSecurityEventRepo(db),
+	}
+}
+
 // SetupRouter はGinルーターをセットアップし、すべてのエンドポイントを登録します。
-func SetupRouter(db *sql.DB) *gin.Engine {
-	r := gin.Default()
+// 漏洩パスワードの一覧など、設定から読み込むものに失敗した場合はエラーを返します。
+func SetupRouter(db *sql.DB, cfg *config.Config) (*gin.Engine, error) {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewRouter(cfg, MySQLStores(db), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// メールサーバーに接続できなくてもメール以外の機能は使えるため、SMTP のチェックは必須にしない
+	healthHandler := handlers.NewHealthHandler(health.NewChecker(health.DefaultTimeout,
+		health.Database(db),
+		health.Migrations(migrator),
+		health.TCP("smtp", cfg.Mail.Addr(), true),
+	))
+
+	// メトリクスにはルートごとのリクエスト数などが含まれるため、公開する環境では metrics.token を設定する
+	r.GET("/metrics", metrics.RequireToken(cfg.Metrics.Token), metrics.Handler(metrics.NewRegistry(db)))
+	r.GET("/healthz", healthHandler.LivenessHandler)
+	r.GET("/readyz", healthHandler.ReadinessHandler)
+	r.GET("/api/dbcheck", func(c *gin.Context) {
+		if err := db.Ping(); err != nil {
+			logging.FromContext(c.Request.Context()).Error("database ping failed", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Database connection failed"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "message": "Database connection is healthy"})
+	})
+	return r, nil
+}
+
+// NewRouter は stores のリポジトリを使うGinルーターを作成し、APIのエンドポイントを登録します。
+// メトリクスやヘルスチェックなどデータベースに直接接続するエンドポイントは SetupRouter で登録します。
+// mailer が nil の場合は設定のSMTPサーバーからメールを送信します。
+func NewRouter(cfg *config.Config, stores Stores, mailer services.Mailer) (*gin.Engine, error) {
+	userServiceOptions, err := services.UserServiceOptionsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if mailer != nil {
+		userServiceOptions.Mailer = mailer
+	}
+
+	r := gin.New()
+	// RequestLogger を外側に置き、Recovery で 500 にしたパニックもアクセスログに残す。
+	// トレースのミドルウェアはさらに外側に置き、アクセスログにトレースIDを含める
+	r.Use(tracing.Middleware(cfg.Tracing.ServiceName), RequestLogger(slog.Default()), metrics.Middleware(), gin.Recovery())
 
 	// CORS対策
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.AllowCredentials = true
-	r.Use(cors.New(config))
-
-	// リポジトリ
-	todoRepo := repositories.NewTodoRepository(db)
-	userRepo := repositories.NewUserRepository(db)
-	resetRepo := repositories.NewMySQLResetTokenRepo(db)
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = cfg.Server.CORSOrigins
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", WorkspaceHeader, handlers.CSRFHeader}
+	corsConfig.AllowCredentials = true
+	r.Use(cors.New(corsConfig))
 
 	// サービス
-	todoService := services.NewTodoService(todoRepo)
-	userService := services.NewUserService(userRepo, resetRepo)
-	jwtService := services.NewJWTService()
+	todoService := services.NewTodoService(stores.Todos)
+	userService := services.NewUserService(stores.Users, stores.ResetTokens, stores.VerificationTokens, stores.MagicLinkTokens, stores.SecurityEvents, userServiceOptions)
+	roleService := services.NewRoleService(stores.Roles)
+	workspaceService := services.NewWorkspaceService(stores.Workspaces, stores.Users)
+	oidcService := services.NewOIDCService(cfg.OIDC, stores.OIDC, stores.Users)
+	jwtService := services.NewJWTService(cfg.Auth.JWTSecret)
+	sessionService := services.NewSessionService(stores.Sessions)
+	impersonationService := services.NewImpersonationService(stores.Users, userService, roleService, sessionService, jwtService)
+	exportService := services.NewExportService(stores.Users, stores.Todos, stores.ResetTokens)
 
 	// ハンドラー
-	userHandler := handlers.NewUserHandler(userService, jwtService)
+	userHandler := handlers.NewUserHandler(userService, jwtService, sessionService, handlers.NewAuthCookieConfig(cfg.Auth))
 	todoHandler := handlers.NewTodoHandler(todoService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	adminHandler := handlers.NewAdminHandler(userService, roleService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, userHandler)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
 
 	// ルーティング
 	r.GET("/api/hello", HelloHandler)
-	r.GET("/api/dbcheck", func(c *gin.Context) {
-		if err := db.Ping(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Database connection failed", "error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "message": "Database connection is healthy"})
-	})
 	r.POST("/api/register", userHandler.RegisterHandler)
 	r.POST("/api/login", userHandler.LoginHandler)
 	r.POST("/api/forgot-password", userHandler.ForgotPasswordHandler)
 	r.POST("/api/reset-password/:token", userHandler.ResetPasswordHandler)
 	r.POST("/api/reset-password", userHandler.ResetPasswordHandler)
+	r.POST("/api/verify-email/:token", userHandler.VerifyEmailHandler)
+	r.POST("/api/resend-verification", userHandler.ResendVerificationHandler)
+	r.POST("/api/login/magic-link", userHandler.RequestMagicLinkHandler)
+	r.POST("/api/login/magic-link/:token", userHandler.MagicLinkLoginHandler)
+	r.GET("/api/auth/oidc/login", oidcHandler.LoginHandler)
+	r.GET("/api/auth/oidc/callback", oidcHandler.CallbackHandler)
 
 	authorized := r.Group("/")
-	authorized.Use(AuthMiddleware(jwtService))
+	authorized.Use(AuthMiddleware(jwtService, userService, roleService, sessionService), ImpersonationMiddleware(impersonationService), CSRFMiddleware())
 	{
-		authorized.GET("/api/todos", todoHandler.GetTodosHandler)
-		authorized.GET("/api/todos/:id", todoHandler.GetTodoByIDHandler)
-		authorized.POST("/api/todos", todoHandler.CreateTodoHandler)
-		authorized.PUT("/api/todos/:id", todoHandler.UpdateTodoHandler)
-		authorized.DELETE("/api/todos/:id", todoHandler.DeleteTodoHandler)
 		authorized.GET("/api/protected", userHandler.ProtectedHandler)
+		authorized.GET("/api/me", userHandler.GetMeHandler)
+		authorized.PATCH("/api/me", userHandler.UpdateMeHandler)
+		authorized.POST("/api/me/password", ForbidImpersonation(), userHandler.ChangePasswordHandler)
+		authorized.POST("/api/me/email", ForbidImpersonation(), userHandler.ChangeEmailHandler)
+		authorized.DELETE("/api/me", ForbidImpersonation(), userHandler.DeleteMeHandler)
+		authorized.GET("/api/me/export", ForbidImpersonation(), exportHandler.ExportMeHandler)
+		authorized.POST("/api/logout", userHandler.LogoutHandler)
+		authorized.GET("/api/me/sessions", userHandler.ListSessionsHandler)
+		authorized.DELETE("/api/me/sessions/:id", ForbidImpersonation(), userHandler.RevokeSessionHandler)
+		authorized.GET("/api/me/security-events", userHandler.SecurityActivityHandler)
+		authorized.GET("/api/workspaces", workspaceHandler.ListWorkspacesHandler)
+		authorized.POST("/api/workspaces", workspaceHandler.CreateWorkspaceHandler)
+		authorized.GET("/api/workspaces/:id/members", workspaceHandler.ListMembersHandler)
+		authorized.POST("/api/workspaces/:id/members", workspaceHandler.AddMemberHandler)
+		authorized.PATCH("/api/workspaces/:id/members/:user_id", workspaceHandler.UpdateMemberHandler)
+		authorized.DELETE("/api/workspaces/:id/members/:user_id", workspaceHandler.RemoveMemberHandler)
+	}
+
+	// Todo は X-Workspace-ID ヘッダーで指定されたワークスペース内で操作します
+	todos := authorized.Group("/api/todos")
+	todos.Use(WorkspaceMiddleware(workspaceService))
+	{
+		todos.GET("", todoHandler.GetTodosHandler)
+		todos.GET("/:id", todoHandler.GetTodoByIDHandler)
+		todos.POST("", RequireVerifiedEmail(userService), todoHandler.CreateTodoHandler)
+		todos.PUT("/:id", todoHandler.UpdateTodoHandler)
+		todos.DELETE("/:id", todoHandler.DeleteTodoHandler)
+	}
+
+	admin := authorized.Group("/api/admin")
+	// なりすまし中は管理操作を行えないようにします
+	admin.Use(ForbidImpersonation())
+	{
+		admin.GET("/users", RequirePermission(models.PermUsersRead), adminHandler.ListUsersHandler)
+		admin.GET("/users/:id", RequirePermission(models.PermUsersRead), adminHandler.GetUserHandler)
+		admin.PATCH("/users/:id/role", RequirePermission(models.PermUsersManage), adminHandler.UpdateRoleHandler)
+		admin.POST("/users/:id/disable", RequirePermission(models.PermUsersManage), adminHandler.DisableUserHandler)
+		admin.POST("/users/:id/enable", RequirePermission(models.PermUsersManage), adminHandler.EnableUserHandler)
+		admin.POST("/users/:id/force-password-reset", RequirePermission(models.PermUsersManage), adminHandler.ForcePasswordResetHandler)
+		admin.DELETE("/users/:id", RequirePermission(models.PermUsersManage), adminHandler.DeleteUserHandler)
+		admin.GET("/security-events", RequirePermission(models.PermAuditRead), adminHandler.ListSecurityEventsHandler)
+		admin.POST("/users/:id/impersonate", RequirePermission(models.PermUsersImpersonate), impersonationHandler.StartImpersonationHandler)
+
+		roles := admin.Group("")
+		roles.Use(RequirePermission(models.PermRolesManage))
+		roles.GET("/permissions", roleHandler.ListPermissionsHandler)
+		roles.GET("/roles", roleHandler.ListRolesHandler)
+		roles.GET("/roles/:name", roleHandler.GetRoleHandler)
+		roles.POST("/roles", roleHandler.CreateRoleHandler)
+		roles.PUT("/roles/:name", roleHandler.UpdateRoleHandler)
+		roles.DELETE("/roles/:name", roleHandler.DeleteRoleHandler)
 	}
 
-	return r
+	return r, nil
 }
 
 func HelloHandler(c *gin.Context) {
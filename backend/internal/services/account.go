@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/tracing"
+)
+
+// GetProfile はログイン中のユーザー情報を返します。
+func (s *UserService) GetProfile(ctx context.Context, userID uint) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfile")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = "" // レスポンスにパスワードを含めない
+	return user, nil
+}
+
+// UpdateProfile はユーザー名を変更します。
+func (s *UserService) UpdateProfile(ctx context.Context, userID uint, req models.UserUpdateProfileRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile")
+	defer span.End()
+	if err := s.userRepo.UpdateUsername(ctx, userID, req.Username); err != nil {
+		return nil, err
+	}
+	return s.GetProfile(ctx, userID)
+}
+
+// ChangePassword は現在のパスワードを確認してからパスワードを変更します。
+// token_version が加算されるため、既存のセッションはすべて無効になります。
+// 呼び出し元が新しいトークンを発行できるよう、更新後のユーザーを返します。
+func (s *UserService) ChangePassword(ctx context.Context, userID uint, req models.UserChangePasswordRequest, client models.ClientInfo) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.VerifyPassword(user.PasswordHash, req.CurrentPassword); err != nil {
+		return nil, ErrInvalidPassword
+	}
+	if err := s.ValidatePassword(req.NewPassword, user.Username, user.Email); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := repositories.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+	s.recordEvent(ctx, models.EventPasswordChanged, userID, 0, client, nil)
+
+	return s.GetProfile(ctx, userID)
+}
+
+// RequestEmailChange は新しいメールアドレス宛に確認メールを送信します。
+// メールアドレスはリンクが開かれた時点で変更されます。
+func (s *UserService) RequestEmailChange(ctx context.Context, userID uint, req models.UserChangeEmailRequest, client models.ClientInfo) error {
+	ctx, span := tracing.Start(ctx, "UserService.RequestEmailChange")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := repositories.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+		return ErrInvalidPassword
+	}
+
+	if _, err := s.userRepo.FindByEmail(ctx, req.NewEmail); err == nil {
+		return repositories.ErrDuplicateEmail
+	} else if err != repositories.ErrUserNotFound {
+		return err
+	}
+
+	if err := s.sendVerificationTo(ctx, user, req.NewEmail, models.VerificationPurposeChangeEmail); err != nil {
+		return err
+	}
+	s.recordEvent(ctx, models.EventEmailChangeRequested, userID, 0, client, map[string]string{"new_email": req.NewEmail})
+
+	// 旧アドレスにも変更依頼があったことを通知する
+	if err := s.mailer.Send(ctx, user.Email, "メールアドレス変更のお知らせ", fmt.Sprintf(
+		"メールアドレスを %s に変更するリクエストを受け付けました。\r\n心当たりがない場合はパスワードを変更してください。",
+		req.NewEmail,
+	)); err != nil {
+		logging.FromContext(ctx).Warn("failed to send email change notice", "user_id", userID, "error", err)
+	}
+	return nil
+}
+
+// DeleteAccount はパスワードを再確認してから退会手続きを行い、完全に削除される日時を返します。
+// 猶予期間中にログインすると退会は取り消されます。既存のセッションはすべて無効になります。
+func (s *UserService) DeleteAccount(ctx context.Context, userID uint, req models.UserDeleteAccountRequest, client models.ClientInfo) (time.Time, error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteAccount")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := repositories.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+		return time.Time{}, ErrInvalidPassword
+	}
+
+	deletionAt := time.Now().Add(s.deletionGracePeriod)
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, deletionAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	s.recordEvent(ctx, models.EventAccountDeletion, userID, 0, client, map[string]string{"deletion_at": deletionAt.UTC().Format(time.RFC3339)})
+
+	if err := s.mailer.Send(ctx, user.Email, "退会手続きのお知らせ", fmt.Sprintf(
+		"退会手続きを受け付けました。アカウントは %s に完全に削除されます。\r\nそれまでにログインすると退会を取り消せます。",
+		deletionAt.Format("2006-01-02 15:04"),
+	)); err != nil {
+		logging.FromContext(ctx).Warn("failed to send deletion notice", "user_id", userID, "error", err)
+	}
+	return deletionAt, nil
+}
+
+// PurgeScheduledDeletions は猶予期間を過ぎたユーザーを削除します。定期実行を想定しています。
+func (s *UserService) PurgeScheduledDeletions(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "UserService.PurgeScheduledDeletions")
+	defer span.End()
+	n, err := s.userRepo.DeleteScheduledBefore(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.Info("purged accounts scheduled for deletion", "count", n)
+	}
+	return nil
+}
+
+// ValidateSession はJWTのクレームが現在も有効かを確認し、最新のユーザー情報を返します。
+// パスワード変更などで token_version が進んでいる場合は ErrSessionInvalid を返します。
+func (s *UserService) ValidateSession(ctx context.Context, claims *models.JWTClaims) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ValidateSession")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrSessionInvalid
+		}
+		return nil, err
+	}
+	if user.TokenVersion != claims.TokenVersion || user.DisabledAt != nil {
+		return nil, ErrSessionInvalid
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/tracing"
+)
+
+const (
+	defaultUsersPerPage = 20
+)
+
+// ErrCannotModifySelf は管理者が自分自身のロール変更・無効化・削除を行おうとした場合のエラーです。
+var ErrCannotModifySelf = errors.New("cannot modify own account")
+
+// ListUsers は検索条件に一致するユーザーをページングして返します。
+func (s *UserService) ListUsers(ctx context.Context, filter models.UserListFilter) (*models.UserListResult, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultUsersPerPage
+	}
+
+	users, total, err := s.userRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		u.PasswordHash = ""
+	}
+	return &models.UserListResult{Users: users, Total: total, Page: filter.Page, PerPage: filter.PerPage}, nil
+}
+
+// ChangeRole はユーザーのロールを変更します。
+func (s *UserService) ChangeRole(ctx context.Context, actorID, userID uint, role string, client models.ClientInfo) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ChangeRole")
+	defer span.End()
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateRole(ctx, userID, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	if user.Role != role {
+		s.recordEvent(ctx, models.EventRoleChanged, userID, actorID, client, map[string]string{"from": user.Role, "to": role})
+	}
+	return s.GetProfile(ctx, userID)
+}
+
+// SetUserDisabled はユーザーを無効化・有効化します。無効化されたユーザーはログインできず、既存のセッションも失効します。
+func (s *UserService) SetUserDisabled(ctx context.Context, actorID, userID uint, disabled bool, client models.ClientInfo) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetUserDisabled")
+	defer span.End()
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetDisabled(ctx, userID, disabled); err != nil {
+		return nil, fmt.Errorf("failed to update user status: %w", err)
+	}
+	eventType := models.EventUserEnabled
+	if disabled {
+		eventType = models.EventUserDisabled
+	}
+	s.recordEvent(ctx, eventType, userID, actorID, client, nil)
+	return s.GetProfile(ctx, userID)
+}
+
+// ForcePasswordReset は現在のパスワードを使えなくし、既存のセッションを失効させたうえでリセットメールを送信します。
+func (s *UserService) ForcePasswordReset(ctx context.Context, actorID, userID uint, client models.ClientInfo) error {
+	ctx, span := tracing.Start(ctx, "UserService.ForcePasswordReset")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// 誰にも知られていないランダムなパスワードに置き換える
+	random, err := generateResetToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate password: %w", err)
+	}
+	hashedPassword, err := repositories.HashPassword(random)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	s.recordEvent(ctx, models.EventPasswordResetForced, userID, actorID, client, nil)
+
+	return s.ForgotPasswordUser(ctx, user.Email, client)
+}
+
+// SetPassword はユーザーのパスワードを管理者が直接設定します。既存のセッションはすべて失効します。
+// リセットメールを送れない環境（初期構築時など）で todoctl から使うためのものです。
+func (s *UserService) SetPassword(ctx context.Context, actorID, userID uint, newPassword string, client models.ClientInfo) error {
+	ctx, span := tracing.Start(ctx, "UserService.SetPassword")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidatePassword(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+	hashedPassword, err := repositories.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	s.recordEvent(ctx, models.EventPasswordResetForced, userID, actorID, client, map[string]string{"method": "set"})
+	return nil
+}
+
+// CreateUser は指定したロールでユーザーを作成します。
+// 管理者が作成するアカウントのため、メールアドレスは確認済みとし、確認メールは送信しません。
+func (s *UserService) CreateUser(ctx context.Context, req models.UserRegisterRequest, role string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+	if err := s.ValidatePassword(req.Password, req.Username, req.Email); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := repositories.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := s.userRepo.Create(ctx, &models.User{
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: hashedPassword,
+		Role:         role,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.MarkEmailVerified(ctx, uint(user.ID)); err != nil {
+		return nil, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	return s.GetProfile(ctx, uint(user.ID))
+}
+
+// DeleteUser はユーザーを猶予期間なしで削除します。
+func (s *UserService) DeleteUser(ctx context.Context, actorID, userID uint, client models.ClientInfo) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+	if actorID == userID {
+		return ErrCannotModifySelf
+	}
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	s.recordEvent(ctx, models.EventUserDeleted, userID, actorID, client, nil)
+	return nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/tracing"
+)
+
+// EmailVerificationMode はメールアドレス未確認のユーザーに対する制限の種類です。
+type EmailVerificationMode string
+
+const (
+	// EmailVerificationOptional は未確認でもすべての操作を許可します（デフォルト）。
+	EmailVerificationOptional EmailVerificationMode = "optional"
+	// EmailVerificationBeforeLogin は確認が済むまでログインを拒否します。
+	EmailVerificationBeforeLogin EmailVerificationMode = "login"
+	// EmailVerificationBeforeTodos は確認が済むまでTodoの作成を拒否します。
+	EmailVerificationBeforeTodos EmailVerificationMode = "todos"
+)
+
+// verificationTokenTTL は確認トークンの有効期限です。
+const verificationTokenTTL = 24 * time.Hour
+
+// ErrEmailNotVerified はメールアドレスが未確認のため操作できない場合のエラーです。
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// EmailVerificationMode は現在のメールアドレス確認モードを返します。
+func (s *UserService) EmailVerificationMode() EmailVerificationMode {
+	return s.verificationMode
+}
+
+// IsEmailVerified はユーザーのメールアドレスが確認済みかどうかを返します。
+func (s *UserService) IsEmailVerified(ctx context.Context, userID uint) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserService.IsEmailVerified")
+	defer span.End()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerifiedAt != nil, nil
+}
+
+// CheckLoginVerification は EmailVerificationBeforeLogin モードでメールアドレスが未確認のユーザーのログインを拒否します。
+// 拒否した場合はセキュリティイベントに記録し、ErrEmailNotVerified を返します。
+func (s *UserService) CheckLoginVerification(ctx context.Context, user *models.User, method string, client models.ClientInfo) error {
+	if s.verificationMode == EmailVerificationBeforeLogin && user.EmailVerifiedAt == nil {
+		s.recordLoginFailure(ctx, user, user.Email, method, "email_not_verified", client)
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// ResendVerification は確認メールを再送します。
+// 存在しない・確認済みのアドレスでもメールアドレスの存在が分からないよう成功扱いにします。
+func (s *UserService) ResendVerification(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "UserService.ResendVerification")
+	defer span.End()
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			logging.FromContext(ctx).Info("verification resend requested for unknown email", "email", email)
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return s.sendVerification(ctx, user)
+}
+
+// sendVerification は登録時の確認メールを送信します。
+func (s *UserService) sendVerification(ctx context.Context, user *models.User) error {
+	return s.sendVerificationTo(ctx, user, user.Email, models.VerificationPurposeVerify)
+}
+
+// VerifyEmail は確認トークンを検証し、メールアドレスを確認済みにします。
+func (s *UserService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "UserService.VerifyEmail")
+	defer span.End()
+	// 1. トークンを検証
+	vt, err := s.verifyTokenRepo.FindByToken(token)
+	if err != nil {
+		return fmt.Errorf("invalid or expired token")
+	}
+	if time.Now().After(vt.ExpiresAt) {
+		return fmt.Errorf("token expired")
+	}
+	if vt.UsedAt != nil {
+		return fmt.Errorf("token already used")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, vt.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid or expired token")
+	}
+
+	if vt.Purpose != models.VerificationPurposeChangeEmail && user.Email != vt.Email {
+		// 送信先アドレスが現在のアドレスと一致するか確認
+		return fmt.Errorf("token does not match current email")
+	}
+
+	// 2. 用途に応じて確認済みにする。同時に使用された場合は1回だけ成功する
+	switch vt.Purpose {
+	case models.VerificationPurposeChangeEmail:
+		// トークンの消費と新しいアドレスへの変更を1つのトランザクションで行う。
+		// アドレスが使用済みで変更できなかった場合、トークンは使用済みにならない
+		if err := s.userRepo.ChangeEmailWithToken(ctx, vt.ID, vt.UserID, vt.Email); err != nil {
+			switch {
+			case errors.Is(err, repositories.ErrVerificationTokenNotFound):
+				return fmt.Errorf("token already used")
+			case err == repositories.ErrDuplicateEmail:
+				return fmt.Errorf("email already in use")
+			}
+			return fmt.Errorf("failed to change email: %w", err)
+		}
+	default:
+		if err := s.verifyTokenRepo.MarkUsed(vt.ID); err != nil {
+			if errors.Is(err, repositories.ErrVerificationTokenNotFound) {
+				return fmt.Errorf("token already used")
+			}
+			return fmt.Errorf("failed to use token: %w", err)
+		}
+		if err := s.userRepo.MarkEmailVerified(ctx, vt.UserID); err != nil {
+			return fmt.Errorf("failed to verify email: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sendVerificationTo は確認トークンを発行し、email 宛に確認メールを送信します。
+func (s *UserService) sendVerificationTo(ctx context.Context, user *models.User, email, purpose string) error {
+	token, err := generateResetToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	vt := &models.EmailVerificationToken{
+		UserID:    uint(user.ID),
+		Email:     email,
+		Purpose:   purpose,
+		Token:     token,
+		ExpiresAt: time.Now().Add(verificationTokenTTL),
+	}
+	if err := s.verifyTokenRepo.Save(vt); err != nil {
+		return fmt.Errorf("failed to save verification token: %w", err)
+	}
+
+	verifyURL := fmt.Sprintf("%s/verify-email/%s", s.frontendURL, token)
+	return s.mailer.Send(ctx, email, "メールアドレスの確認", fmt.Sprintf(
+		"以下のURLからメールアドレスを確認してください。\r\n%s",
+		verifyURL,
+	))
+}
+
+// CleanupVerificationTokens は期限切れ・使用済みのメール確認トークンを削除します。
+func (s *UserService) CleanupVerificationTokens() error {
+	return s.verifyTokenRepo.CleanupExpired()
+}
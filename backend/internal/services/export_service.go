@@ -0,0 +1,108 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+)
+
+// ExportService はユーザーの個人データのエクスポートを扱います。
+type ExportService struct {
+	userRepo       repositories.UserStore
+	todoRepo       repositories.TodoStore
+	resetTokenRepo repositories.ResetTokenRepository
+}
+
+// NewExportService は新しいExportServiceを作成します。
+func NewExportService(userRepo repositories.UserStore, todoRepo repositories.TodoStore, resetTokenRepo repositories.ResetTokenRepository) *ExportService {
+	return &ExportService{userRepo: userRepo, todoRepo: todoRepo, resetTokenRepo: resetTokenRepo}
+}
+
+// UserExport はエクスポートされる個人データ一式です。
+type UserExport struct {
+	ExportedAt          time.Time            `json:"exported_at"`
+	Profile             *models.User         `json:"profile"`
+	Todos               []*models.Todo       `json:"todos"`
+	PasswordResetTokens []ExportedResetToken `json:"password_reset_tokens"`
+}
+
+// ExportedResetToken はリセットトークンの履歴です。トークンの値自体は含めません。
+type ExportedResetToken struct {
+	ID        uint       `json:"id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CollectUserData はユーザーの個人データを収集します。
+func (s *ExportService) CollectUserData(ctx context.Context, userID uint) (*UserExport, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = "" // パスワードハッシュは含めない
+
+	todos, err := s.todoRepo.FindByUserIDAcrossWorkspaces(ctx, int(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.resetTokenRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reset tokens: %w", err)
+	}
+	history := make([]ExportedResetToken, 0, len(tokens))
+	for _, t := range tokens {
+		history = append(history, ExportedResetToken{
+			ID:        t.ID,
+			ExpiresAt: t.ExpiresAt,
+			UsedAt:    t.UsedAt,
+			CreatedAt: t.CreatedAt,
+		})
+	}
+
+	return &UserExport{
+		ExportedAt:          time.Now(),
+		Profile:             user,
+		Todos:               todos,
+		PasswordResetTokens: history,
+	}, nil
+}
+
+// WriteJSON は個人データを1つのJSONドキュメントとして w に書き込みます。
+func (s *ExportService) WriteJSON(w io.Writer, data *UserExport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// WriteZip は個人データを種類ごとのJSONファイルに分けたZIPアーカイブとして w に書き込みます。
+func (s *ExportService) WriteZip(w io.Writer, data *UserExport) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		body interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"todos.json", data.Todos},
+		{"password_reset_tokens.json", data.PasswordResetTokens},
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: data.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+)
+
+// impersonationTTL はなりすましトークンの有効期限です。通常のログインより短くします。
+const impersonationTTL = 30 * time.Minute
+
+var (
+	// ErrImpersonationForbidden は権限を持つユーザー（管理者など）になりすまそうとした場合のエラーです。
+	ErrImpersonationForbidden = errors.New("cannot impersonate a privileged user")
+	// ErrImpersonatorInvalid はなりすましている管理者が無効化された・権限を失った場合のエラーです。
+	ErrImpersonatorInvalid = errors.New("impersonator is no longer allowed to impersonate")
+)
+
+// ImpersonationService は管理者によるユーザーへのなりすましを扱います。
+// なりすましの開始と、なりすまし中の操作はすべてセキュリティイベントに記録します。
+type ImpersonationService struct {
+	userRepo       repositories.UserStore
+	userService    *UserService
+	roleService    *RoleService
+	sessionService *SessionService
+	jwtService     *JWTService
+}
+
+// NewImpersonationService は新しいImpersonationServiceを作成します。
+func NewImpersonationService(userRepo repositories.UserStore, userService *UserService, roleService *RoleService, sessionService *SessionService, jwtService *JWTService) *ImpersonationService {
+	return &ImpersonationService{
+		userRepo:       userRepo,
+		userService:    userService,
+		roleService:    roleService,
+		sessionService: sessionService,
+		jwtService:     jwtService,
+	}
+}
+
+// Start は管理者 actorID が subjectID のユーザーになりすますためのトークンを発行します。
+// 権限を持つユーザーへのなりすましは権限の昇格になるため許可しません。
+func (s *ImpersonationService) Start(ctx context.Context, actorID, subjectID uint, reason string, client models.ClientInfo) (*models.ImpersonationResponse, error) {
+	if actorID == subjectID {
+		return nil, ErrCannotModifySelf
+	}
+	subject, err := s.userRepo.FindByID(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if subject.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	perms, err := s.roleService.Permissions(subject.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+	if len(perms) > 0 {
+		return nil, ErrImpersonationForbidden
+	}
+
+	session, err := s.sessionService.StartImpersonation(subject, actorID, client.IPAddress, client.UserAgent, impersonationTTL)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.jwtService.GenerateImpersonationToken(subject, actorID, session.ID, impersonationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	s.userService.recordEvent(ctx, models.EventImpersonationStarted, subjectID, actorID, client, map[string]string{
+		"reason":     reason,
+		"session_id": session.ID,
+	})
+
+	subject.PasswordHash = ""
+	return &models.ImpersonationResponse{Token: token, ExpiresAt: session.ExpiresAt, User: subject}, nil
+}
+
+// ValidateImpersonator はなりすましトークンの管理者が現在もなりすましを許可されているかを確認します。
+// 管理者が無効化された・権限を失った場合、発行済みのなりすましトークンも使えなくなります。
+func (s *ImpersonationService) ValidateImpersonator(ctx context.Context, actorID uint) error {
+	actor, err := s.userRepo.FindByID(ctx, actorID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return ErrImpersonatorInvalid
+		}
+		return err
+	}
+	if actor.DisabledAt != nil {
+		return ErrImpersonatorInvalid
+	}
+	perms, err := s.roleService.Permissions(actor.Role)
+	if err != nil {
+		return fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+	if !perms.Has(models.PermUsersImpersonate) {
+		return ErrImpersonatorInvalid
+	}
+	return nil
+}
+
+// RecordAction はなりすまし中に行われた操作を記録します。
+func (s *ImpersonationService) RecordAction(ctx context.Context, actorID, subjectID uint, method, path string, status int, client models.ClientInfo) {
+	s.userService.recordEvent(ctx, models.EventImpersonationAction, subjectID, actorID, client, map[string]string{
+		"method": method,
+		"path":   path,
+		"status": fmt.Sprint(status),
+	})
+}
@@ -3,8 +3,6 @@ package services
 
 import (
 	"fmt"
-	"log"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,28 +10,52 @@ import (
 	"go-next-todo/backend/internal/models"
 )
 
+// TokenTTL はJWTの有効期限です。セッションの有効期限も同じ長さになります。
+const TokenTTL = 24 * time.Hour
+
 // JWTService はJWTトークンの生成と検証を扱います。
 type JWTService struct {
 	secret []byte
 }
 
-// NewJWTService は新しいJWTServiceを作成します。
-func NewJWTService() *JWTService {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("JWT_SECRET environment variable not set")
-	}
+// NewJWTService は署名鍵 secret を使う新しいJWTServiceを作成します。
+func NewJWTService(secret string) *JWTService {
 	return &JWTService{secret: []byte(secret)}
 }
 
 // GenerateToken はJWTトークンを生成します。
-func (s *JWTService) GenerateToken(userID uint, email, role string) (string, error) {
+// tokenVersion はユーザーの token_version で、パスワード変更後の古いトークンを検出するために使います。
+// sessionID はトークンを発行したセッションのIDで、セッションが失効したトークンを検出するために使います。
+func (s *JWTService) GenerateToken(userID uint, email, role string, tokenVersion int, sessionID string) (string, error) {
 	claims := &jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
 		"role":    role,
+		"ver":     tokenVersion,
+		"sid":     sessionID,
+		"iat":     time.Now().Unix(),
+		"exp":     time.Now().Add(TokenTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT token: %w", err)
+	}
+	return tokenString, nil
+}
+
+// GenerateImpersonationToken は管理者 actorID が user になりすますためのJWTを ttl の有効期限で生成します。
+// 対象ユーザーのクレームに加え、なりすましている管理者を "act" クレーム（RFC 8693）に含めます。
+func (s *JWTService) GenerateImpersonationToken(user *models.User, actorID uint, sessionID string, ttl time.Duration) (string, error) {
+	claims := &jwt.MapClaims{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"role":    user.Role,
+		"ver":     user.TokenVersion,
+		"sid":     sessionID,
+		"act":     map[string]interface{}{"user_id": actorID},
 		"iat":     time.Now().Unix(),
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"exp":     time.Now().Add(ttl).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(s.secret)
@@ -69,10 +91,28 @@ func (s *JWTService) ValidateToken(tokenString string) (*models.JWTClaims, error
 		if !ok {
 			return nil, fmt.Errorf("invalid role")
 		}
+		// ver を持たない古いトークンはバージョン0として扱う
+		version, _ := claims["ver"].(float64)
+		sessionID, _ := claims["sid"].(string)
+		var actorID uint
+		if act, ok := claims["act"]; ok {
+			actor, ok := act.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid act")
+			}
+			actorIDFloat, ok := actor["user_id"].(float64)
+			if !ok || actorIDFloat <= 0 {
+				return nil, fmt.Errorf("invalid act")
+			}
+			actorID = uint(actorIDFloat)
+		}
 		return &models.JWTClaims{
-			UserID: uint(userIDFloat),
-			Email:  email,
-			Role:   role,
+			UserID:       uint(userIDFloat),
+			Email:        email,
+			Role:         role,
+			TokenVersion: int(version),
+			SessionID:    sessionID,
+			ActorID:      actorID,
 		}, nil
 	}
 
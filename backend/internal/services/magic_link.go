@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/tracing"
+)
+
+// magicLinkTTL はログインリンクの有効期限です。
+const magicLinkTTL = 15 * time.Minute
+
+// ErrMagicLinkInvalid はログインリンクが不明・期限切れ・使用済みの場合のエラーです。
+var ErrMagicLinkInvalid = errors.New("invalid or expired login link")
+
+// RequestMagicLink はログインリンクをメールで送信します。
+// 存在しない・無効化されたアカウントでもメールアドレスの存在が分からないよう成功扱いにします。
+func (s *UserService) RequestMagicLink(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "UserService.RequestMagicLink")
+	defer span.End()
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			logging.FromContext(ctx).Info("login link requested for unknown email", "email", email)
+			return nil
+		}
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	token, err := generateResetToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate login token: %w", err)
+	}
+
+	// 以前に発行したリンクを無効にし、最新のリンクだけを使えるようにする
+	if err := s.magicLinkRepo.InvalidateForUser(uint(user.ID)); err != nil {
+		return fmt.Errorf("failed to invalidate previous login tokens: %w", err)
+	}
+	mt := &models.MagicLinkToken{
+		UserID:    uint(user.ID),
+		Token:     token,
+		ExpiresAt: time.Now().Add(magicLinkTTL),
+	}
+	if err := s.magicLinkRepo.Save(mt); err != nil {
+		return fmt.Errorf("failed to save login token: %w", err)
+	}
+
+	loginURL := fmt.Sprintf("%s/login/magic-link/%s", s.frontendURL, token)
+	if err := s.mailer.Send(ctx, email, "ログインリンク", fmt.Sprintf(
+		"以下のURLからログインしてください。リンクの有効期限は%d分で、1回のみ使用できます。\r\n%s",
+		int(magicLinkTTL.Minutes()), loginURL,
+	)); err != nil {
+		logging.FromContext(ctx).Warn("failed to send login link email", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+// ExchangeMagicLink はログインリンクのトークンを検証し、ログインしたユーザーを返します。
+// リンクを開けたことでメールアドレスの所有が確認できるため、未確認のアドレスは確認済みにします。
+func (s *UserService) ExchangeMagicLink(ctx context.Context, token string, client models.ClientInfo) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ExchangeMagicLink")
+	defer span.End()
+	// 1. トークンを検証
+	mt, err := s.magicLinkRepo.FindByToken(token)
+	if err != nil {
+		if err == repositories.ErrMagicLinkTokenNotFound {
+			return nil, ErrMagicLinkInvalid
+		}
+		return nil, err
+	}
+	if mt.UsedAt != nil || time.Now().After(mt.ExpiresAt) {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	// 2. トークンを使用済みにする（同時に使用された場合は1回だけ成功する）
+	if err := s.magicLinkRepo.MarkUsed(mt.ID); err != nil {
+		if err == repositories.ErrMagicLinkTokenNotFound {
+			return nil, ErrMagicLinkInvalid
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, mt.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrMagicLinkInvalid
+		}
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		s.recordLoginFailure(ctx, user, user.Email, LoginMethodMagicLink, "account_disabled", client)
+		return nil, ErrAccountDisabled
+	}
+
+	// 3. メールアドレスを確認済みにする
+	if user.EmailVerifiedAt == nil {
+		if err := s.userRepo.MarkEmailVerified(ctx, mt.UserID); err != nil {
+			return nil, fmt.Errorf("failed to verify email: %w", err)
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	return s.completeLogin(ctx, user)
+}
+
+// CleanupMagicLinks は期限切れ・使用済みのログインリンクを削除します。
+func (s *UserService) CleanupMagicLinks() error {
+	return s.magicLinkRepo.CleanupExpired()
+}
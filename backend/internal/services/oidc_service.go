@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"go-next-todo/backend/internal/config"
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+)
+
+// OIDCAuthRequestTTL は認可リクエスト（state）の有効期限です。
+const OIDCAuthRequestTTL = 10 * time.Minute
+
+var (
+	// ErrOIDCDisabled はOIDCログインが設定されていない場合のエラーです。
+	ErrOIDCDisabled = errors.New("oidc login is not configured")
+	// ErrOIDCStateInvalid は state が不明・期限切れ・使用済みの場合のエラーです。
+	ErrOIDCStateInvalid = errors.New("invalid or expired oidc state")
+	// ErrOIDCTokenInvalid はIDトークンの検証に失敗した場合のエラーです。
+	ErrOIDCTokenInvalid = errors.New("invalid oidc id token")
+	// ErrOIDCEmailNotVerified はIDプロバイダーで未確認のメールアドレスを既存ユーザーに紐付けようとした場合のエラーです。
+	ErrOIDCEmailNotVerified = errors.New("oidc email not verified")
+)
+
+// oidcClaims はIDトークンから読み取るクレームです。
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+}
+
+// OIDCService はOIDCの認可コードフロー（PKCE付き）でユーザーを認証します。
+type OIDCService struct {
+	cfg      config.OIDC
+	oidcRepo repositories.OIDCRepository
+	userRepo repositories.UserStore
+
+	mu           sync.Mutex
+	verifier     *oidc.IDTokenVerifier
+	oauth2Config *oauth2.Config
+}
+
+// NewOIDCService は新しいOIDCServiceを作成します。
+// IDプロバイダーのディスカバリーは最初のリクエスト時に行います。
+func NewOIDCService(cfg config.OIDC, oidcRepo repositories.OIDCRepository, userRepo repositories.UserStore) *OIDCService {
+	return &OIDCService{cfg: cfg, oidcRepo: oidcRepo, userRepo: userRepo}
+}
+
+// Enabled はOIDCログインが利用可能かを返します。
+func (s *OIDCService) Enabled() bool {
+	return s.cfg.Enabled()
+}
+
+// client はディスカバリー済みのOAuth2設定とIDトークン検証器を返します。
+// ディスカバリーに失敗した場合は次回のリクエストで再試行します。
+func (s *OIDCService) client(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	if !s.cfg.Enabled() {
+		return nil, nil, ErrOIDCDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oauth2Config != nil {
+		return s.oauth2Config, s.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, s.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+	s.oauth2Config = &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, s.cfg.Scopes...),
+	}
+	s.verifier = provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID})
+	return s.oauth2Config, s.verifier, nil
+}
+
+// AuthCodeURL は state・nonce・PKCE code_verifier を発行して保存し、IDプロバイダーの認可URLと state を返します。
+// 呼び出し側は state をログインを開始したブラウザに紐付け、コールバックで同じブラウザからのリクエストか確認してください。
+func (s *OIDCService) AuthCodeURL(ctx context.Context) (authURL, state string, err error) {
+	conf, _, err := s.client(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err = generateResetToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	nonce, err := generateResetToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	req := &models.OIDCAuthRequest{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(OIDCAuthRequestTTL),
+	}
+	if err := s.oidcRepo.SaveAuthRequest(req); err != nil {
+		return "", "", err
+	}
+
+	return conf.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), state, nil
+}
+
+// Callback は認可コードをトークンと交換し、IDトークンを検証してユーザーを返します。
+// 紐付け済みのアカウントがなければ、既存ユーザーへの紐付けまたは新規作成を行います。
+// 返したユーザーのログインは UserService.CompleteExternalLogin で完了してください。
+func (s *OIDCService) Callback(ctx context.Context, code, state string) (*models.User, error) {
+	conf, verifier, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. state を検証（1回限り）
+	authReq, err := s.oidcRepo.ConsumeAuthRequest(state)
+	if err != nil {
+		if err == repositories.ErrOIDCStateNotFound {
+			return nil, ErrOIDCStateInvalid
+		}
+		return nil, err
+	}
+	if time.Now().After(authReq.ExpiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	// 2. 認可コードをトークンと交換
+	token, err := conf.Exchange(ctx, code, oauth2.VerifierOption(authReq.CodeVerifier))
+	if err != nil {
+		logging.FromContext(ctx).Warn("oidc code exchange failed", "error", err)
+		return nil, ErrOIDCTokenInvalid
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrOIDCTokenInvalid
+	}
+
+	// 3. IDトークンを検証
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		logging.FromContext(ctx).Warn("oidc id token verification failed", "error", err)
+		return nil, ErrOIDCTokenInvalid
+	}
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, ErrOIDCTokenInvalid
+	}
+	if claims.Nonce != authReq.Nonce {
+		return nil, ErrOIDCTokenInvalid
+	}
+
+	// 4. ユーザーを紐付け・作成
+	return s.linkOrCreateUser(ctx, idToken.Issuer, idToken.Subject, claims)
+}
+
+// linkOrCreateUser は外部アカウントに対応するユーザーを返します。
+// 未紐付けの場合、確認済みメールアドレスが一致する既存ユーザーに紐付け、該当がなければユーザーを作成します。
+// 既存ユーザーのメールアドレスが未確認の場合、第三者が先にそのアドレスで登録した可能性があるため、
+// 紐付けの前にパスワードをリセットし、発行済みのトークンを失効させます。
+func (s *OIDCService) linkOrCreateUser(ctx context.Context, issuer, subject string, claims oidcClaims) (*models.User, error) {
+	identity, err := s.oidcRepo.FindIdentity(issuer, subject)
+	if err == nil {
+		return s.userRepo.FindByID(ctx, uint(identity.UserID))
+	}
+	if err != repositories.ErrIdentityNotFound {
+		return nil, err
+	}
+
+	if claims.Email == "" {
+		return nil, ErrOIDCTokenInvalid
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// 未確認のメールアドレスで既存アカウントを乗っ取られないようにする
+		if !claims.EmailVerified {
+			return nil, ErrOIDCEmailNotVerified
+		}
+		if user.EmailVerifiedAt == nil {
+			if user, err = s.reclaimUnverifiedUser(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+	case err == repositories.ErrUserNotFound:
+		if user, err = s.createUser(ctx, claims); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := s.oidcRepo.LinkIdentity(&models.UserIdentity{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: subject,
+		Email:   claims.Email,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// reclaimUnverifiedUser はメールアドレスが未確認の既存ユーザーを、IDプロバイダーで確認されたアドレスの持ち主に引き渡します。
+// パスワードをランダムな値に変更して発行済みのトークンを失効させ、メールアドレスを確認済みにします。
+func (s *OIDCService) reclaimUnverifiedUser(ctx context.Context, user *models.User) (*models.User, error) {
+	hashed, err := randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, uint(user.ID), hashed); err != nil {
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+	if err := s.userRepo.MarkEmailVerified(ctx, uint(user.ID)); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Warn("reset password of unverified user before linking oidc identity", "user_id", user.ID)
+	return s.userRepo.FindByID(ctx, uint(user.ID))
+}
+
+// randomPasswordHash はランダムなパスワードのハッシュを返します。
+// 設定したユーザーがパスワードでログインするにはパスワードリセットが必要です。
+func randomPasswordHash() (string, error) {
+	password, err := generateResetToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	hashed, err := repositories.HashPassword(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hashed, nil
+}
+
+// createUser はIDプロバイダーのクレームからユーザーを作成します。
+// パスワードはランダムな値を設定し、パスワードでのログインにはパスワードリセットが必要です。
+func (s *OIDCService) createUser(ctx context.Context, claims oidcClaims) (*models.User, error) {
+	hashed, err := randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	// ユーザー名が重複する場合はランダムな接尾辞を付けて再試行する。メールアドレスの重複は再試行しない
+	username := base
+	for attempt := 0; ; attempt++ {
+		user := &models.User{
+			Username:     username,
+			Email:        claims.Email,
+			PasswordHash: hashed,
+			Role:         models.RoleUser,
+		}
+		created, err := s.userRepo.Create(ctx, user)
+		if err == nil {
+			if claims.EmailVerified {
+				if err := s.userRepo.MarkEmailVerified(ctx, uint(created.ID)); err != nil {
+					return nil, err
+				}
+			}
+			return s.userRepo.FindByID(ctx, uint(created.ID))
+		}
+		if err != repositories.ErrDuplicateUsername || attempt >= 3 {
+			return nil, err
+		}
+		suffix, err := generateResetToken()
+		if err != nil {
+			return nil, err
+		}
+		username = fmt.Sprintf("%s_%s", base, suffix[:6])
+	}
+}
+
+// CleanupExpired は期限切れの認可リクエストを削除します。
+func (s *OIDCService) CleanupExpired() error {
+	return s.oidcRepo.CleanupExpired()
+}
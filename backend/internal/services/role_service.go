@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+)
+
+var (
+	// ErrBuiltInRole は組み込みロールを変更・削除しようとした場合のエラーです。
+	ErrBuiltInRole = errors.New("built-in roles cannot be modified")
+	// ErrRoleInUse はユーザーに割り当てられているロールを削除しようとした場合のエラーです。
+	ErrRoleInUse = errors.New("role is assigned to users")
+	// ErrUnknownPermission は定義されていない権限を指定した場合のエラーです。
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrPermissionEscalation は自分が持たない権限を含むロールを割り当てようとした場合のエラーです。
+	ErrPermissionEscalation = errors.New("role grants permissions the actor does not have")
+)
+
+// RoleService はロールと権限に関するビジネスロジックを扱います。
+type RoleService struct {
+	roleRepo repositories.RoleRepository
+}
+
+// NewRoleService は新しいRoleServiceを作成します。
+func NewRoleService(roleRepo repositories.RoleRepository) *RoleService {
+	return &RoleService{roleRepo: roleRepo}
+}
+
+// EnsureBuiltInRoles は組み込みロールを作成し、権限を最新の定義に同期します。起動時に呼び出します。
+func (s *RoleService) EnsureBuiltInRoles() error {
+	for i := range models.BuiltInRoles {
+		if err := s.roleRepo.Upsert(&models.BuiltInRoles[i]); err != nil {
+			return fmt.Errorf("failed to ensure role %q: %w", models.BuiltInRoles[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// Permissions はロールに付与された権限を返します。リクエストごとに呼び出され、ロールの変更は即座に反映されます。
+func (s *RoleService) Permissions(roleName string) (models.PermissionSet, error) {
+	perms, err := s.roleRepo.PermissionsFor(roleName)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPermissionSet(perms), nil
+}
+
+// CheckAssignable は actorPerms を持つユーザーが roleName を他のユーザーに割り当てられるかを確認します。
+// ロールの権限が actorPerms に含まれない場合は権限の昇格になるため ErrPermissionEscalation を返します。
+func (s *RoleService) CheckAssignable(actorPerms models.PermissionSet, roleName string) error {
+	perms, err := s.roleRepo.PermissionsFor(roleName)
+	if err != nil {
+		return err
+	}
+	for _, p := range perms {
+		if !actorPerms.Has(p) {
+			return ErrPermissionEscalation
+		}
+	}
+	return nil
+}
+
+// ListRoles はすべてのロールを返します。
+func (s *RoleService) ListRoles() ([]*models.Role, error) {
+	return s.roleRepo.List()
+}
+
+// GetRole は指定した名前のロールを返します。
+func (s *RoleService) GetRole(name string) (*models.Role, error) {
+	return s.roleRepo.FindByName(name)
+}
+
+// CreateRole はカスタムロールを作成します。
+func (s *RoleService) CreateRole(req models.RoleCreateRequest) (*models.Role, error) {
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+	role := &models.Role{Name: req.Name, Description: req.Description, Permissions: req.Permissions}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.FindByName(req.Name)
+}
+
+// UpdateRole はカスタムロールの説明と権限を更新します。
+func (s *RoleService) UpdateRole(name string, req models.RoleUpdateRequest) (*models.Role, error) {
+	existing, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing.BuiltIn {
+		return nil, ErrBuiltInRole
+	}
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+	role := &models.Role{Name: name, Description: req.Description, Permissions: req.Permissions}
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.FindByName(name)
+}
+
+// DeleteRole はユーザーに割り当てられていないカスタムロールを削除します。
+func (s *RoleService) DeleteRole(name string) error {
+	existing, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return err
+	}
+	if existing.BuiltIn {
+		return ErrBuiltInRole
+	}
+	n, err := s.roleRepo.CountUsers(name)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrRoleInUse
+	}
+	return s.roleRepo.Delete(name)
+}
+
+// validatePermissions は権限名がすべて定義済みかを確認します。
+func validatePermissions(perms []string) error {
+	for _, p := range perms {
+		if !models.IsValidPermission(p) {
+			return fmt.Errorf("%w: %s", ErrUnknownPermission, p)
+		}
+	}
+	return nil
+}
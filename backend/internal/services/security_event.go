@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/metrics"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/tracing"
+)
+
+const (
+	defaultSecurityEventsPerPage = 50
+	// recentSecurityActivityLimit はユーザー向けに返す最近のセキュリティイベントの件数です。
+	recentSecurityActivityLimit = 20
+	// maxEventUserAgentLength は記録する User-Agent の最大長です。
+	maxEventUserAgentLength = 512
+)
+
+// ログイン方法です。login.succeeded / login.failed イベントの metadata に記録します。
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodOIDC      = "oidc"
+)
+
+// RecordSecurityEvent はセキュリティイベントを記録します。
+// 記録に失敗しても元の操作は失敗させず、ログに出力するだけにします。
+func (s *UserService) RecordSecurityEvent(ctx context.Context, event *models.SecurityEvent) {
+	ctx, span := tracing.Start(ctx, "UserService.RecordSecurityEvent")
+	defer span.End()
+	if len(event.UserAgent) > maxEventUserAgentLength {
+		event.UserAgent = event.UserAgent[:maxEventUserAgentLength]
+	}
+	if err := s.securityEventRepo.Create(event); err != nil {
+		logging.FromContext(ctx).Error("failed to record security event", "event_type", event.Type, "error", err)
+	}
+}
+
+// recordEvent は userID のユーザーに関するイベントを記録します。actorID が0の場合は本人の操作として記録します。
+func (s *UserService) recordEvent(ctx context.Context, eventType string, userID, actorID uint, client models.ClientInfo, metadata map[string]string) {
+	if actorID == 0 {
+		actorID = userID
+	}
+	s.RecordSecurityEvent(ctx, &models.SecurityEvent{
+		Type:      eventType,
+		UserID:    idPtr(userID),
+		ActorID:   idPtr(actorID),
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Metadata:  metadata,
+	})
+}
+
+// RecordLogin はログインの成功を記録します。トークンを発行した時点で呼び出します。
+func (s *UserService) RecordLogin(ctx context.Context, user *models.User, method string, client models.ClientInfo) {
+	ctx, span := tracing.Start(ctx, "UserService.RecordLogin")
+	defer span.End()
+	metrics.ObserveLogin(method, "")
+	s.recordEvent(ctx, models.EventLoginSucceeded, uint(user.ID), 0, client, map[string]string{"method": method})
+}
+
+// recordLoginFailure はログインの失敗を記録します。存在しないメールアドレスの場合は user を nil にします。
+func (s *UserService) recordLoginFailure(ctx context.Context, user *models.User, email, method, reason string, client models.ClientInfo) {
+	metrics.ObserveLogin(method, reason)
+	event := &models.SecurityEvent{
+		Type:      models.EventLoginFailed,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Metadata:  map[string]string{"method": method, "reason": reason},
+	}
+	if user != nil {
+		event.UserID = &user.ID
+		event.ActorID = &user.ID
+	} else {
+		event.Metadata["email"] = email
+	}
+	s.RecordSecurityEvent(ctx, event)
+}
+
+// ListSecurityEvents は検索条件に一致するセキュリティイベントを新しい順にページングして返します。
+func (s *UserService) ListSecurityEvents(ctx context.Context, filter models.SecurityEventFilter) (*models.SecurityEventListResult, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListSecurityEvents")
+	defer span.End()
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultSecurityEventsPerPage
+	}
+
+	events, total, err := s.securityEventRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SecurityEventListResult{Events: events, Total: total, Page: filter.Page, PerPage: filter.PerPage}, nil
+}
+
+// RecentSecurityActivity はユーザー本人に関する最近のセキュリティイベントを返します。
+func (s *UserService) RecentSecurityActivity(ctx context.Context, userID uint) ([]*models.SecurityEvent, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RecentSecurityActivity")
+	defer span.End()
+	return s.securityEventRepo.ListForUser(int(userID), recentSecurityActivityLimit)
+}
+
+func idPtr(id uint) *int {
+	if id == 0 {
+		return nil
+	}
+	v := int(id)
+	return &v
+}
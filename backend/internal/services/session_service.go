@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-next-todo/backend/internal/logging"
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+)
+
+// sessionTouchInterval は最終アクセス日時を更新する最小間隔です。リクエストごとの書き込みを避けるために使います。
+const sessionTouchInterval = time.Minute
+
+// maxUserAgentLength は保存する User-Agent の最大長です。
+const maxUserAgentLength = 512
+
+// SessionService はログインセッションの発行・検証・失効を扱います。
+type SessionService struct {
+	sessionRepo repositories.SessionRepository
+}
+
+// NewSessionService は新しいSessionServiceを作成します。
+func NewSessionService(sessionRepo repositories.SessionRepository) *SessionService {
+	return &SessionService{sessionRepo: sessionRepo}
+}
+
+// Start はログインしたユーザーのセッションを作成します。有効期限はJWTと同じです。
+func (s *SessionService) Start(user *models.User, ipAddress, userAgent string) (*models.Session, error) {
+	return s.start(user, ipAddress, userAgent, TokenTTL, nil)
+}
+
+// StartImpersonation は管理者 actorID が user になりすますためのセッションを ttl の有効期限で作成します。
+// セッションは user のセッション一覧にも表示されるため、本人もなりすましに気付いて失効させることができます。
+func (s *SessionService) StartImpersonation(user *models.User, actorID uint, ipAddress, userAgent string, ttl time.Duration) (*models.Session, error) {
+	impersonatorID := int(actorID)
+	return s.start(user, ipAddress, userAgent, ttl, &impersonatorID)
+}
+
+func (s *SessionService) start(user *models.User, ipAddress, userAgent string, ttl time.Duration, impersonatorID *int) (*models.Session, error) {
+	id, err := generateResetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:           id,
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		Device:       describeDevice(userAgent),
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		CreatedAt:    now,
+		LastSeenAt:   now,
+		ExpiresAt:    now.Add(ttl),
+	}
+	session.ImpersonatorID = impersonatorID
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Validate はトークンのセッションが有効かを検証し、最終アクセス日時を更新します。
+// セッションを持たないトークンや、失効・期限切れのセッションは ErrSessionInvalid になります。
+func (s *SessionService) Validate(ctx context.Context, claims *models.JWTClaims, ipAddress string) (*models.Session, error) {
+	if claims.SessionID == "" {
+		return nil, ErrSessionInvalid
+	}
+	session, err := s.sessionRepo.FindByID(claims.SessionID)
+	if err != nil {
+		if err == repositories.ErrSessionNotFound {
+			return nil, ErrSessionInvalid
+		}
+		return nil, err
+	}
+	if session.UserID != int(claims.UserID) || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionInvalid
+	}
+	// なりすましのトークンはなりすましのセッションでのみ有効（逆も同様）
+	if claims.ActorID != 0 {
+		if session.ImpersonatorID == nil || *session.ImpersonatorID != int(claims.ActorID) {
+			return nil, ErrSessionInvalid
+		}
+	} else if session.ImpersonatorID != nil {
+		return nil, ErrSessionInvalid
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeenAt) >= sessionTouchInterval || session.IPAddress != ipAddress {
+		// 更新に失敗してもリクエストは続行する
+		if err := s.sessionRepo.Touch(session.ID, ipAddress, now); err != nil {
+			logging.FromContext(ctx).Warn("failed to update session last seen", "error", err)
+		} else {
+			session.LastSeenAt = now
+			session.IPAddress = ipAddress
+		}
+	}
+	return session, nil
+}
+
+// ListSessions はユーザーの有効なセッションを返します。currentID のセッションには Current を設定します。
+func (s *SessionService) ListSessions(userID int, currentID string) ([]*models.Session, error) {
+	sessions, err := s.sessionRepo.ListActiveForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession はユーザーのセッションを失効させ、そのセッションのトークンでのアクセスを拒否します。
+func (s *SessionService) RevokeSession(userID int, sessionID string) error {
+	return s.sessionRepo.Revoke(userID, sessionID)
+}
+
+// CleanupExpired は期限切れのセッションを削除します。
+func (s *SessionService) CleanupExpired() error {
+	return s.sessionRepo.CleanupExpired()
+}
+
+// describeDevice は User-Agent から "Chrome on macOS" のような表示用の端末名を作ります。
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/"), strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/"), strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/"):
+		browser = "curl"
+	}
+
+	platform := "Unknown OS"
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	return browser + " on " + platform
+}
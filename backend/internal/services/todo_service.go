@@ -1,67 +1,97 @@
 package services
 
 import (
+	"context"
+
+	"go-next-todo/backend/internal/metrics"
 	"go-next-todo/backend/internal/models"
 	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/tracing"
 )
 
 // TodoService はTodo関連のビジネスロジックを扱います。
 type TodoService struct {
-	todoRepo *repositories.TodoRepository
+	todoRepo repositories.TodoStore
 }
 
 // NewTodoService は新しいTodoServiceを作成します。
-func NewTodoService(todoRepo *repositories.TodoRepository) *TodoService {
+func NewTodoService(todoRepo repositories.TodoStore) *TodoService {
 	return &TodoService{todoRepo: todoRepo}
 }
 
-// CreateTodo は新しいTodoを作成します。
-func (s *TodoService) CreateTodo(todo *models.Todo, userID int) (*models.Todo, error) {
+// CreateTodo はワークスペースに新しいTodoを作成します。
+func (s *TodoService) CreateTodo(ctx context.Context, workspaceID int, todo *models.Todo, userID int) (*models.Todo, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.CreateTodo")
+	defer span.End()
 	todo.UserID = userID
-	return s.todoRepo.Create(todo)
+	todo.WorkspaceID = workspaceID
+	created, err := s.todoRepo.Create(ctx, todo)
+	if err != nil {
+		return nil, err
+	}
+	metrics.TodosCreated.Inc()
+	if created.Completed {
+		metrics.TodosCompleted.Inc()
+	}
+	return created, nil
 }
 
-// GetTodos はユーザーのTodoを取得します。adminの場合は全Todo。
-func (s *TodoService) GetTodos(userID int, userRole string) ([]*models.Todo, error) {
-	if userRole == "admin" {
-		return s.todoRepo.FindAll()
+// GetTodos はワークスペース内のユーザーのTodoを取得します。todos.read.any 権限がある場合はワークスペースの全Todo。
+func (s *TodoService) GetTodos(ctx context.Context, workspaceID, userID int, perms models.PermissionSet) ([]*models.Todo, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetTodos")
+	defer span.End()
+	if perms.Has(models.PermTodosReadAny) {
+		return s.todoRepo.FindAll(ctx, workspaceID)
 	}
-	return s.todoRepo.FindByUserID(userID)
+	return s.todoRepo.FindByUserID(ctx, workspaceID, userID)
 }
 
 // GetTodoByID は指定IDのTodoを取得し、認可チェックを行います。
-func (s *TodoService) GetTodoByID(id, userID int, userRole string) (*models.Todo, error) {
-	todo, err := s.todoRepo.FindByID(id)
+func (s *TodoService) GetTodoByID(ctx context.Context, workspaceID, id, userID int, perms models.PermissionSet) (*models.Todo, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetTodoByID")
+	defer span.End()
+	todo, err := s.todoRepo.FindByID(ctx, workspaceID, id)
 	if err != nil {
 		return nil, err
 	}
-	if todo.UserID != userID && userRole != "admin" {
+	if todo.UserID != userID && !perms.Has(models.PermTodosReadAny) {
 		return nil, repositories.ErrTodoForbidden // アクセス拒否
 	}
 	return todo, nil
 }
 
 // UpdateTodo はTodoを更新し、認可チェックを行います。
-func (s *TodoService) UpdateTodo(id int, updateTodo *models.Todo, userID int, userRole string) (*models.Todo, error) {
-	existingTodo, err := s.todoRepo.FindByID(id)
+func (s *TodoService) UpdateTodo(ctx context.Context, workspaceID, id int, updateTodo *models.Todo, userID int, perms models.PermissionSet) (*models.Todo, error) {
+	ctx, span := tracing.Start(ctx, "TodoService.UpdateTodo")
+	defer span.End()
+	existingTodo, err := s.todoRepo.FindByID(ctx, workspaceID, id)
 	if err != nil {
 		return nil, err
 	}
-	if existingTodo.UserID != userID && userRole != "admin" {
+	if existingTodo.UserID != userID && !perms.Has(models.PermTodosWriteAny) {
 		return nil, repositories.ErrTodoForbidden
 	}
 	updateTodo.UserID = existingTodo.UserID // 元の所有者を保持
-	return s.todoRepo.Update(id, updateTodo)
+	updated, err := s.todoRepo.Update(ctx, workspaceID, id, updateTodo)
+	if err != nil {
+		return nil, err
+	}
+	if !existingTodo.Completed && updated.Completed {
+		metrics.TodosCompleted.Inc()
+	}
+	return updated, nil
 }
 
 // DeleteTodo はTodoを削除し、認可チェックを行います。
-func (s *TodoService) DeleteTodo(id, userID int, userRole string) error {
-	existingTodo, err := s.todoRepo.FindByID(id)
+func (s *TodoService) DeleteTodo(ctx context.Context, workspaceID, id, userID int, perms models.PermissionSet) error {
+	ctx, span := tracing.Start(ctx, "TodoService.DeleteTodo")
+	defer span.End()
+	existingTodo, err := s.todoRepo.FindByID(ctx, workspaceID, id)
 	if err != nil {
 		return err
 	}
-	if existingTodo.UserID != userID && userRole != "admin" {
+	if existingTodo.UserID != userID && !perms.Has(models.PermTodosWriteAny) {
 		return repositories.ErrTodoForbidden
 	}
-	return s.todoRepo.Delete(id)
+	return s.todoRepo.Delete(ctx, workspaceID, id)
 }
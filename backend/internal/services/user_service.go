@@ -1,34 +1,107 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
-	"net/smtp"
-	"os"
+	"log/slog"
 	"time"
 
+	"go-next-todo/backend/internal/config"
+	"go-next-todo/backend/internal/logging"
 	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/password"
 	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/tracing"
+)
+
+var (
+	// ErrInvalidPassword は本人確認用のパスワードが一致しない場合のエラーです。
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrSessionInvalid はJWTが発行後に無効化されている場合のエラーです。
+	ErrSessionInvalid = errors.New("session is no longer valid")
+	// ErrAccountDisabled は管理者によって無効化されたアカウントでログインしようとした場合のエラーです。
+	ErrAccountDisabled = errors.New("account disabled")
 )
 
 // UserService はユーザー関連のビジネスロジックを扱います。
 type UserService struct {
-	userRepo       *repositories.UserRepository
-	resetTokenRepo repositories.ResetTokenRepository
+	userRepo            repositories.UserStore
+	resetTokenRepo      repositories.ResetTokenRepository
+	verifyTokenRepo     repositories.VerificationTokenRepository
+	magicLinkRepo       repositories.MagicLinkTokenRepository
+	securityEventRepo   repositories.SecurityEventRepository
+	verificationMode    EmailVerificationMode
+	passwordPolicy      password.Policy
+	frontendURL         string
+	deletionGracePeriod time.Duration
+	mailer              Mailer
+}
+
+// UserServiceOptions は UserService の設定です。
+type UserServiceOptions struct {
+	// FrontendURL はメール本文に埋め込むフロントエンドのURLです。
+	FrontendURL      string
+	VerificationMode EmailVerificationMode
+	// DeletionGracePeriod は退会手続きから完全削除までの猶予期間です。
+	DeletionGracePeriod time.Duration
+	PasswordPolicy      password.Policy
+	Mailer              Mailer
+}
+
+// UserServiceOptionsFromConfig は設定から UserServiceOptions を作成します。
+// 漏洩パスワードの一覧を読み込めない場合はエラーを返します。
+func UserServiceOptionsFromConfig(cfg *config.Config) (UserServiceOptions, error) {
+	policy, err := cfg.Password.Policy()
+	if err != nil {
+		return UserServiceOptions{}, fmt.Errorf("failed to load password policy: %w", err)
+	}
+	if list, ok := policy.Breached.(*password.HashPrefixList); ok {
+		slog.Info("loaded breached password hashes", "count", list.Len(), "path", cfg.Password.BreachList)
+	}
+	return UserServiceOptions{
+		FrontendURL:         cfg.Server.FrontendURL,
+		VerificationMode:    EmailVerificationMode(cfg.Auth.EmailVerificationMode),
+		DeletionGracePeriod: time.Duration(cfg.Auth.AccountDeletionGraceDays) * 24 * time.Hour,
+		PasswordPolicy:      policy,
+		Mailer:              NewSMTPMailer(cfg.Mail),
+	}, nil
 }
 
 // NewUserService は新しいUserServiceを作成します。
-func NewUserService(userRepo *repositories.UserRepository, resetTokenRepo repositories.ResetTokenRepository) *UserService {
-	return &UserService{userRepo: userRepo, resetTokenRepo: resetTokenRepo}
+func NewUserService(userRepo repositories.UserStore, resetTokenRepo repositories.ResetTokenRepository, verifyTokenRepo repositories.VerificationTokenRepository, magicLinkRepo repositories.MagicLinkTokenRepository, securityEventRepo repositories.SecurityEventRepository, opts UserServiceOptions) *UserService {
+	return &UserService{
+		userRepo:            userRepo,
+		resetTokenRepo:      resetTokenRepo,
+		verifyTokenRepo:     verifyTokenRepo,
+		magicLinkRepo:       magicLinkRepo,
+		securityEventRepo:   securityEventRepo,
+		verificationMode:    opts.VerificationMode,
+		passwordPolicy:      opts.PasswordPolicy,
+		frontendURL:         opts.FrontendURL,
+		deletionGracePeriod: opts.DeletionGracePeriod,
+		mailer:              opts.Mailer,
+	}
+}
+
+// ValidatePassword はパスワードがパスワードポリシーを満たすかを検証します。
+// 満たさない場合は違反の一覧を持つ *password.PolicyError を返します。
+func (s *UserService) ValidatePassword(pw, username, email string) error {
+	return s.passwordPolicy.Validate(pw, username, email)
 }
 
 // RegisterUser はユーザーを登録します。
-func (s *UserService) RegisterUser(req models.UserRegisterRequest) (*models.User, error) {
+func (s *UserService) RegisterUser(ctx context.Context, req models.UserRegisterRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterUser")
+	defer span.End()
+	if err := s.ValidatePassword(req.Password, req.Username, req.Email); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := repositories.HashPassword(req.Password)
 	if err != nil {
-		log.Printf("Failed to hash password: %v", err)
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
@@ -36,71 +109,143 @@ func (s *UserService) RegisterUser(req models.UserRegisterRequest) (*models.User
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: hashedPassword,
-		Role:         "user",
+		Role:         models.RoleUser,
 	}
 
-	createdUser, err := s.userRepo.Create(newUser)
+	createdUser, err := s.userRepo.Create(ctx, newUser)
 	if err != nil {
 		return nil, err
 	}
+
+	// 確認メールの送信に失敗しても登録自体は成功扱いにする（再送エンドポイントで再試行できる）
+	if err := s.sendVerification(ctx, createdUser); err != nil {
+		logging.FromContext(ctx).Warn("failed to send verification email", "user_id", createdUser.ID, "error", err)
+	}
+
 	createdUser.PasswordHash = "" // レスポンスにパスワードを含めない
 	return createdUser, nil
 }
 
 // AuthenticateUser はユーザーを認証し、成功したらユーザーを返します。
-func (s *UserService) AuthenticateUser(req models.UserLoginRequest) (*models.User, error) {
-	foundUser, err := s.userRepo.FindByEmail(req.Email)
+// 失敗した場合はセキュリティイベントに記録します（成功は RecordLogin で記録します）。
+func (s *UserService) AuthenticateUser(ctx context.Context, req models.UserLoginRequest, client models.ClientInfo) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.AuthenticateUser")
+	defer span.End()
+	foundUser, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			s.recordLoginFailure(ctx, nil, req.Email, LoginMethodPassword, "unknown_email", client)
+		}
 		return nil, err
 	}
 
 	if err := repositories.VerifyPassword(foundUser.PasswordHash, req.Password); err != nil {
+		s.recordLoginFailure(ctx, foundUser, req.Email, LoginMethodPassword, "invalid_password", client)
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
-	foundUser.PasswordHash = "" // レスポンスにパスワードを含めない
-	return foundUser, nil
+	if foundUser.DisabledAt != nil {
+		s.recordLoginFailure(ctx, foundUser, req.Email, LoginMethodPassword, "account_disabled", client)
+		return nil, ErrAccountDisabled
+	}
+
+	if err := s.CheckLoginVerification(ctx, foundUser, LoginMethodPassword, client); err != nil {
+		return nil, err
+	}
+
+	// ログインを拒否したアカウントのハッシュは書き換えないよう、無効化・メール確認のチェックの後で行う
+	if repositories.PasswordNeedsRehash(foundUser.PasswordHash) {
+		// 再ハッシュに失敗してもログインは成功させる（次回のログインで再試行される）
+		if err := s.rehashPassword(ctx, foundUser, req.Password); err != nil {
+			logging.FromContext(ctx).Warn("failed to rehash password", "user_id", foundUser.ID, "error", err)
+		}
+	}
+
+	return s.completeLogin(ctx, foundUser)
+}
+
+// rehashPassword は検証済みの平文パスワードを現在のハッシュ設定でハッシュし直して保存します。
+func (s *UserService) rehashPassword(ctx context.Context, user *models.User, plain string) error {
+	hashed, err := repositories.HashPassword(plain)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePasswordHash(ctx, uint(user.ID), user.PasswordHash, hashed); err != nil {
+		return err
+	}
+	user.PasswordHash = hashed
+	return nil
 }
 
-func (s *UserService) ForgotPasswordUser(email string) error {
+// CompleteExternalLogin は OIDC など外部の認証で本人確認したユーザーのログイン処理を完了します。
+// パスワードやマジックリンクでのログインと同じく、無効化されたアカウントと未確認のメールアドレスを拒否し、
+// 退会の猶予期間中であれば退会を取り消します。
+func (s *UserService) CompleteExternalLogin(ctx context.Context, user *models.User, method string, client models.ClientInfo) (*models.User, error) {
+	if user.DisabledAt != nil {
+		s.recordLoginFailure(ctx, user, user.Email, method, "account_disabled", client)
+		return nil, ErrAccountDisabled
+	}
+	if err := s.CheckLoginVerification(ctx, user, method, client); err != nil {
+		return nil, err
+	}
+	return s.completeLogin(ctx, user)
+}
+
+// completeLogin は認証に成功したユーザーのログイン処理を完了します。
+// 退会の猶予期間中にログインした場合は退会を取り消します。
+func (s *UserService) completeLogin(ctx context.Context, user *models.User) (*models.User, error) {
+	if user.DeletionAt != nil {
+		if err := s.userRepo.CancelDeletion(ctx, uint(user.ID)); err != nil {
+			return nil, fmt.Errorf("failed to cancel deletion: %w", err)
+		}
+		user.DeletionAt = nil
+	}
+
+	user.PasswordHash = "" // レスポンスにパスワードを含めない
+	return user, nil
+}
+
+// ForgotPasswordUser はパスワードリセット用のメールを送信します。
+func (s *UserService) ForgotPasswordUser(ctx context.Context, email string, client models.ClientInfo) error {
+	ctx, span := tracing.Start(ctx, "UserService.ForgotPasswordUser")
+	defer span.End()
 	// 1. ユーザーが存在するか確認
-	user, err := s.userRepo.FindByEmail(email)
+	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		// メール存在しない → バレないように成功扱い
-		log.Printf("email not found but returning OK: %s", email)
+		logging.FromContext(ctx).Info("password reset requested for unknown email", "email", email)
 		return nil
 	}
 
 	// 2. パスワードリセット用のトークンを生成
 	token, err := generateResetToken()
 	if err != nil {
-		log.Printf("Failed to generate reset token: %v", err)
 		return fmt.Errorf("failed to generate reset token: %w", err)
 	}
 
-	// 3. トークンをデータベースに保存（有効期限1時間）
+	// 3. 以前に発行したトークンを無効にし、新しいトークンをデータベースに保存（有効期限1時間）
+	if err := s.resetTokenRepo.InvalidateForUser(ctx, uint(user.ID)); err != nil {
+		return fmt.Errorf("failed to invalidate previous reset tokens: %w", err)
+	}
 	resetToken := &models.PasswordResetToken{
 		UserID:    uint(user.ID),
 		Token:     token,
 		ExpiresAt: time.Now().Add(1 * time.Hour),
 	}
-	err = s.resetTokenRepo.Save(resetToken)
+	err = s.resetTokenRepo.Save(ctx, resetToken)
 	if err != nil {
 		return fmt.Errorf("failed to save reset token: %w", err)
 	}
 
-	frontendURL := os.Getenv("FRONTEND_URL")
-	if frontendURL == "" {
-		frontendURL = "http://localhost:3000"
-	}
-	// 4. フロントのリセットURLにトークンをセット
+	s.recordEvent(ctx, models.EventPasswordResetRequested, uint(user.ID), 0, client, nil)
 
-	resetURL := fmt.Sprintf("%s/reset-password/%s", frontendURL, token)
+	// 4. フロントのリセットURLにトークンをセット
+	resetURL := fmt.Sprintf("%s/reset-password/%s", s.frontendURL, token)
 
 	// 5. メール送信
-	err = s.sendPasswordResetEmail(email, resetURL)
+	err = s.sendPasswordResetEmail(ctx, email, resetURL)
 	if err != nil {
-		log.Printf("failed to send reset email: %v", err)
+		logging.FromContext(ctx).Warn("failed to send password reset email", "user_id", user.ID, "error", err)
 	}
 
 	return nil
@@ -117,9 +262,11 @@ func generateResetToken() (string, error) {
 }
 
 // ResetPasswordUser はトークンを使ってパスワードをリセットします。
-func (s *UserService) ResetPasswordUser(token, newPassword string) error {
+func (s *UserService) ResetPasswordUser(ctx context.Context, token, newPassword string, client models.ClientInfo) error {
+	ctx, span := tracing.Start(ctx, "UserService.ResetPasswordUser")
+	defer span.End()
 	// 1. トークンを検証
-	resetToken, err := s.resetTokenRepo.FindByToken(token)
+	resetToken, err := s.resetTokenRepo.FindByToken(ctx, token)
 
 	if err != nil {
 		return fmt.Errorf("invalid or expired token")
@@ -134,50 +281,44 @@ func (s *UserService) ResetPasswordUser(token, newPassword string) error {
 		return fmt.Errorf("token already used")
 	}
 
+	user, err := s.userRepo.FindByID(ctx, resetToken.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid or expired token")
+	}
+	if err := s.ValidatePassword(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
 	// 3. パスワードをハッシュ化
 	hashedPassword, err := repositories.HashPassword(newPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// 4. ユーザーのパスワードを更新
-	err = s.userRepo.UpdatePassword(resetToken.UserID, hashedPassword)
-	if err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
+	// 4. トークンの消費とパスワードの更新を同時に行う
+	err = s.userRepo.ResetPasswordWithToken(ctx, resetToken.ID, resetToken.UserID, hashedPassword)
+	if err == repositories.ErrResetTokenNotFound {
+		// 検証後に別のリクエストで使用された、または期限が切れた
+		return fmt.Errorf("invalid or expired token")
 	}
-
-	// 5. トークンを使用済みにマーク
-	err = s.resetTokenRepo.MarkUsed(resetToken.ID)
 	if err != nil {
-		log.Printf("Failed to mark token as used: %v", err)
-		// 失敗しても続行
+		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	s.recordEvent(ctx, models.EventPasswordReset, resetToken.UserID, 0, client, nil)
 	return nil
 }
 
-func (s *UserService) sendPasswordResetEmail(email, resetURL string) error {
-	from := os.Getenv("SMTP_USER")
-	password := os.Getenv("SMTP_PASSWORD")
-	to := []string{email}
-
-	smtpHost := "sandbox.smtp.mailtrap.io"
-	smtpPort := "2525"
+// CleanupResetTokens は期限切れ・使用済みのパスワードリセットトークンを削除します。
+func (s *UserService) CleanupResetTokens(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "UserService.CleanupResetTokens")
+	defer span.End()
+	return s.resetTokenRepo.CleanupExpired(ctx)
+}
 
-	// 件名と本文
-	message := []byte(fmt.Sprintf(
-		"Subject: パスワードリセット\r\n\r\n以下のURLからパスワードを再設定してください。\r\n%s",
+func (s *UserService) sendPasswordResetEmail(ctx context.Context, email, resetURL string) error {
+	return s.mailer.Send(ctx, email, "パスワードリセット", fmt.Sprintf(
+		"以下のURLからパスワードを再設定してください。\r\n%s",
 		resetURL,
 	))
-
-	auth := smtp.PlainAuth("", from, password, smtpHost)
-
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, to, message)
-	if err != nil {
-		// Mailtrap が無くてもテストできるように成功扱いにする
-		log.Printf("Failed to send reset email: %v", err)
-		return nil
-	}
-
-	return nil
 }
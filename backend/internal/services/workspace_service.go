@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+)
+
+var (
+	// ErrInvalidWorkspaceID はワークスペースIDの形式が不正な場合のエラーです。
+	ErrInvalidWorkspaceID = errors.New("invalid workspace ID")
+	// ErrWorkspaceForbidden はワークスペース内で権限のない操作を行おうとした場合のエラーです。
+	ErrWorkspaceForbidden = errors.New("insufficient workspace permissions")
+)
+
+// WorkspaceService はワークスペースとメンバーシップに関するビジネスロジックを扱います。
+type WorkspaceService struct {
+	workspaceRepo repositories.WorkspaceRepository
+	userRepo      repositories.UserStore
+}
+
+// NewWorkspaceService は新しいWorkspaceServiceを作成します。
+func NewWorkspaceService(workspaceRepo repositories.WorkspaceRepository, userRepo repositories.UserStore) *WorkspaceService {
+	return &WorkspaceService{workspaceRepo: workspaceRepo, userRepo: userRepo}
+}
+
+// Resolve はリクエストで指定されたワークスペースのメンバーシップを返します。
+// workspaceID が空の場合はユーザーの既定のワークスペースを使用します。
+// メンバーでないワークスペースを指定した場合は repositories.ErrNotMember を返します。
+func (s *WorkspaceService) Resolve(ctx context.Context, userID int, workspaceID string) (*models.WorkspaceMember, error) {
+	if workspaceID == "" {
+		ws, err := s.DefaultWorkspace(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return s.workspaceRepo.FindMember(ctx, ws.ID, userID)
+	}
+	id, err := strconv.Atoi(workspaceID)
+	if err != nil || id <= 0 {
+		return nil, ErrInvalidWorkspaceID
+	}
+	return s.workspaceRepo.FindMember(ctx, id, userID)
+}
+
+// DefaultWorkspace はユーザーの既定のワークスペースを返します。
+// どのワークスペースにも所属していない場合は個人用のワークスペースを作成します。
+// 最初のリクエストが同時に届いても、個人用のワークスペースは1つだけ作成されます。
+func (s *WorkspaceService) DefaultWorkspace(ctx context.Context, userID int) (*models.Workspace, error) {
+	ws, err := s.workspaceRepo.FirstForUser(ctx, userID)
+	if err == nil {
+		return ws, nil
+	}
+	if err != repositories.ErrWorkspaceNotFound {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.FirstOrCreateForUser(ctx, userID, fmt.Sprintf("%s's workspace", user.Username))
+}
+
+// ListWorkspaces はユーザーが所属するワークスペースを返します。
+func (s *WorkspaceService) ListWorkspaces(ctx context.Context, userID int) ([]*models.Workspace, error) {
+	return s.workspaceRepo.ListForUser(ctx, userID)
+}
+
+// CreateWorkspace はワークスペースを作成し、作成者をオーナーにします。
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, userID int, name string) (*models.Workspace, error) {
+	return s.workspaceRepo.Create(ctx, name, userID)
+}
+
+// ListMembers はワークスペースのメンバー一覧を返します。メンバーであれば誰でも閲覧できます。
+func (s *WorkspaceService) ListMembers(ctx context.Context, actorID, workspaceID int) ([]*models.WorkspaceMember, error) {
+	if _, err := s.workspaceRepo.FindMember(ctx, workspaceID, actorID); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.ListMembers(ctx, workspaceID)
+}
+
+// AddMember はメールアドレスで指定したユーザーをワークスペースに追加します。
+func (s *WorkspaceService) AddMember(ctx context.Context, actorID, workspaceID int, email, role string) (*models.WorkspaceMember, error) {
+	actor, err := s.manager(ctx, actorID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.WorkspaceRoleOwner && actor.Role != models.WorkspaceRoleOwner {
+		return nil, ErrWorkspaceForbidden
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.workspaceRepo.AddMember(ctx, workspaceID, user.ID, role); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.FindMember(ctx, workspaceID, user.ID)
+}
+
+// UpdateMemberRole はメンバーのロールを変更します。オーナーの付与・変更はオーナーのみ行えます。
+// 最後のオーナーを別のロールに変更する場合は repositories.ErrLastOwner を返します。
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, actorID, workspaceID, userID int, role string) (*models.WorkspaceMember, error) {
+	actor, err := s.manager(ctx, actorID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.workspaceRepo.FindMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if (role == models.WorkspaceRoleOwner || target.Role == models.WorkspaceRoleOwner) && actor.Role != models.WorkspaceRoleOwner {
+		return nil, ErrWorkspaceForbidden
+	}
+
+	if err := s.workspaceRepo.UpdateMemberRole(ctx, workspaceID, userID, role); err != nil {
+		return nil, err
+	}
+	target.Role = role
+	return target, nil
+}
+
+// RemoveMember はメンバーをワークスペースから外します。自分自身はロールに関係なく脱退できます。
+// 最後のオーナーを外す場合は repositories.ErrLastOwner を返します。
+func (s *WorkspaceService) RemoveMember(ctx context.Context, actorID, workspaceID, userID int) error {
+	actor, err := s.workspaceRepo.FindMember(ctx, workspaceID, actorID)
+	if err != nil {
+		return err
+	}
+	target, err := s.workspaceRepo.FindMember(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if actorID != userID {
+		if !models.WorkspacePermissions(actor.Role).Has(models.PermWorkspaceMembersManage) {
+			return ErrWorkspaceForbidden
+		}
+		if target.Role == models.WorkspaceRoleOwner && actor.Role != models.WorkspaceRoleOwner {
+			return ErrWorkspaceForbidden
+		}
+	}
+	return s.workspaceRepo.RemoveMember(ctx, workspaceID, userID)
+}
+
+// manager はメンバー管理権限を持つ操作者のメンバーシップを返します。
+func (s *WorkspaceService) manager(ctx context.Context, actorID, workspaceID int) (*models.WorkspaceMember, error) {
+	actor, err := s.workspaceRepo.FindMember(ctx, workspaceID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.WorkspacePermissions(actor.Role).Has(models.PermWorkspaceMembersManage) {
+		return nil, ErrWorkspaceForbidden
+	}
+	return actor, nil
+}
@@ -13,8 +13,7 @@ import (
 )
 
 func TestAuthMiddleware_ValidToken(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	token, err := testutil.LoginAndGetToken(t, r, "normal_user@example.com", "password123")
 	require.NoError(t, err)
@@ -36,8 +35,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 }
 
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	req, _ := http.NewRequest("GET", "/api/protected", nil)
 	req.Header.Set("Authorization", "Bearer invalid.jwt.token") // 不正なトークン
@@ -49,12 +47,11 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	var response map[string]string
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Contains(t, response["error"], "Invalid or expired token")
+	assert.Contains(t, response["error"], "Invalid or expired jwt token")
 }
 
 func TestAuthMiddleware_NoToken(t *testing.T) {
-	db, r, _, _ := testutil.SetupTestDB(t)
-	defer db.Close()
+	r, _, _ := testutil.SetupMemoryRouter(t)
 
 	req, _ := http.NewRequest("GET", "/api/protected", nil) // トークンなし
 	w := httptest.NewRecorder()
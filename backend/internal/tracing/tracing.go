@@ -0,0 +1,137 @@
+// Package tracing は OpenTelemetry による分散トレースを扱います。
+//
+// スパンはリクエストごとに gin のミドルウェアで開始し、services のメソッドと SQL 文ごとの子スパンを context.Context で繋げます。
+// 呼び出し元とのトレースの受け渡しには W3C Trace Context（traceparent ヘッダー）を使います。
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-next-todo/backend/internal/config"
+)
+
+// instrumentationName はアプリケーションが作成するスパンの計装ライブラリ名です。
+const instrumentationName = "go-next-todo/backend"
+
+// Setup は設定に従ってスパンの送信先を作成し、グローバルな TracerProvider とプロパゲーターに設定します。
+// stdout エクスポーターは w に書き出します。返す関数は未送信のスパンを送ってから終了するため、プロセスの終了前に呼び出してください。
+// エクスポーターが none の場合もプロパゲーターは設定するため、呼び出し元から受け取ったトレースIDはログなどで使えます。
+func Setup(ctx context.Context, cfg config.Tracing, w io.Writer) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "otlp":
+		exporter, err = newOTLPExporter(ctx, cfg.Endpoint)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	provider := NewProvider(cfg.ServiceName, exporter)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider は exporter にスパンをまとめて送る TracerProvider を作成します。
+// HTTP サーバーのスパンの url.path は routePathProcessor でルートのパターンに置き換えます。
+func NewProvider(serviceName string, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	res, _ := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(routePathProcessor{}),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+}
+
+// newOTLPExporter は endpoint のコレクターに OTLP/HTTP でスパンを送るエクスポーターを作成します。
+// endpoint は OTEL_EXPORTER_OTLP_ENDPOINT と同じくベースURLで、パスに /v1/traces を付けて送ります。
+func newOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(path.Join("/", u.Path, "v1/traces")),
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// Start は name のスパンを開始し、スパンを持つ context を返します。呼び出し元は span.End を呼び出してください。
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// TraceID は ctx のスパンのトレースIDを返します。スパンがない場合は空文字列です。
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// untracedRoutes はスパンを記録しないルートです。プローブやスクレイプのたびにスパンが増えないようにします。
+var untracedRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Middleware はリクエストごとに、一致したルートのパターン（"/api/todos/:id" など）を名前にしたスパンを開始するミドルウェアです。
+// リクエストに traceparent ヘッダーがある場合は、呼び出し元のトレースの子スパンになります。
+func Middleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return !untracedRoutes[c.FullPath()]
+	}))
+}
+
+// routePathProcessor は HTTP サーバーのスパンの url.path を、一致したルートのパターン（http.route）に置き換える SpanProcessor です。
+// パスにはパスワードリセットやマジックリンクの使い捨てトークンが含まれるため、RequestLogger と同じくパターンだけを送ります。
+// どのルートにも一致しなかったリクエストの url.path は空文字列になります。
+type routePathProcessor struct{}
+
+func (routePathProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	var route string
+	hasPath := false
+	for _, kv := range s.Attributes() {
+		switch kv.Key {
+		case semconv.URLPathKey:
+			hasPath = true
+		case semconv.HTTPRouteKey:
+			route = kv.Value.AsString()
+		}
+	}
+	if hasPath {
+		s.SetAttributes(semconv.URLPath(route))
+	}
+}
+
+func (routePathProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (routePathProcessor) Shutdown(context.Context) error { return nil }
+
+func (routePathProcessor) ForceFlush(context.Context) error { return nil }
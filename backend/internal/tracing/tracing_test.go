@@ -0,0 +1,121 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"go-next-todo/backend/internal/config"
+	"go-next-todo/backend/internal/tracing"
+)
+
+// exportedSpan は stdout エクスポーターが出力するスパンのうち、テストで確認する項目です。
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		TraceID string
+		SpanID  string
+	}
+}
+
+func decodeSpans(t *testing.T, out *bytes.Buffer) map[string]exportedSpan {
+	t.Helper()
+	spans := map[string]exportedSpan{}
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var s exportedSpan
+		require.NoError(t, dec.Decode(&s))
+		spans[s.Name] = s
+	}
+	return spans
+}
+
+func TestMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	shutdown, err := tracing.Setup(context.Background(), config.Tracing{Exporter: "stdout", ServiceName: "test"}, &out)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tracing.Middleware("test"))
+	r.GET("/items/:id", func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "ItemService.Get")
+		span.End()
+		c.String(http.StatusOK, tracing.TraceID(c.Request.Context()))
+	})
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, traceID, w.Body.String())
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.NoError(t, shutdown(context.Background()))
+	spans := decodeSpans(t, &out)
+
+	// ルートのスパンは呼び出し元のトレースを引き継ぎ、サービスのスパンはその子になる
+	route, ok := spans["GET /items/:id"]
+	require.True(t, ok, "spans: %v", spans)
+	assert.Equal(t, traceID, route.SpanContext.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", route.Parent.SpanID)
+
+	service, ok := spans["ItemService.Get"]
+	require.True(t, ok)
+	assert.Equal(t, traceID, service.SpanContext.TraceID)
+	assert.Equal(t, route.SpanContext.SpanID, service.Parent.SpanID)
+
+	assert.NotContains(t, spans, "GET /healthz")
+}
+
+func TestMiddleware_DoesNotExportPathTokens(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider("test", exporter)
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tracing.Middleware("test"))
+	r.POST("/api/reset-password/:token", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	const token = "3f9a1c0e5b7d2468ace013579bdf2468"
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/reset-password/"+token, nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/unknown/"+token, nil))
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	for _, span := range spans {
+		assert.NotContains(t, span.Name, token)
+		for _, kv := range span.Attributes {
+			assert.NotContains(t, kv.Value.Emit(), token, "attribute %s of span %q", kv.Key, span.Name)
+		}
+	}
+	// url.path はルートのパターンに置き換わる
+	assert.Contains(t, spans[0].Attributes, attribute.String("url.path", "/api/reset-password/:token"))
+}
+
+func TestSetupWithoutExporter(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), config.Tracing{Exporter: "none", ServiceName: "test"}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
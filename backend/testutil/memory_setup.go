@@ -0,0 +1,113 @@
+package testutil
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"go-next-todo/backend/internal/models"
+	"go-next-todo/backend/internal/repositories"
+	"go-next-todo/backend/internal/routes"
+)
+
+// SentMail は RecordingMailer が受け取ったメールです。
+type SentMail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// RecordingMailer は送信する代わりにメールを記録する Mailer です。
+// トークンはハッシュだけが保存されるため、テストはメールに書かれたリンクからトークンを取り出します。
+type RecordingMailer struct {
+	mu   sync.Mutex
+	sent []SentMail
+}
+
+// Send はメールを記録します。
+func (m *RecordingMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, SentMail{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+// Sent はこれまでに記録したメールを送信順に返します。
+func (m *RecordingMailer) Sent() []SentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SentMail(nil), m.sent...)
+}
+
+// LastToken は to に最後に送られた、"/<path>/<トークン>" のリンクを含むメールからトークンを返します。
+// 例えば確認メールのトークンは LastToken(t, email, "verify-email") で取り出します。
+func (m *RecordingMailer) LastToken(t *testing.T, to, path string) string {
+	t.Helper()
+	sent := m.Sent()
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].To != to {
+			continue
+		}
+		_, rest, ok := strings.Cut(sent[i].Body, "/"+path+"/")
+		if !ok {
+			continue
+		}
+		if token, _, _ := strings.Cut(rest, "\r\n"); token != "" {
+			return strings.TrimSpace(token)
+		}
+	}
+	t.Fatalf("no mail with a /%s/ link was sent to %s", path, to)
+	return ""
+}
+
+// SetupMemoryRouter は MySQL の代わりにメモリ上のストアを使うルーターをセットアップします。
+// SetupTestDB と同じテストユーザー（normal_user@example.com / password123 と admin@example.com / adminpass）と
+// 共有ワークスペースを作成します。メールは送信せず、返り値の RecordingMailer に記録します。
+func SetupMemoryRouter(t *testing.T) (*gin.Engine, *repositories.MemoryStore, *RecordingMailer) {
+	store := repositories.NewMemoryStore()
+	userRepo := store.Users()
+
+	normalUser := createSeedUser(t, userRepo, "normal_user", "normal_user@example.com", "password123", models.RoleUser)
+	adminUser := createSeedUser(t, userRepo, "admin_user", "admin@example.com", "adminpass", models.RoleAdmin)
+
+	// SetupTestDB と同じくオーナーのいない共有ワークスペースを作成する
+	workspaces := store.Workspaces()
+	ws := workspaces.CreateWithoutOwner("Default Workspace")
+	require.Equal(t, DefaultWorkspaceID, ws.ID)
+	AddTestWorkspaceMember(t, userRepo, normalUser)
+	AddTestWorkspaceMember(t, userRepo, adminUser)
+
+	mailer := &RecordingMailer{}
+	r, err := routes.NewRouter(testConfig(t), routes.Stores{
+		Users:              userRepo,
+		Todos:              store.Todos(),
+		Roles:              store.Roles(),
+		ResetTokens:        store.ResetTokens(),
+		VerificationTokens: store.VerificationTokens(),
+		Workspaces:         workspaces,
+		OIDC:               store.OIDC(),
+		MagicLinkTokens:    store.MagicLinkTokens(),
+		Sessions:           store.Sessions(),
+		SecurityEvents:     store.SecurityEvents(),
+	}, mailer)
+	require.NoError(t, err)
+	return r, store, mailer
+}
+
+// createSeedUser はワークスペースに追加せずにテストユーザーを作成します。
+func createSeedUser(t *testing.T, userRepo repositories.UserStore, username, email, password, role string) *models.User {
+	hashedPassword, err := repositories.HashPassword(password)
+	require.NoError(t, err)
+	user, err := userRepo.Create(context.Background(), &models.User{
+		Username:     username,
+		Email:        email,
+		PasswordHash: hashedPassword,
+		Role:         role,
+	})
+	require.NoError(t, err)
+	return user
+}
@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -14,13 +15,13 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"go-next-todo/backend/internal/handlers"
+	"go-next-todo/backend/internal/config"
+	"go-next-todo/backend/internal/migrations"
 	"go-next-todo/backend/internal/models"
 	"go-next-todo/backend/internal/repositories"
 	"go-next-todo/backend/internal/routes"
 	"go-next-todo/backend/internal/services"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -58,41 +59,58 @@ func SetupTestDB(t *testing.T) (*sql.DB, *gin.Engine, *repositories.TodoReposito
 	if _, err := db.Exec("TRUNCATE TABLE todos"); err != nil {
 		log.Printf("Failed to truncate todos table (it might not exist yet): %v", err)
 	}
+	if _, err := db.Exec("TRUNCATE TABLE email_verification_tokens"); err != nil {
+		log.Printf("Failed to truncate email_verification_tokens table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE sessions"); err != nil {
+		log.Printf("Failed to truncate sessions table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE magic_link_tokens"); err != nil {
+		log.Printf("Failed to truncate magic_link_tokens table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE password_reset_tokens"); err != nil {
+		log.Printf("Failed to truncate password_reset_tokens table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE user_identities"); err != nil {
+		log.Printf("Failed to truncate user_identities table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE oidc_auth_requests"); err != nil {
+		log.Printf("Failed to truncate oidc_auth_requests table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE security_events"); err != nil {
+		log.Printf("Failed to truncate security_events table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE workspace_members"); err != nil {
+		log.Printf("Failed to truncate workspace_members table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE workspaces"); err != nil {
+		log.Printf("Failed to truncate workspaces table (it might not exist yet): %v", err)
+	}
 	if _, err := db.Exec("TRUNCATE TABLE users"); err != nil {
 		log.Printf("Failed to truncate users table (it might not exist yet): %v", err)
 	}
+	if _, err := db.Exec("TRUNCATE TABLE role_permissions"); err != nil {
+		log.Printf("Failed to truncate role_permissions table (it might not exist yet): %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE TABLE roles"); err != nil {
+		log.Printf("Failed to truncate roles table (it might not exist yet): %v", err)
+	}
 	if _, err := db.Exec("SET FOREIGN_KEY_CHECKS=1;"); err != nil {
 		log.Printf("Failed to enable foreign key checks: %v", err)
 	}
 
-	// ユーザーテーブルの作成
-	createUserTableSQL := `
-    	CREATE TABLE IF NOT EXISTS users (
-    		id INT AUTO_INCREMENT PRIMARY KEY,
-    		username VARCHAR(255) NOT NULL UNIQUE,
-    		email VARCHAR(255) NOT NULL UNIQUE,
-    		password_hash VARCHAR(255) NOT NULL,
-    		role ENUM('user', 'admin') NOT NULL DEFAULT 'user',
-    		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-    	);`
-	if _, err := db.Exec(createUserTableSQL); err != nil {
-		t.Fatalf("Failed to create users table: %v", err)
-	}
-
-	// ToDoテーブルの作成
-	createTodoTableSQL := `
-    	CREATE TABLE IF NOT EXISTS todos (
-    		id INT AUTO_INCREMENT PRIMARY KEY,
-    		user_id INT NOT NULL,
-    		title VARCHAR(255) NOT NULL,
-    		completed BOOLEAN NOT NULL DEFAULT FALSE,
-    		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-    	);`
-	if _, err := db.Exec(createTodoTableSQL); err != nil {
-		t.Fatalf("Failed to create todos table: %v", err)
+	// マイグレーションでテーブルを作成
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+
+	// 組み込みロールの投入
+	if err := services.NewRoleService(repositories.NewMySQLRoleRepo(db)).EnsureBuiltInRoles(); err != nil {
+		t.Fatalf("Failed to seed built-in roles: %v", err)
 	}
 
 	// テストユーザーの挿入
@@ -104,7 +122,8 @@ func SetupTestDB(t *testing.T) (*sql.DB, *gin.Engine, *repositories.TodoReposito
 		PasswordHash: hashedPasswordUser,
 		Role:         "user",
 	}
-	if _, err := userRepo.Create(&normalUser); err != nil {
+	createdNormalUser, err := userRepo.Create(context.Background(), &normalUser)
+	if err != nil {
 		log.Printf("Failed to create normal_user (might exist, or duplicate entry): %v", err)
 	}
 
@@ -115,10 +134,22 @@ func SetupTestDB(t *testing.T) (*sql.DB, *gin.Engine, *repositories.TodoReposito
 		PasswordHash: hashedPasswordAdmin,
 		Role:         "admin",
 	}
-	if _, err := userRepo.Create(&adminUser); err != nil {
+	createdAdminUser, err := userRepo.Create(context.Background(), &adminUser)
+	if err != nil {
 		log.Printf("Failed to create admin_user (might exist, or duplicate entry): %v", err)
 	}
 
+	// 共有ワークスペースの作成。テストユーザーは全員このワークスペースに所属します
+	if _, err := db.Exec("INSERT INTO workspaces (id, name) VALUES (?, ?)", DefaultWorkspaceID, "Default Workspace"); err != nil {
+		t.Fatalf("Failed to create default workspace: %v", err)
+	}
+	if createdNormalUser != nil {
+		AddTestWorkspaceMember(t, userRepo, createdNormalUser)
+	}
+	if createdAdminUser != nil {
+		AddTestWorkspaceMember(t, userRepo, createdAdminUser)
+	}
+
 	log.Println("Successfully set up test database!")
 
 	// Ginルーターのセットアップ
@@ -128,51 +159,33 @@ func SetupTestDB(t *testing.T) (*sql.DB, *gin.Engine, *repositories.TodoReposito
 	return db, router, todoRepo, userRepo
 }
 
-// setupTestRouter はテスト用のGinルーターとリポジトリをセットアップします。
+// SetupTestRouter はアプリケーションと同じルーターをテスト用の設定でセットアップします。
+// 設定は環境変数から読み込むため、テストは t.Setenv で設定を変えてから呼び出します。
 func SetupTestRouter(t *testing.T, db *sql.DB) *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	// リポジトリ
-	todoRepo := repositories.NewTodoRepository(db)
-	userRepo := repositories.NewUserRepository(db)
-	resetTokenRepo := repositories.NewMySQLResetTokenRepo(db)
-
-	// サービス
-	todoService := services.NewTodoService(todoRepo)
-	userService := services.NewUserService(userRepo, resetTokenRepo)
-	jwtService := services.NewJWTService()
-
-	// ハンドラー
-	userHandler := handlers.NewUserHandler(userService, jwtService)
-	todoHandler := handlers.NewTodoHandler(todoService)
-	r := gin.Default()
-
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	r.Use(cors.New(config))
-
-	// r.GET("/api/hello", routes.HelloHandler)
-	// r.GET("/api/dbcheck", func(c *gin.Context) { routes.DbCheckHandler(c, db) })
-
-	r.POST("/api/register", userHandler.RegisterHandler)
-	r.POST("/api/login", userHandler.LoginHandler)
+	r, err := routes.SetupRouter(db, testConfig(t))
+	if err != nil {
+		t.Fatalf("Failed to set up router: %v", err)
+	}
+	return r
+}
 
-	authorized := r.Group("/")
+// testConfig は環境変数から読み込んだテスト用の設定を返します。
+func testConfig(t *testing.T) *config.Config {
+	gin.SetMode(gin.TestMode)
 
-	authorized.Use(routes.AuthMiddleware(jwtService))
-	{
-		authorized.GET("/api/todos", todoHandler.GetTodosHandler)
-		authorized.GET("/api/todos/:id", todoHandler.GetTodoByIDHandler)
-		authorized.POST("/api/todos", todoHandler.CreateTodoHandler)
-		authorized.PUT("/api/todos/:id", todoHandler.UpdateTodoHandler)
-		authorized.DELETE("/api/todos/:id", todoHandler.DeleteTodoHandler)
-		authorized.GET("/api/protected", userHandler.ProtectedHandler)
+	cfg := config.Default()
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("Failed to load config from environment: %v", err)
 	}
-	return r
+	if cfg.Auth.JWTSecret == "" {
+		cfg.Auth.JWTSecret = "test-secret"
+	}
+	return cfg
 }
 
-func CreateTestUser(t *testing.T, userRepo *repositories.UserRepository, username, email, password, role string) *models.User {
+// CreateTestUser はユーザーを作成し、共有ワークスペースに追加します。
+// userRepo には SetupTestDB の UserRepository と SetupMemoryRouter の MemoryStore のどちらのユーザーストアも渡せます。
+func CreateTestUser(t *testing.T, userRepo repositories.UserStore, username, email, password, role string) *models.User {
 	hashedPassword, err := repositories.HashPassword(password)
 	require.NoError(t, err)
 
@@ -183,13 +196,40 @@ func CreateTestUser(t *testing.T, userRepo *repositories.UserRepository, usernam
 		Role:         role,
 	}
 
-	createdUser, err := userRepo.Create(&newUser)
+	createdUser, err := userRepo.Create(context.Background(), &newUser)
 	require.NoError(t, err)
 	require.NotNil(t, createdUser)
 	require.NotEqual(t, 0, createdUser.ID)
+	AddTestWorkspaceMember(t, userRepo, createdUser)
 	return createdUser
 }
 
+// DefaultWorkspaceID はテストユーザーが所属する共有ワークスペースのIDです。
+const DefaultWorkspaceID = 1
+
+// AddTestWorkspaceMember はユーザーを共有ワークスペースに追加します。
+// グローバルロールが admin のユーザーはワークスペースの admin、それ以外は member になります。
+func AddTestWorkspaceMember(t *testing.T, userRepo repositories.UserStore, user *models.User) {
+	role := models.WorkspaceRoleMember
+	if user.Role == models.RoleAdmin {
+		role = models.WorkspaceRoleAdmin
+	}
+	err := workspaceRepoFor(t, userRepo).AddMember(context.Background(), DefaultWorkspaceID, user.ID, role)
+	require.NoError(t, err)
+}
+
+// workspaceRepoFor は userRepo と同じデータを使う WorkspaceRepository を返します。
+func workspaceRepoFor(t *testing.T, userRepo repositories.UserStore) repositories.WorkspaceRepository {
+	switch repo := userRepo.(type) {
+	case *repositories.UserRepository:
+		return repositories.NewMySQLWorkspaceRepo(repo.DB)
+	case *repositories.MemoryUserStore:
+		return repo.Store().Workspaces()
+	}
+	t.Fatalf("unsupported user store %T", userRepo)
+	return nil
+}
+
 // createTestTodo はテスト用のTODOを作成し、データベースに保存します。
 func CreateTestTodo(t *testing.T, router *gin.Engine, token, title string, completed bool) *models.Todo {
 	todoPayload := map[string]interface{}{